@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/internal/controllers/events"
+	"github.com/oskargbc/dws-event-service.git/internal/services"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	importTenant     string
+	importOrganizer  string
+	importFormat     string
+	importDryRun     bool
+	importBestEffort bool
+
+	ImportEventsCmd = &cobra.Command{
+		Use:   "import-events FILE",
+		Short: "Import events from a CSV or JSON Lines file",
+		Long: `Creates events in the DRAFT state from a CSV or JSON Lines file, like
+POST /api/v1/events/import. Every row is validated like a CreateEventRequest.
+By default the events are created in one transaction and only when every row
+is valid; with --best-effort the valid rows are created and the invalid ones
+reported.`,
+		Example: "event-service import-events semester.csv --tenant ltu --organizer <organizer id> --dry-run",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImport(cmd, args[0])
+		},
+	}
+)
+
+func init() {
+	ImportEventsCmd.Flags().StringVar(&importTenant, "tenant", "", "slug of the tenant (default: tenancy.default_tenant)")
+	ImportEventsCmd.Flags().StringVar(&importOrganizer, "organizer", "", "ID of the organizer the events belong to")
+	ImportEventsCmd.Flags().StringVar(&importFormat, "format", "", "csv or jsonl (default: taken from the file extension)")
+	ImportEventsCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "only validate the rows")
+	ImportEventsCmd.Flags().BoolVar(&importBestEffort, "best-effort", false, "create the valid rows even when others are invalid")
+	_ = ImportEventsCmd.MarkFlagRequired("organizer")
+}
+
+func runImport(cmd *cobra.Command, path string) error {
+	format := importFormat
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			format = events.ImportFormatCSV
+		case ".jsonl", ".ndjson":
+			format = events.ImportFormatJSONL
+		default:
+			return fmt.Errorf("cannot tell the format of %s, set --format", path)
+		}
+	}
+	tenantSlug := importTenant
+	if tenantSlug == "" {
+		tenantSlug = configs.GetEnvConfig().Tenancy.DefaultTenant
+	}
+	if tenantSlug == "" {
+		return fmt.Errorf("--tenant is required when no default tenant is configured")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	dbService := services.GetDatabaseSeviceInstance()
+	defer dbService.DbDisconnect()
+	ctx := context.Background()
+
+	tenant, err := dbService.FindTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return fmt.Errorf("tenant %s: %w", tenantSlug, err)
+	}
+	if _, err := dbService.GetClient().Organizer.FindFirst(
+		db.Organizer.ID.Equals(importOrganizer),
+		db.Organizer.TenantID.Equals(tenant.ID),
+	).Exec(ctx); err != nil {
+		return fmt.Errorf("organizer %s of tenant %s: %w", importOrganizer, tenantSlug, err)
+	}
+
+	report, err := events.NewController().Import(ctx, file, events.ImportOptions{
+		Format:      format,
+		DryRun:      importDryRun,
+		BestEffort:  importBestEffort,
+		TenantID:    tenant.ID,
+		OrganizerID: importOrganizer,
+	})
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	for _, row := range report.Rows {
+		switch {
+		case len(row.Errors) > 0:
+			fmt.Fprintf(out, "line %d: %s: %s\n", row.Line, row.Status, row.Errors.Error())
+		case row.Error != "":
+			fmt.Fprintf(out, "line %d: %s: %s\n", row.Line, row.Status, row.Error)
+		case row.EventID != "":
+			fmt.Fprintf(out, "line %d: %s %s (%s)\n", row.Line, row.Status, row.EventID, row.Name)
+		default:
+			fmt.Fprintf(out, "line %d: %s (%s)\n", row.Line, row.Status, row.Name)
+		}
+	}
+	fmt.Fprintf(out, "%d rows: %d valid, %d invalid, %d created, %d failed\n",
+		report.Total, report.Valid, report.Invalid, report.Created, report.Failed)
+
+	if importDryRun && report.Invalid > 0 {
+		return fmt.Errorf("%d of %d rows are invalid", report.Invalid, report.Total)
+	}
+	if report.Invalid > 0 || report.Failed > 0 {
+		return fmt.Errorf("%d of %d rows were not imported", report.Invalid+report.Failed, report.Total)
+	}
+	return nil
+}
@@ -43,8 +43,9 @@ func init() {
 		run()
 	}
 
-	rootCmd.AddCommand(ServerStartCmd) // add server start command
-	rootCmd.AddCommand(VersionCmd)     // add version command
+	rootCmd.AddCommand(ServerStartCmd)  // add server start command
+	rootCmd.AddCommand(VersionCmd)      // add version command
+	rootCmd.AddCommand(ImportEventsCmd) // add event import command
 }
 
 var embedFs embed.FS
@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/eventchange"
 	"github.com/oskargbc/dws-event-service.git/internal/pkg/logger"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/waitlist"
 	"github.com/oskargbc/dws-event-service.git/internal/router"
 	"github.com/oskargbc/dws-event-service.git/internal/services"
 
@@ -62,6 +64,19 @@ func run() {
 		logger.Infoln("RabbitMQ connection verified successfully")
 	}
 
+	// Release the seats of expired capacity holds in the background
+	sweeperCtx, stopSweeper := context.WithCancel(context.Background())
+	defer stopSweeper()
+	sweeper := services.NewHoldSweeper(dbService, envConfig.Holds.SweepInterval)
+	// Freed seats go to the next students on the event's waitlist
+	promoter := waitlist.NewPromoter()
+	sweeper.OnRelease(func(ctx context.Context, eventID string) {
+		// The released seats change the event's availability
+		eventchange.Default().Announce("", eventID)
+		promoter.PromoteAfterRelease(ctx, eventID)
+	})
+	go sweeper.Run(sweeperCtx)
+
 	router := router.NewGinRouter(envConfig.Server.GinMode)
 
 	server := &http.Server{
@@ -0,0 +1,16 @@
+package configs
+
+import "time"
+
+// Branding configures the tenant branding served to white-label frontends
+type Branding struct {
+	// CacheTTL is how long branding is cached by the service and by clients (default: 5m).
+	// Other replicas may serve outdated branding for this long after a change.
+	CacheTTL time.Duration `mapstructure:"cache_ttl"`
+
+	// DefaultLocale is served for tenants without branding (default: en)
+	DefaultLocale string `mapstructure:"default_locale"`
+
+	// DefaultTimezone is served for tenants without branding (default: UTC)
+	DefaultTimezone string `mapstructure:"default_timezone"`
+}
@@ -0,0 +1,19 @@
+package configs
+
+// Calendar configures the iCalendar export and the subscribable calendar feeds
+type Calendar struct {
+	// FeedSecret signs the URLs of personal ("my events") feeds, which calendar
+	// apps fetch without a Keycloak token. Personal feeds are disabled when empty.
+	FeedSecret string `mapstructure:"feed_secret"`
+
+	// BaseURL is the public URL feed links are built from, e.g. https://events.example.com.
+	// Defaults to the scheme and host of the request.
+	BaseURL string `mapstructure:"base_url"`
+
+	// UIDDomain is the right-hand side of the UID of every exported event (default: the service slug).
+	// Changing it makes calendar apps treat all events as new ones.
+	UIDDomain string `mapstructure:"uid_domain"`
+
+	// HistoryDays is how long feeds keep events that have ended (default: 90)
+	HistoryDays int `mapstructure:"history_days"`
+}
@@ -15,6 +15,12 @@ type Config struct {
 	Supabase Supabase
 	Keycloak Keycloak
 	RabbitMQ RabbitMQ
+	Holds    Holds
+	Calendar Calendar
+	Tenancy  Tenancy
+	Branding Branding
+	Storage  Storage
+	Events   Events
 }
 
 var EnvConfig *Config
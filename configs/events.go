@@ -0,0 +1,54 @@
+package configs
+
+import "time"
+
+// Events configures the defaults of new events, bulk imports, the facets of
+// the listing, the preconditions of writes and the caching of reads
+type Events struct {
+	// DefaultTimezone is the IANA timezone of events created without one
+	// (default: Europe/Stockholm)
+	DefaultTimezone string `mapstructure:"default_timezone"`
+	// ImportMaxRows limits the rows of one bulk import (default: 1000)
+	ImportMaxRows int `mapstructure:"import_max_rows"`
+	// PriceBuckets are the bounds between the price ranges of the facets
+	// endpoint, in ascending order (default: 100, 250, 500)
+	PriceBuckets []float64 `mapstructure:"price_buckets"`
+	// IfMatchClients are the Keycloak client IDs, as found in the token's
+	// azp claim, whose writes to events must send If-Match
+	IfMatchClients []string `mapstructure:"if_match_clients"`
+	// CacheControl holds the Cache-Control policies of event reads
+	CacheControl EventsCacheControl `mapstructure:"cache_control"`
+	// Cache configures the in-process cache of event queries
+	Cache EventsCache `mapstructure:"cache"`
+}
+
+// EventsCache configures the cache of events, listings and modification
+// times kept by every replica. Entries are dropped when events are written,
+// by this replica or, through event.updated and event.deleted messages, by
+// any other.
+type EventsCache struct {
+	// Enabled turns the cache on
+	Enabled bool `mapstructure:"enabled"`
+	// TTL is how long an entry is kept; it bounds how late writes of other
+	// services that are not announced show (default: 10s)
+	TTL time.Duration `mapstructure:"ttl"`
+	// MaxEntries bounds the number of cached events and of cached listing
+	// pages each (default: 1000)
+	MaxEntries int `mapstructure:"max_entries"`
+}
+
+// EventsCacheControl holds the Cache-Control headers of event listings and
+// single events
+type EventsCacheControl struct {
+	// Lists is sent with listings that are the same for every user of the
+	// tenant, so a CDN or the API gateway may cache them
+	// (default: "public, max-age=30, stale-while-revalidate=30")
+	Lists string `mapstructure:"lists"`
+	// Events is sent with published events, which are the same for every
+	// user of the tenant; they carry live availability, so by default caches
+	// must revalidate them (default: "public, no-cache")
+	Events string `mapstructure:"events"`
+	// Private is sent with responses that depend on the caller, e.g. listings
+	// including an organiser's drafts (default: "private, no-cache")
+	Private string `mapstructure:"private"`
+}
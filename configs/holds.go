@@ -0,0 +1,18 @@
+package configs
+
+import "time"
+
+// Holds configures the capacity holds used by checkout flows
+type Holds struct {
+	// DefaultTTL is used when a hold is created without a TTL (default: 10m)
+	DefaultTTL time.Duration `mapstructure:"default_ttl"`
+
+	// MaxTTL is the longest TTL a client may request (default: 30m)
+	MaxTTL time.Duration `mapstructure:"max_ttl"`
+
+	// PromotionTTL is the TTL of holds created for promoted waitlist entries (default: 1h)
+	PromotionTTL time.Duration `mapstructure:"promotion_ttl"`
+
+	// SweepInterval is how often expired holds are released (default: 30s)
+	SweepInterval time.Duration `mapstructure:"sweep_interval"`
+}
@@ -20,6 +20,9 @@ type RabbitMQ struct {
 	// VirtualHost is the RabbitMQ virtual host (default: "/")
 	VirtualHost string `mapstructure:"virtual_host"`
 
+	// Exchange is the topic exchange domain messages such as waitlist.promoted and event.updated are published to (default: "events")
+	Exchange string `mapstructure:"exchange"`
+
 	// Enabled determines if RabbitMQ integration is enabled
 	Enabled bool `mapstructure:"enabled"`
 }
@@ -0,0 +1,49 @@
+package configs
+
+// Storage configures where uploaded files such as event images are stored
+type Storage struct {
+	// Backend is "local" (default) or "s3"
+	Backend string `mapstructure:"backend"`
+
+	// MaxImageBytes is the largest image that may be uploaded (default: 5 MiB)
+	MaxImageBytes int64 `mapstructure:"max_image_bytes"`
+
+	// ThumbnailWidths are the widths in pixels of the resized copies of every
+	// uploaded image (default: 320 and 800)
+	ThumbnailWidths []int `mapstructure:"thumbnail_widths"`
+
+	Local LocalStorage `mapstructure:"local"`
+	S3    S3Storage    `mapstructure:"s3"`
+}
+
+// LocalStorage configures the local-filesystem storage backend
+type LocalStorage struct {
+	// Dir is the directory files are written to (default: uploads)
+	Dir string `mapstructure:"dir"`
+
+	// BaseURL is prepended to the file keys to build their URLs (default: /uploads,
+	// where the service serves Dir itself)
+	BaseURL string `mapstructure:"base_url"`
+}
+
+// S3Storage configures the S3-compatible storage backend (AWS S3, MinIO or Supabase Storage)
+type S3Storage struct {
+	// Endpoint is the S3 API URL, e.g. https://s3.eu-north-1.amazonaws.com.
+	// Defaults to the S3 endpoint of the Supabase project in supabase.url.
+	Endpoint string `mapstructure:"endpoint"`
+
+	// Region is used to sign requests (default: us-east-1)
+	Region string `mapstructure:"region"`
+
+	// Bucket receives the files; it must allow public reads
+	Bucket string `mapstructure:"bucket"`
+
+	// AccessKeyID and SecretAccessKey are the S3 access key (for Supabase:
+	// Project Settings > Storage > S3 access keys)
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+
+	// PublicURL is prepended to the file keys to build their URLs. Defaults to
+	// the public bucket URL of the Supabase project, else to the bucket's URL at Endpoint.
+	PublicURL string `mapstructure:"public_url"`
+}
@@ -0,0 +1,16 @@
+package configs
+
+// Tenancy configures how requests are mapped to tenants of the white-label platform
+type Tenancy struct {
+	// Header carries the slug of the requested tenant (default: X-Tenant)
+	Header string `mapstructure:"header"`
+
+	// DefaultTenant is the slug used when neither the header, the host nor the
+	// token names a tenant. Such requests are rejected when empty.
+	DefaultTenant string `mapstructure:"default_tenant"`
+
+	// CrossTenantClients are the Keycloak clients whose tokens may be used
+	// with every tenant when they carry no tenant claim. Other tokens without
+	// the claim may only be used with the default tenant.
+	CrossTenantClients []string `mapstructure:"cross_tenant_clients"`
+}
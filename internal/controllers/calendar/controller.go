@@ -0,0 +1,311 @@
+package calendar
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/ical"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/internal/services"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+const (
+	// DefaultHistoryDays is used when the config does not set how long feeds keep ended events
+	DefaultHistoryDays = 90
+
+	// RefreshInterval is how often subscribed calendar apps are asked to reload a feed
+	RefreshInterval = time.Hour
+
+	// MaxFeedEvents limits the number of events in a single feed
+	MaxFeedEvents = 1000
+)
+
+// Controller serves the subscribable calendar feeds
+type Controller struct {
+	dbService   *services.DatabaseService
+	feedSecret  string
+	baseURL     string
+	uidDomain   string
+	historyDays int
+}
+
+// NewController creates a new calendar controller
+func NewController() *Controller {
+	calendar := configs.GetEnvConfig().Calendar
+	historyDays := calendar.HistoryDays
+	if historyDays <= 0 {
+		historyDays = DefaultHistoryDays
+	}
+	return &Controller{
+		dbService:   services.GetDatabaseSeviceInstance(),
+		feedSecret:  calendar.FeedSecret,
+		baseURL:     strings.TrimSuffix(calendar.BaseURL, "/"),
+		uidDomain:   ical.DomainFromConfig(),
+		historyDays: historyDays,
+	}
+}
+
+// FeedURL is the subscription link of a personal calendar feed
+type FeedURL struct {
+	URL       string `json:"url"`
+	WebcalURL string `json:"webcalUrl"`
+}
+
+// feedToken signs the personal feed of a user. The token never expires; it
+// changes only when the feed secret is rotated.
+func (cc *Controller) feedToken(userID string) string {
+	mac := hmac.New(sha256.New, []byte(cc.feedSecret))
+	mac.Write([]byte("calendar-feed:" + userID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// validFeedToken reports whether token grants access to the user's personal feed
+func (cc *Controller) validFeedToken(userID, token string) bool {
+	if cc.feedSecret == "" || token == "" {
+		return false
+	}
+	return hmac.Equal([]byte(cc.feedToken(userID)), []byte(token))
+}
+
+// requestBaseURL returns the configured base URL or, if none is set, the
+// scheme and host the request was sent to
+func (cc *Controller) requestBaseURL(c *gin.Context) string {
+	if cc.baseURL != "" {
+		return cc.baseURL
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// feedFilter selects the events a feed shows: everything but drafts, unless
+// it ended more than historyDays ago
+func (cc *Controller) feedFilter(now time.Time) []db.EventWhereParam {
+	return []db.EventWhereParam{
+		db.Event.Status.Not(db.EventStatusDraft),
+		endsAfter(now.AddDate(0, 0, -cc.historyDays)),
+	}
+}
+
+// endsAfter matches the events that end after t. Series match while they
+// have occurrences after t.
+func endsAfter(t time.Time) db.EventWhereParam {
+	return db.Event.Or(
+		db.Event.EndsAt.Gte(t),
+		db.Event.And(
+			db.Event.Not(db.Event.RecurrenceRule.EqualsOptional(nil)),
+			db.Event.Or(
+				db.Event.RecurrenceEndsAt.EqualsOptional(nil),
+				db.Event.RecurrenceEndsAt.Gte(t),
+			),
+		),
+	)
+}
+
+// findFeedEvents loads up to limit events matching when and where, in the given order
+func (cc *Controller) findFeedEvents(ctx context.Context, when db.EventWhereParam, where []db.EventWhereParam, order db.EventOrderByParam, limit int) ([]db.EventModel, error) {
+	return cc.dbService.GetClient().Event.FindMany(append([]db.EventWhereParam{when}, where...)...).With(
+		db.Event.Organizer.Fetch(),
+		db.Event.Category.Fetch(),
+	).OrderBy(
+		order,
+		db.Event.ID.Order(db.SortOrderAsc),
+	).Take(limit).Exec(ctx)
+}
+
+// writeFeed responds with a calendar of the current tenant's events matching where.
+// Upcoming and running events are fetched before ended ones, so a feed over
+// MaxFeedEvents drops the oldest history rather than the next events.
+func (cc *Controller) writeFeed(c *gin.Context, name string, where ...db.EventWhereParam) {
+	ctx := c.Request.Context()
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+
+	now := time.Now().UTC()
+	where = append(cc.feedFilter(now), where...)
+	where = append(where, db.Event.TenantID.Equals(tenantID))
+	events, err := cc.findFeedEvents(ctx, endsAfter(now), where, db.Event.StartsAt.Order(db.SortOrderAsc), MaxFeedEvents)
+	if err != nil {
+		problem.Internal(c, "Failed to fetch events", err)
+		return
+	}
+	if remaining := MaxFeedEvents - len(events); remaining > 0 {
+		ended, err := cc.findFeedEvents(ctx, db.Event.Not(endsAfter(now)), where, db.Event.EndsAt.Order(db.SortOrderDesc), remaining)
+		if err != nil {
+			problem.Internal(c, "Failed to fetch events", err)
+			return
+		}
+		events = append(ended, events...)
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].StartsAt.Before(events[j].StartsAt)
+		})
+	}
+
+	var recurring []string
+	for _, event := range events {
+		if _, ok := event.RecurrenceRule(); ok {
+			recurring = append(recurring, event.ID)
+		}
+	}
+	overrides := map[string][]db.EventOccurrenceOverrideModel{}
+	if len(recurring) > 0 {
+		found, err := cc.dbService.GetClient().EventOccurrenceOverride.FindMany(
+			db.EventOccurrenceOverride.EventID.In(recurring),
+		).Exec(ctx)
+		if err != nil {
+			problem.Internal(c, "Failed to fetch occurrences", err)
+			return
+		}
+		for _, override := range found {
+			overrides[override.EventID] = append(overrides[override.EventID], override)
+		}
+	}
+
+	calendar := ical.Calendar{
+		Name:            name,
+		RefreshInterval: RefreshInterval,
+	}
+	for i := range events {
+		calendar.Events = append(calendar.Events, ical.FromEvent(&events[i], overrides[events[i].ID], cc.uidDomain)...)
+	}
+
+	c.Data(http.StatusOK, ical.ContentType, []byte(calendar.String()))
+}
+
+// GetOrganizerFeed godoc
+// @Summary      Calendar feed of an organizer
+// @Description  Subscribable iCalendar feed with the organizer's events. Drafts are left out; cancelled events
+// @Description  stay in the feed with STATUS:CANCELLED so subscribed calendars remove them.
+// @Tags         calendar
+// @Produce      text/calendar
+// @Param        organizerId  path      string  true  "Organizer ID"
+// @Success      200  {string}  string
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /calendar/organizers/{organizerId}/events.ics [get]
+func (cc *Controller) GetOrganizerFeed(c *gin.Context) {
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	organizer, err := cc.dbService.GetClient().Organizer.FindFirst(
+		db.Organizer.ID.Equals(c.Param("organizerId")),
+		db.Organizer.TenantID.Equals(tenantID),
+	).Exec(c.Request.Context())
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			problem.Respond(c, http.StatusNotFound, "organizer_not_found", "Organizer not found", "")
+			return
+		}
+		problem.Internal(c, "Failed to fetch organizer", err)
+		return
+	}
+
+	cc.writeFeed(c, organizer.Name, db.Event.OrganizerID.Equals(organizer.ID))
+}
+
+// GetCategoryFeed godoc
+// @Summary      Calendar feed of a category
+// @Description  Subscribable iCalendar feed with the events of a category and its subcategories. Drafts are left out.
+// @Tags         calendar
+// @Produce      text/calendar
+// @Param        category  path      string  true  "Category slug"
+// @Success      200  {string}  string
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /calendar/categories/{category}/events.ics [get]
+func (cc *Controller) GetCategoryFeed(c *gin.Context) {
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	category, err := cc.dbService.GetClient().Category.FindUnique(
+		db.Category.TenantIDSlug(
+			db.Category.TenantID.Equals(tenantID),
+			db.Category.Slug.Equals(c.Param("category")),
+		),
+	).Exec(c.Request.Context())
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			problem.Respond(c, http.StatusNotFound, "category_not_found", "Category not found", "")
+			return
+		}
+		problem.Internal(c, "Failed to fetch category", err)
+		return
+	}
+
+	cc.writeFeed(c, category.Name, db.Event.Category.Where(db.Category.Or(
+		db.Category.ID.Equals(category.ID),
+		db.Category.ParentID.Equals(category.ID),
+	)))
+}
+
+// GetUserFeed godoc
+// @Summary      Personal calendar feed
+// @Description  Subscribable iCalendar feed with the events the user has confirmed seats for. Calendar apps
+// @Description  cannot send a Keycloak token, so the feed is protected by the signed token of the URL returned
+// @Description  by GET /api/v1/calendar/feed.
+// @Tags         calendar
+// @Produce      text/calendar
+// @Param        userId  path      string  true  "Keycloak user ID"
+// @Param        token   query     string  true  "Feed token"
+// @Success      200  {string}  string
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /calendar/users/{userId}/events.ics [get]
+func (cc *Controller) GetUserFeed(c *gin.Context) {
+	if cc.feedSecret == "" {
+		problem.Respond(c, http.StatusNotFound, "calendar_feeds_disabled", "Personal calendar feeds are disabled", "")
+		return
+	}
+
+	userID := c.Param("userId")
+	if !cc.validFeedToken(userID, c.Query("token")) {
+		problem.Respond(c, http.StatusForbidden, "invalid_feed_token", "Invalid feed token", "")
+		return
+	}
+
+	cc.writeFeed(c, "My events", db.Event.Holds.Some(
+		db.CapacityHold.HolderID.Equals(userID),
+		db.CapacityHold.Status.Equals(db.HoldStatusConfirmed),
+	))
+}
+
+// GetPersonalFeedURL godoc
+// @Summary      Get your calendar feed URL
+// @Description  Returns the subscription link of the caller's personal calendar feed, in https and webcal form.
+// @Description  Anyone with the link can read the feed, so it should be kept private.
+// @Tags         calendar
+// @Produce      json
+// @Success      200  {object}  FeedURL
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      503  {object}  map[string]interface{}
+// @Router       /calendar/feed [get]
+func (cc *Controller) GetPersonalFeedURL(c *gin.Context) {
+	userID, ok := middlewares.GetUserIDFromContext(c)
+	if !ok || userID == "" {
+		problem.Respond(c, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized", "")
+		return
+	}
+	if cc.feedSecret == "" {
+		problem.Respond(c, http.StatusServiceUnavailable, "calendar_feeds_disabled", "Personal calendar feeds are disabled",
+			"calendar.feed_secret is not configured")
+		return
+	}
+
+	feedURL := cc.requestBaseURL(c) + "/calendar/users/" + url.PathEscape(userID) +
+		"/events.ics?token=" + url.QueryEscape(cc.feedToken(userID))
+	webcalURL := "webcal" + feedURL[strings.Index(feedURL, "://"):]
+
+	c.JSON(http.StatusOK, FeedURL{
+		URL:       feedURL,
+		WebcalURL: webcalURL,
+	})
+}
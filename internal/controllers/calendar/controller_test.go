@@ -0,0 +1,119 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+)
+
+func TestFeedToken(t *testing.T) {
+	cc := &Controller{feedSecret: "secret"}
+	token := cc.feedToken("kc-user-123")
+
+	if !cc.validFeedToken("kc-user-123", token) {
+		t.Error("expected the user's token to be valid")
+	}
+	if cc.validFeedToken("kc-other", token) {
+		t.Error("expected the token not to grant access to another user's feed")
+	}
+	if cc.validFeedToken("kc-user-123", "") {
+		t.Error("expected an empty token to be invalid")
+	}
+
+	rotated := &Controller{feedSecret: "rotated"}
+	if rotated.validFeedToken("kc-user-123", token) {
+		t.Error("expected tokens to be invalidated by rotating the secret")
+	}
+	if disabled := (&Controller{}); disabled.validFeedToken("kc-user-123", disabled.feedToken("kc-user-123")) {
+		t.Error("expected no token to be valid without a feed secret")
+	}
+}
+
+func TestGetUserFeed_WithoutAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		cc     *Controller
+		query  string
+		expect int
+	}{
+		{"disabled", &Controller{}, "?token=abc", http.StatusNotFound},
+		{"missing token", &Controller{feedSecret: "secret"}, "", http.StatusForbidden},
+		{"wrong token", &Controller{feedSecret: "secret"}, "?token=abc", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		r := gin.New()
+		r.GET("/calendar/users/:userId/events.ics", tt.cc.GetUserFeed)
+
+		req := httptest.NewRequest("GET", "/calendar/users/kc-user-123/events.ics"+tt.query, nil)
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.expect {
+			t.Errorf("%s: expected status %d, got %d. body=%s", tt.name, tt.expect, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestGetPersonalFeedURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cc := &Controller{feedSecret: "secret", baseURL: "https://events.example.com"}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	ctx := context.WithValue(context.Background(), middlewares.UserIDKey, "kc-user-123")
+	c.Request = httptest.NewRequest("GET", "/api/v1/calendar/feed", nil).WithContext(ctx)
+
+	cc.GetPersonalFeedURL(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d. body=%s", w.Code, w.Body.String())
+	}
+	var feed FeedURL
+	if err := json.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	prefix := "https://events.example.com/calendar/users/kc-user-123/events.ics?token="
+	if !strings.HasPrefix(feed.URL, prefix) || !cc.validFeedToken("kc-user-123", strings.TrimPrefix(feed.URL, prefix)) {
+		t.Errorf("expected a signed feed URL, got %q", feed.URL)
+	}
+	if feed.WebcalURL != "webcal"+strings.TrimPrefix(feed.URL, "https") {
+		t.Errorf("expected the webcal form of the feed URL, got %q", feed.WebcalURL)
+	}
+}
+
+func TestGetPersonalFeedURL_Unavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		cc     *Controller
+		userID string
+		expect int
+	}{
+		{"anonymous", &Controller{feedSecret: "secret"}, "", http.StatusUnauthorized},
+		{"disabled", &Controller{}, "kc-user-123", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		ctx := context.WithValue(context.Background(), middlewares.UserIDKey, tt.userID)
+		c.Request = httptest.NewRequest("GET", "/api/v1/calendar/feed", nil).WithContext(ctx)
+
+		tt.cc.GetPersonalFeedURL(c)
+
+		if w.Code != tt.expect {
+			t.Errorf("%s: expected status %d, got %d. body=%s", tt.name, tt.expect, w.Code, w.Body.String())
+		}
+	}
+}
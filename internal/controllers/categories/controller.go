@@ -0,0 +1,390 @@
+package categories
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/eventchange"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+	"github.com/oskargbc/dws-event-service.git/internal/services"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// validSlug limits slugs to lowercase words joined by hyphens, e.g. "board-games"
+var validSlug = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Controller handles category-related HTTP requests
+type Controller struct {
+	dbService *services.DatabaseService
+	// changes announces edits to categories, which events embed
+	changes *eventchange.Publisher
+}
+
+// NewController creates a new categories controller
+func NewController() *Controller {
+	return &Controller{
+		dbService: services.GetDatabaseSeviceInstance(),
+		changes:   eventchange.Default(),
+	}
+}
+
+// ListCategoriesResponse holds the categories of the tenant ordered by name.
+// Subcategories carry the ID of their parent.
+type ListCategoriesResponse struct {
+	Categories []db.CategoryModel `json:"categories"`
+}
+
+// respondWriteError turns a failed create or update into a response. Slugs
+// are unique per tenant, so a violation is a conflict, not a server error.
+func respondWriteError(c *gin.Context, err error, message string) {
+	if _, ok := db.IsErrUniqueConstraint(err); ok {
+		problem.Respond(c, http.StatusConflict, "category_exists", "Category already exists",
+			"a category with this slug already exists")
+		return
+	}
+	if db.IsErrNotFound(err) {
+		problem.Respond(c, http.StatusNotFound, "category_not_found", "Category not found", "")
+		return
+	}
+	problem.Internal(c, message, err)
+}
+
+// bindJSON binds the request body to obj. Failed binding tags and values of
+// the wrong JSON type are reported as field errors. On failure the response is
+// already written and false is returned.
+func bindJSON(c *gin.Context, obj any) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+	if errs, ok := validation.FromError(err, obj); ok {
+		problem.Validation(c, errs)
+		return false
+	}
+	problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload", err.Error())
+	return false
+}
+
+// findCategory loads a category of the current tenant. On failure the
+// response is already written and false is returned.
+func (cc *Controller) findCategory(c *gin.Context, categoryID string) (*db.CategoryModel, bool) {
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	category, err := cc.dbService.GetClient().Category.FindFirst(
+		db.Category.ID.Equals(categoryID),
+		db.Category.TenantID.Equals(tenantID),
+	).Exec(c.Request.Context())
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			problem.Respond(c, http.StatusNotFound, "category_not_found", "Category not found", "")
+			return nil, false
+		}
+		problem.Internal(c, "Failed to fetch category", err)
+		return nil, false
+	}
+	return category, true
+}
+
+// checkSlug adds an error unless the slug is made of lowercase words joined
+// by hyphens
+func checkSlug(errs *validation.Errors, slug string) {
+	if !validSlug.MatchString(slug) {
+		errs.Add("slug", validation.CodeInvalid, "must consist of lowercase letters and digits separated by hyphens")
+	}
+}
+
+// checkParent adds an error unless parentID names a top-level category of
+// the tenant other than the category itself. Categories are at most two
+// levels deep, so a category that has subcategories cannot get a parent.
+func checkParent(errs *validation.Errors, categories []db.CategoryModel, categoryID, parentID string) {
+	for _, category := range categories {
+		if parent, ok := category.ParentID(); ok && categoryID != "" && parent == categoryID {
+			errs.Add("parentId", validation.CodeInvalid, "categories with subcategories cannot have a parent")
+			return
+		}
+	}
+	for _, category := range categories {
+		if category.ID != parentID {
+			continue
+		}
+		if category.ID == categoryID {
+			errs.Add("parentId", validation.CodeInvalid, "a category cannot be its own parent")
+		} else if _, ok := category.ParentID(); ok {
+			errs.Add("parentId", validation.CodeInvalid, "must refer to a top-level category")
+		}
+		return
+	}
+	errs.Add("parentId", validation.CodeUnknown, "does not refer to a category of the tenant")
+}
+
+// GetCategories godoc
+// @Summary      List categories
+// @Description  Returns the categories of the tenant ordered by name. Subcategories carry the ID of their parent.
+// @Tags         categories
+// @Produce      json
+// @Success      200  {object}  ListCategoriesResponse
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /categories [get]
+func (cc *Controller) GetCategories(c *gin.Context) {
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	categories, err := cc.dbService.FindCategories(c.Request.Context(), tenantID)
+	if err != nil {
+		problem.Internal(c, "Failed to fetch categories", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ListCategoriesResponse{Categories: append([]db.CategoryModel{}, categories...)})
+}
+
+// GetCategoryByID godoc
+// @Summary      Get category by ID
+// @Description  Returns a single category by its ID
+// @Tags         categories
+// @Produce      json
+// @Param        id   path      string  true  "Category ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /categories/{id} [get]
+func (cc *Controller) GetCategoryByID(c *gin.Context) {
+	category, ok := cc.findCategory(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// CreateCategoryRequest represents the JSON payload for creating a category
+// @Description  Category creation payload
+type CreateCategoryRequest struct {
+	// Slug identifies the category in URLs and filters, e.g. "board-games"; unique per tenant
+	Slug string `json:"slug" binding:"required,max=64"`
+	Name string `json:"name" binding:"required,max=100"`
+	// ParentID files the category under a top-level category
+	ParentID *string `json:"parentId" binding:"omitempty,min=1"`
+	// Icon is the name or URL of the icon the frontend shows
+	Icon *string `json:"icon" binding:"omitempty,max=200"`
+}
+
+// CreateCategory godoc
+// @Summary      Create a category
+// @Description  Creates a category events can be filed under. Admins only.
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        category  body      CreateCategoryRequest  true  "Category to create"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      422  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /categories [post]
+func (cc *Controller) CreateCategory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateCategoryRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	var errs validation.Errors
+	checkSlug(&errs, req.Slug)
+	if strings.TrimSpace(req.Name) == "" {
+		errs.Add("name", validation.CodeRequired, "must not be blank")
+	}
+	if req.ParentID != nil {
+		categories, err := cc.dbService.FindCategories(ctx, tenantID)
+		if err != nil {
+			problem.Internal(c, "Failed to fetch categories", err)
+			return
+		}
+		checkParent(&errs, categories, "", *req.ParentID)
+	}
+	if len(errs) > 0 {
+		problem.Validation(c, errs)
+		return
+	}
+
+	var optional []db.CategorySetParam
+	if req.ParentID != nil {
+		optional = append(optional, db.Category.Parent.Link(db.Category.ID.Equals(*req.ParentID)))
+	}
+	optional = append(optional, db.Category.Icon.SetIfPresent(req.Icon))
+
+	category, err := cc.dbService.GetClient().Category.CreateOne(
+		db.Category.Slug.Set(req.Slug),
+		db.Category.Name.Set(req.Name),
+		db.Category.Tenant.Link(db.Tenant.ID.Equals(tenantID)),
+		optional...,
+	).Exec(ctx)
+	if err != nil {
+		respondWriteError(c, err, "Failed to create category")
+		return
+	}
+	cc.changes.AnnounceTenant(tenantID)
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// PatchCategoryRequest represents the JSON payload for partially updating a
+// category. Omitted fields are left unchanged; an empty parentId or icon
+// removes it.
+// @Description  Category partial update payload
+type PatchCategoryRequest struct {
+	Slug     *string `json:"slug" binding:"omitempty,max=64"`
+	Name     *string `json:"name" binding:"omitempty,min=1,max=100"`
+	ParentID *string `json:"parentId"`
+	Icon     *string `json:"icon" binding:"omitempty,max=200"`
+}
+
+// setParams converts the provided fields into Prisma update parameters
+func (req *PatchCategoryRequest) setParams() []db.CategorySetParam {
+	var params []db.CategorySetParam
+	if req.Slug != nil {
+		params = append(params, db.Category.Slug.Set(*req.Slug))
+	}
+	if req.Name != nil {
+		params = append(params, db.Category.Name.Set(*req.Name))
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == "" {
+			params = append(params, db.Category.Parent.Unlink())
+		} else {
+			params = append(params, db.Category.Parent.Link(db.Category.ID.Equals(*req.ParentID)))
+		}
+	}
+	if req.Icon != nil {
+		if *req.Icon == "" {
+			params = append(params, db.Category.Icon.SetOptional(nil))
+		} else {
+			params = append(params, db.Category.Icon.Set(*req.Icon))
+		}
+	}
+	return params
+}
+
+// PatchCategory godoc
+// @Summary      Partially update a category
+// @Description  Updates only the provided fields of a category. Events keep their category when its slug or name
+// @Description  changes. Admins only.
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string                true  "Category ID"
+// @Param        category  body      PatchCategoryRequest  true  "Fields to update"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      422  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /categories/{id} [patch]
+func (cc *Controller) PatchCategory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req PatchCategoryRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	category, ok := cc.findCategory(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var errs validation.Errors
+	if req.Slug != nil {
+		checkSlug(&errs, *req.Slug)
+	}
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		errs.Add("name", validation.CodeRequired, "must not be blank")
+	}
+	if req.ParentID != nil && *req.ParentID != "" {
+		categories, err := cc.dbService.FindCategories(ctx, category.TenantID)
+		if err != nil {
+			problem.Internal(c, "Failed to fetch categories", err)
+			return
+		}
+		checkParent(&errs, categories, category.ID, *req.ParentID)
+	}
+	if len(errs) > 0 {
+		problem.Validation(c, errs)
+		return
+	}
+
+	params := append(req.setParams(), db.Category.UpdatedAt.Set(time.Now()))
+	updated, err := cc.dbService.GetClient().Category.FindUnique(
+		db.Category.ID.Equals(category.ID),
+	).Update(params...).Exec(ctx)
+	if err != nil {
+		respondWriteError(c, err, "Failed to update category")
+		return
+	}
+	cc.changes.AnnounceTenant(updated.TenantID)
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteCategory godoc
+// @Summary      Delete a category
+// @Description  Removes a category that has no events and no subcategories. Admins only.
+// @Tags         categories
+// @Param        id   path  string  true  "Category ID"
+// @Success      204
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /categories/{id} [delete]
+func (cc *Controller) DeleteCategory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	category, ok := cc.findCategory(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	children, err := cc.dbService.GetClient().Category.FindMany(
+		db.Category.ParentID.Equals(category.ID),
+	).Take(1).Exec(ctx)
+	if err != nil {
+		problem.Internal(c, "Failed to fetch categories", err)
+		return
+	}
+	if len(children) > 0 {
+		problem.Respond(c, http.StatusConflict, "category_has_subcategories", "Category has subcategories",
+			"delete or move its subcategories first")
+		return
+	}
+
+	events, err := cc.dbService.GetClient().Event.FindMany(
+		db.Event.CategoryID.Equals(category.ID),
+	).Take(1).Exec(ctx)
+	if err != nil {
+		problem.Internal(c, "Failed to fetch events", err)
+		return
+	}
+	if len(events) > 0 {
+		problem.Respond(c, http.StatusConflict, "category_in_use", "Category is in use",
+			"move its events to another category first")
+		return
+	}
+
+	if _, err := cc.dbService.GetClient().Category.FindUnique(
+		db.Category.ID.Equals(category.ID),
+	).Delete().Exec(ctx); err != nil {
+		problem.Internal(c, "Failed to delete category", err)
+		return
+	}
+	cc.changes.AnnounceTenant(category.TenantID)
+
+	c.Status(http.StatusNoContent)
+}
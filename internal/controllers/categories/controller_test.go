@@ -0,0 +1,63 @@
+package categories
+
+import (
+	"testing"
+
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+func newCategory(id string, parentID *string) db.CategoryModel {
+	var category db.CategoryModel
+	category.InnerCategory.ID = id
+	category.InnerCategory.ParentID = parentID
+	return category
+}
+
+func TestCheckSlug(t *testing.T) {
+	for _, slug := range []string{"music", "board-games", "5k-run"} {
+		var errs validation.Errors
+		if checkSlug(&errs, slug); len(errs) > 0 {
+			t.Errorf("expected %q to be valid, got %+v", slug, errs)
+		}
+	}
+	for _, slug := range []string{"", "Music", "board games", "-music", "music-", "board--games", "musik/jazz"} {
+		var errs validation.Errors
+		if checkSlug(&errs, slug); !errs.Has("slug") {
+			t.Errorf("expected %q to be rejected", slug)
+		}
+	}
+}
+
+func TestCheckParent(t *testing.T) {
+	music := "music"
+	categories := []db.CategoryModel{
+		newCategory("music", nil),
+		newCategory("jazz", &music),
+		newCategory("sports", nil),
+	}
+
+	tests := []struct {
+		name       string
+		categoryID string
+		parentID   string
+		wantCode   string
+	}{
+		{"new subcategory", "", "music", ""},
+		{"move top-level category", "sports", "music", ""},
+		{"unknown parent", "", "theatre", validation.CodeUnknown},
+		{"parent is a subcategory", "", "jazz", validation.CodeInvalid},
+		{"own parent", "sports", "sports", validation.CodeInvalid},
+		{"category with subcategories", "music", "sports", validation.CodeInvalid},
+	}
+	for _, tt := range tests {
+		var errs validation.Errors
+		checkParent(&errs, categories, tt.categoryID, tt.parentID)
+		if tt.wantCode == "" && len(errs) > 0 {
+			t.Errorf("%s: expected no errors, got %+v", tt.name, errs)
+		}
+		if tt.wantCode != "" && (len(errs) != 1 || errs[0].Field != "parentId" || errs[0].Code != tt.wantCode) {
+			t.Errorf("%s: expected a single %s error on parentId, got %+v", tt.name, tt.wantCode, errs)
+		}
+	}
+}
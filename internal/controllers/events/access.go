@@ -0,0 +1,181 @@
+package events
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// canManageEvent reports whether the authenticated user may modify the event.
+// Admins may modify any event; everyone else must be the Keycloak user linked
+// to the event's organizer. The event must be loaded with its organizer.
+func canManageEvent(c *gin.Context, event *db.EventModel) bool {
+	if middlewares.HasRole(c, middlewares.RoleAdmin) {
+		return true
+	}
+
+	userID, ok := middlewares.GetUserIDFromContext(c)
+	if !ok || userID == "" {
+		return false
+	}
+
+	organizer := event.RelationsEvent.Organizer
+	if organizer == nil {
+		return false
+	}
+
+	keycloakID, ok := organizer.KeycloakID()
+	return ok && keycloakID == userID
+}
+
+// authorizeEventWrite loads the event of the current tenant together with its
+// organizer and tags and checks that the caller may modify it. Events of other tenants
+// are reported as not found, so writes that follow by ID stay inside the
+// tenant. On failure the response is already written and false is returned.
+func (ec *Controller) authorizeEventWrite(c *gin.Context, eventID string) (*db.EventModel, bool) {
+	ctx := c.Request.Context()
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+
+	event, err := ec.dbService.GetClient().Event.FindFirst(
+		db.Event.ID.Equals(eventID),
+		db.Event.TenantID.Equals(tenantID),
+	).With(
+		db.Event.Organizer.Fetch(),
+		db.Event.Tags.Fetch(),
+	).Exec(ctx)
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			problem.Respond(c, http.StatusNotFound, "event_not_found", "Event not found", "")
+			return nil, false
+		}
+		problem.Internal(c, "Failed to fetch event", err)
+		return nil, false
+	}
+
+	if !canManageEvent(c, event) {
+		problem.Respond(c, http.StatusForbidden, "not_event_owner", "Only the event organizer or an admin may modify this event", "")
+		return nil, false
+	}
+
+	return event, true
+}
+
+// resolveOrganizer returns the organizer a new event belongs to. Admins must
+// name an organizer of the current tenant. Everyone else creates events for
+// the organizer linked to their Keycloak user in the current tenant and may
+// only name that one. On failure the response is already written and false is
+// returned.
+func (ec *Controller) resolveOrganizer(c *gin.Context, requested string) (string, bool) {
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+
+	if middlewares.HasRole(c, middlewares.RoleAdmin) {
+		if requested == "" {
+			problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload",
+				"organizerId is required for admins")
+			return "", false
+		}
+		organizer, err := ec.dbService.GetClient().Organizer.FindFirst(
+			db.Organizer.ID.Equals(requested),
+			db.Organizer.TenantID.Equals(tenantID),
+		).Exec(c.Request.Context())
+		if err != nil {
+			if db.IsErrNotFound(err) {
+				problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload",
+					"organizerId does not refer to an existing organizer")
+				return "", false
+			}
+			problem.Internal(c, "Failed to fetch organizer", err)
+			return "", false
+		}
+		return organizer.ID, true
+	}
+
+	organizer, ok := ec.ownOrganizer(c)
+	if !ok {
+		return "", false
+	}
+	if requested != "" && requested != organizer.ID {
+		problem.Respond(c, http.StatusForbidden, "not_own_organizer", "Events can only be created for your own organizer", "")
+		return "", false
+	}
+	return organizer.ID, true
+}
+
+// ownOrganizer returns the organizer linked to the caller's Keycloak user in
+// the current tenant. On failure the response is already written and false is
+// returned.
+func (ec *Controller) ownOrganizer(c *gin.Context) (*db.OrganizerModel, bool) {
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	userID, _ := middlewares.GetUserIDFromContext(c)
+	organizer, err := ec.dbService.GetClient().Organizer.FindUnique(
+		db.Organizer.TenantIDKeycloakID(
+			db.Organizer.TenantID.Equals(tenantID),
+			db.Organizer.KeycloakID.Equals(userID),
+		),
+	).Exec(c.Request.Context())
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			problem.Respond(c, http.StatusForbidden, "organizer_profile_missing", "No organizer profile",
+				"create one with POST /api/v1/organizers/me or claim an existing one with POST /api/v1/organizers/{id}/claim")
+			return nil, false
+		}
+		problem.Internal(c, "Failed to fetch organizer", err)
+		return nil, false
+	}
+	return organizer, true
+}
+
+// canViewEvent reports whether the authenticated user may see the event.
+// Drafts are only visible to the users who may manage them.
+func canViewEvent(c *gin.Context, event *db.EventModel) bool {
+	return event.Status != db.EventStatusDraft || canManageEvent(c, event)
+}
+
+// eventVisibility describes which events of the tenant the caller sees: all
+// of them for admins, else the published ones and those of organizers linked
+// to userID, if the caller is signed in
+type eventVisibility struct {
+	tenantID string
+	userID   string
+	all      bool
+}
+
+// visibilityOf returns the event visibility of the caller
+func visibilityOf(c *gin.Context) eventVisibility {
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	userID, _ := middlewares.GetUserIDFromContext(c)
+	return eventVisibility{
+		tenantID: tenantID,
+		userID:   userID,
+		all:      middlewares.HasRole(c, middlewares.RoleAdmin),
+	}
+}
+
+// visibleEventsFilter restricts event listings to the current tenant's
+// published events, plus the caller's own events in any state. Admins see
+// every event of the tenant.
+func visibleEventsFilter(c *gin.Context) []db.EventWhereParam {
+	visibility := visibilityOf(c)
+	inTenant := db.Event.TenantID.Equals(visibility.tenantID)
+
+	if visibility.all {
+		return []db.EventWhereParam{inTenant}
+	}
+
+	published := db.Event.Status.Equals(db.EventStatusPublished)
+
+	if visibility.userID == "" {
+		return []db.EventWhereParam{inTenant, published}
+	}
+
+	return []db.EventWhereParam{
+		inTenant,
+		db.Event.Or(
+			published,
+			db.Event.Organizer.Where(db.Organizer.KeycloakID.Equals(visibility.userID)),
+		),
+	}
+}
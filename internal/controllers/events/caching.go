@@ -0,0 +1,254 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/cache"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/httpcache"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// Cache-Control policies used when events.cache_control is not configured
+const (
+	DefaultListsCacheControl   = "public, max-age=30, stale-while-revalidate=30"
+	DefaultEventsCacheControl  = "public, no-cache"
+	DefaultPrivateCacheControl = "private, no-cache"
+)
+
+// tenantStateSQL fingerprints everything listings of the tenant are built
+// from: its events, its categories, and its organizers, as their linked users
+// see their drafts. Every write, including seat changes, sets the row's
+// updatedAt, and deletions change the count, so the latest updatedAt and the
+// number of rows of each table change with every write. Both are read from
+// the (tenantId, updatedAt) indexes.
+const tenantStateSQL = `
+SELECT
+    (SELECT COALESCE(MAX(e."updatedAt")::text, '') || ':' || COUNT(*)
+     FROM "public"."Event" e WHERE e."tenantId" = $1) AS "events",
+    (SELECT COALESCE(MAX(c."updatedAt")::text, '') || ':' || COUNT(*)
+     FROM "public"."Category" c WHERE c."tenantId" = $1) AS "categories",
+    (SELECT COALESCE(MAX(o."updatedAt")::text, '') || ':' || COUNT(*)
+     FROM "public"."Organizer" o WHERE o."tenantId" = $1) AS "organizers",
+    GREATEST(
+        (SELECT MAX("updatedAt") FROM "public"."Event" WHERE "tenantId" = $1),
+        (SELECT MAX("updatedAt") FROM "public"."Category" WHERE "tenantId" = $1)
+    ) AS "lastModified"`
+
+// cachePolicies are the Cache-Control headers of event reads and the request
+// headers responses vary on
+type cachePolicies struct {
+	lists   string
+	events  string
+	private string
+	vary    string
+}
+
+// newCachePolicies returns the configured policies. Responses vary on the
+// tenant header, which selects the tenant, and on Authorization, as the
+// token may select the tenant and decides which drafts are visible.
+func newCachePolicies(cfg configs.EventsCacheControl, tenantHeader string) cachePolicies {
+	if tenantHeader == "" {
+		tenantHeader = middlewares.DefaultTenantHeader
+	}
+	policies := cachePolicies{
+		lists:   cfg.Lists,
+		events:  cfg.Events,
+		private: cfg.Private,
+		vary:    tenantHeader + ", Authorization",
+	}
+	if policies.lists == "" {
+		policies.lists = DefaultListsCacheControl
+	}
+	if policies.events == "" {
+		policies.events = DefaultEventsCacheControl
+	}
+	if policies.private == "" {
+		policies.private = DefaultPrivateCacheControl
+	}
+	return policies
+}
+
+// personalised reports whether the caller's listings may differ from what
+// other users of the tenant see: admins see every event and organisers
+// their own drafts
+func personalised(c *gin.Context) bool {
+	return middlewares.HasRole(c, middlewares.RoleAdmin) || middlewares.HasRole(c, middlewares.RoleOrganiser)
+}
+
+// listPolicy returns the Cache-Control of a listing for the caller
+func (ec *Controller) listPolicy(c *gin.Context) string {
+	if personalised(c) {
+		return ec.cachePolicies.private
+	}
+	return ec.cachePolicies.lists
+}
+
+// setValidators writes the headers a cache needs to store and revalidate a
+// response
+func (p cachePolicies) setValidators(c *gin.Context, policy, etag string, lastModified time.Time) {
+	c.Header("Cache-Control", policy)
+	c.Header("Vary", p.vary)
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", httpcache.FormatTime(lastModified))
+	}
+}
+
+// tenantState fingerprints the rows listings of a tenant are built from
+type tenantState struct {
+	Events       string     `json:"events"`
+	Categories   string     `json:"categories"`
+	Organizers   string     `json:"organizers"`
+	LastModified *time.Time `json:"lastModified"`
+}
+
+// listValidators returns the entity tag and modification time of a listing.
+// Listings hold nothing but the tenant's events with their categories and
+// tags, so they change only when one of those does: the tag is derived from
+// the tenant's state, the URL and who is asking, without running the query.
+func (ec *Controller) listValidators(c *gin.Context) (string, time.Time, error) {
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	state, err := ec.tenantState(c.Request.Context(), tenantID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	var lastModified time.Time
+	if state.LastModified != nil {
+		lastModified = *state.LastModified
+	}
+
+	digest := httpcache.Digest(
+		[]byte(state.Events),
+		[]byte(state.Categories),
+		[]byte(state.Organizers),
+		[]byte(tenantID),
+		[]byte(c.Request.URL.Path),
+		[]byte(c.Request.URL.RawQuery),
+		[]byte(listViewer(c)),
+	)
+	return `W/"` + digest + `"`, lastModified, nil
+}
+
+// listViewer names whose listings the caller gets. Signed-in users also see
+// the drafts of organizers linked to them.
+func listViewer(c *gin.Context) string {
+	if middlewares.HasRole(c, middlewares.RoleAdmin) {
+		return "admin"
+	}
+	if userID, ok := middlewares.GetUserIDFromContext(c); ok && userID != "" {
+		return userID
+	}
+	return "public"
+}
+
+// tenantState returns the state of the tenant's events, categories and
+// organizers
+func (ec *Controller) tenantState(ctx context.Context, tenantID string) (tenantState, error) {
+	var states *cache.Cache[tenantState]
+	if ec.readCache != nil {
+		states = ec.readCache.states
+	}
+	return load(ctx, states, tenantID, func(ctx context.Context) (tenantState, error) {
+		var rows []tenantState
+		if err := ec.dbService.GetClient().Prisma.QueryRaw(tenantStateSQL, tenantID).Exec(ctx, &rows); err != nil {
+			return tenantState{}, err
+		}
+		if len(rows) == 0 {
+			return tenantState{}, nil
+		}
+		return rows[0], nil
+	})
+}
+
+// checkListFresh answers a conditional listing request with 304 if nothing
+// changed since the client's copy. Otherwise it writes the validators and
+// returns false, so the listing is built; on errors the response is already
+// written and true is returned as well.
+func (ec *Controller) checkListFresh(c *gin.Context) bool {
+	etag, lastModified, err := ec.listValidators(c)
+	if err != nil {
+		problem.Internal(c, "Failed to fetch events", err)
+		return true
+	}
+	ec.cachePolicies.setValidators(c, ec.listPolicy(c), etag, lastModified)
+	if httpcache.NotModified(c.Request, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// respondList writes a listing. A listing that turns out to contain events
+// other users cannot see is never cached publicly.
+func (ec *Controller) respondList(c *gin.Context, response ListEventsResponse) {
+	for _, item := range response.Events {
+		if item.Status != db.EventStatusPublished {
+			c.Header("Cache-Control", ec.cachePolicies.private)
+			break
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// detailLastModified returns when the detail view of an event last changed:
+// the latest change to the event, its organizer, category or ticket types, or
+// the latest opening or closing of a ticket sale
+func detailLastModified(event *db.EventModel, now time.Time) time.Time {
+	lastModified := event.UpdatedAt
+	later := func(t time.Time) {
+		if t.After(lastModified) && !t.After(now) {
+			lastModified = t
+		}
+	}
+	if organizer := event.RelationsEvent.Organizer; organizer != nil {
+		later(organizer.UpdatedAt)
+	}
+	if category := event.RelationsEvent.Category; category != nil {
+		later(category.UpdatedAt)
+	}
+	for _, ticketType := range event.RelationsEvent.TicketTypes {
+		later(ticketType.UpdatedAt)
+		if start, ok := ticketType.SaleStartsAt(); ok {
+			later(start)
+		}
+		if end, ok := ticketType.SaleEndsAt(); ok {
+			later(end)
+		}
+	}
+	return lastModified
+}
+
+// respondDetail writes the detail view of an event, or 304 if the client's
+// copy is current. Its entity tag is the event's version followed by a hash
+// of the body, so it changes with the availability as well while If-Match
+// still only compares the version.
+func (ec *Controller) respondDetail(c *gin.Context, event *db.EventModel, now time.Time) {
+	body, err := json.Marshal(newEventDetail(*event, now))
+	if err != nil {
+		problem.Internal(c, "Failed to encode event", err)
+		return
+	}
+	policy := ec.cachePolicies.private
+	if event.Status == db.EventStatusPublished {
+		policy = ec.cachePolicies.events
+	}
+	etag := `"` + strconv.Itoa(event.Version) + "-" + httpcache.Digest(body) + `"`
+	lastModified := detailLastModified(event, now)
+
+	ec.cachePolicies.setValidators(c, policy, etag, lastModified)
+	if httpcache.NotModified(c.Request, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
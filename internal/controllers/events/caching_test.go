@@ -0,0 +1,109 @@
+package events
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/httpcache"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+func TestNewCachePolicies(t *testing.T) {
+	policies := newCachePolicies(configs.EventsCacheControl{Lists: "public, max-age=60"}, "X-University")
+
+	if policies.lists != "public, max-age=60" {
+		t.Errorf("expected the configured list policy, got %q", policies.lists)
+	}
+	if policies.events != DefaultEventsCacheControl || policies.private != DefaultPrivateCacheControl {
+		t.Errorf("expected the default policies, got %q and %q", policies.events, policies.private)
+	}
+	if policies.vary != "X-University, Authorization" {
+		t.Errorf("expected to vary on the configured tenant header and the token, got %q", policies.vary)
+	}
+}
+
+func TestDetailLastModified(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	saleStart := now.Add(-time.Hour)
+	saleEnd := now.Add(time.Hour)
+
+	var event db.EventModel
+	event.InnerEvent.UpdatedAt = now.Add(-3 * time.Hour)
+	var ticketType db.TicketTypeModel
+	ticketType.InnerTicketType.UpdatedAt = now.Add(-2 * time.Hour)
+	ticketType.InnerTicketType.SaleStartsAt = &saleStart
+	ticketType.InnerTicketType.SaleEndsAt = &saleEnd
+	event.RelationsEvent.TicketTypes = []db.TicketTypeModel{ticketType}
+
+	// The sale opening counts, its closing is still ahead
+	if got := detailLastModified(&event, now); !got.Equal(saleStart) {
+		t.Errorf("expected %v, got %v", saleStart, got)
+	}
+	if got := detailLastModified(&event, saleEnd); !got.Equal(saleEnd) {
+		t.Errorf("expected %v, got %v", saleEnd, got)
+	}
+}
+
+func TestRespondDetail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ec := &Controller{cachePolicies: newCachePolicies(configs.EventsCacheControl{}, "")}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	var event db.EventModel
+	event.InnerEvent.ID = "evt-1"
+	event.InnerEvent.Version = 3
+	event.InnerEvent.Status = db.EventStatusPublished
+	event.InnerEvent.UpdatedAt = now.Add(-time.Hour)
+
+	respond := func(header, value string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/events/evt-1", nil)
+		if header != "" {
+			c.Request.Header.Set(header, value)
+		}
+		ec.respondDetail(c, &event, now)
+		// The engine writes the status of bodiless responses after the handler
+		c.Writer.WriteHeaderNow()
+		return w
+	}
+
+	w := respond("", "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || !strings.HasPrefix(etag, `"3-`) {
+		t.Fatalf("expected 200 with a version 3 ETag, got %d and %q", w.Code, etag)
+	}
+	if got := w.Header().Get("Cache-Control"); got != DefaultEventsCacheControl {
+		t.Errorf("expected the public policy, got %q", got)
+	}
+	if got := w.Header().Get("Vary"); got != "X-Tenant, Authorization" {
+		t.Errorf("expected to vary on the tenant header and the token, got %q", got)
+	}
+	if !matchesETag(etag, eventETag(&event)) {
+		t.Errorf("expected %s to satisfy If-Match of version 3", etag)
+	}
+
+	if w := respond("If-None-Match", etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("expected an empty 304, got %d", w.Code)
+	}
+	if w := respond("If-Modified-Since", httpcache.FormatTime(now)); w.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for a later copy, got %d", w.Code)
+	}
+
+	// Availability is part of the body, so selling a seat changes the tag
+	event.InnerEvent.Capacity = 10
+	event.InnerEvent.SeatsSold = 1
+	if w := respond("If-None-Match", etag); w.Code != http.StatusOK {
+		t.Errorf("expected 200 after a sale, got %d", w.Code)
+	}
+
+	event.InnerEvent.Status = db.EventStatusDraft
+	if got := respond("", "").Header().Get("Cache-Control"); got != DefaultPrivateCacheControl {
+		t.Errorf("expected drafts to be private, got %q", got)
+	}
+}
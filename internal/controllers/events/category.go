@@ -0,0 +1,89 @@
+package events
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// CategoryStore looks up the categories events of a tenant may be filed under
+type CategoryStore interface {
+	FindCategories(ctx context.Context, tenantID string) ([]db.CategoryModel, error)
+}
+
+// categoryIndex holds the categories of a tenant for resolving the category
+// of event payloads
+type categoryIndex []db.CategoryModel
+
+// byID returns the category with the given ID
+func (ci categoryIndex) byID(id string) (*db.CategoryModel, bool) {
+	for i := range ci {
+		if ci[i].ID == id {
+			return &ci[i], true
+		}
+	}
+	return nil, false
+}
+
+// byName returns the category whose slug or display name matches ref
+// case-insensitively
+func (ci categoryIndex) byName(ref string) (*db.CategoryModel, bool) {
+	ref = strings.TrimSpace(ref)
+	for i := range ci {
+		if strings.EqualFold(ci[i].Slug, ref) || strings.EqualFold(ci[i].Name, ref) {
+			return &ci[i], true
+		}
+	}
+	return nil, false
+}
+
+func (ci categoryIndex) slugs() []string {
+	slugs := make([]string, len(ci))
+	for i, category := range ci {
+		slugs[i] = category.Slug
+	}
+	return slugs
+}
+
+// resolveCategory returns the ID of the category a payload files the event
+// under: categoryId, or else the deprecated category field naming a category
+// by slug or display name. When neither is set or the category does not
+// exist, the error is added to errs and "" is returned.
+func resolveCategory(errs *validation.Errors, categories categoryIndex, id, name string) string {
+	if errs.Has("categoryId") || errs.Has("category") {
+		return ""
+	}
+	if id != "" {
+		if category, ok := categories.byID(id); ok {
+			return category.ID
+		}
+		errs.Add("categoryId", validation.CodeUnknown, "does not refer to a category of the tenant")
+		return ""
+	}
+	if name != "" {
+		if category, ok := categories.byName(name); ok {
+			return category.ID
+		}
+		errs.Add("category", validation.CodeUnknown, "must be one of "+strings.Join(categories.slugs(), ", "))
+		return ""
+	}
+	errs.Add("categoryId", validation.CodeRequired, "is required")
+	return ""
+}
+
+// tenantCategories returns the categories of the current tenant. On failure
+// the response is already written and false is returned.
+func (ec *Controller) tenantCategories(c *gin.Context) (categoryIndex, bool) {
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	categories, err := ec.categoryStore.FindCategories(c.Request.Context(), tenantID)
+	if err != nil {
+		problem.Internal(c, "Failed to fetch categories", err)
+		return nil, false
+	}
+	return categories, true
+}
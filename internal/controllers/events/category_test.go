@@ -0,0 +1,66 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// fakeCategoryStore serves the same categories to every tenant
+type fakeCategoryStore []db.CategoryModel
+
+func (s fakeCategoryStore) FindCategories(ctx context.Context, tenantID string) ([]db.CategoryModel, error) {
+	return s, nil
+}
+
+func newCategory(id, slug, name string) db.CategoryModel {
+	var category db.CategoryModel
+	category.InnerCategory.ID = id
+	category.InnerCategory.Slug = slug
+	category.InnerCategory.Name = name
+	return category
+}
+
+// testCategories are the categories payloads in the tests refer to
+var testCategories = fakeCategoryStore{
+	newCategory("cat-music", "music", "Music"),
+	newCategory("cat-party", "party", "Party"),
+	newCategory("cat-workshop", "workshop", "Workshop"),
+	newCategory("cat-board-games", "board-games", "Board games"),
+}
+
+func TestResolveCategory(t *testing.T) {
+	categories := categoryIndex(testCategories)
+
+	tests := []struct {
+		name      string
+		id        string
+		category  string
+		want      string
+		wantField string
+		wantCode  string
+	}{
+		{"by ID", "cat-party", "", "cat-party", "", ""},
+		{"ID wins over name", "cat-party", "music", "cat-party", "", ""},
+		{"by slug", "", "board-games", "cat-board-games", "", ""},
+		{"by display name", "", " board GAMES ", "cat-board-games", "", ""},
+		{"unknown ID", "cat-parties", "", "", "categoryId", validation.CodeUnknown},
+		{"unknown name", "", "Parties", "", "category", validation.CodeUnknown},
+		{"missing", "", "", "", "categoryId", validation.CodeRequired},
+	}
+	for _, tt := range tests {
+		var errs validation.Errors
+		got := resolveCategory(&errs, categories, tt.id, tt.category)
+		if got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+		if tt.wantField == "" && len(errs) > 0 {
+			t.Errorf("%s: expected no errors, got %+v", tt.name, errs)
+		}
+		if tt.wantField != "" && (len(errs) != 1 || errs[0].Field != tt.wantField || errs[0].Code != tt.wantCode) {
+			t.Errorf("%s: expected a single %s error on %s, got %+v", tt.name, tt.wantCode, tt.wantField, errs)
+		}
+	}
+}
@@ -1,75 +1,343 @@
 package events
 
 import (
+	"context"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/eventchange"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/geo"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/ical"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/logger"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/storage"
 	"github.com/oskargbc/dws-event-service.git/internal/services"
 	"github.com/oskargbc/dws-event-service.git/prisma/db"
 	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
 )
 
 // Controller handles event-related HTTP requests
 type Controller struct {
 	dbService *services.DatabaseService
+	uidDomain string
+	// storage holds uploaded images; image uploads are unavailable when it is nil
+	storage         storage.Storage
+	maxImageBytes   int64
+	thumbnailWidths []int
+	// categoryStore looks up the categories event payloads refer to
+	categoryStore CategoryStore
+	// importMaxRows limits the rows of one import; DefaultImportMaxRows when 0
+	importMaxRows int
+	// priceBuckets are the price ranges counted by the facets endpoint
+	priceBuckets []priceBucket
+	// defaultTimezone is the timezone of events created without one;
+	// DefaultTimezone when empty
+	defaultTimezone string
+	// ifMatchClients are the Keycloak clients whose writes must send If-Match
+	ifMatchClients map[string]bool
+	// cachePolicies are the Cache-Control headers of event reads
+	cachePolicies cachePolicies
+	// readCache caches the queries of event reads; nil when disabled
+	readCache *readCache
+	// changes announces writes to the caches of every replica
+	changes *eventchange.Publisher
+	logger  *logrus.Logger
 }
 
 // NewController creates a new events controller
 func NewController() *Controller {
-	return &Controller{
-		dbService: services.GetDatabaseSeviceInstance(),
+	cfg := configs.GetEnvConfig()
+	ec := &Controller{
+		dbService:       services.GetDatabaseSeviceInstance(),
+		uidDomain:       ical.DomainFromConfig(),
+		maxImageBytes:   cfg.Storage.MaxImageBytes,
+		thumbnailWidths: cfg.Storage.ThumbnailWidths,
+		categoryStore:   services.GetDatabaseSeviceInstance(),
+		importMaxRows:   cfg.Events.ImportMaxRows,
+		defaultTimezone: cfg.Events.DefaultTimezone,
+		cachePolicies:   newCachePolicies(cfg.Events.CacheControl, cfg.Tenancy.Header),
+		readCache:       newReadCache(cfg.Events.Cache),
+		changes:         eventchange.Default(),
+		logger:          logger.NewLogrusLogger(),
 	}
+	if ec.readCache != nil {
+		ec.changes.OnChange(ec.readCache.invalidate)
+	}
+	if ec.maxImageBytes <= 0 {
+		ec.maxImageBytes = DefaultMaxImageBytes
+	}
+	if ec.thumbnailWidths == nil {
+		ec.thumbnailWidths = DefaultThumbnailWidths
+	}
+	ec.ifMatchClients = map[string]bool{}
+	for _, clientID := range cfg.Events.IfMatchClients {
+		ec.ifMatchClients[clientID] = true
+	}
+	if bounds := cfg.Events.PriceBuckets; bounds != nil {
+		ec.priceBuckets = newPriceBuckets(bounds)
+	} else {
+		ec.priceBuckets = newPriceBuckets(DefaultPriceBuckets)
+	}
+
+	store, err := storage.New(cfg)
+	if err != nil {
+		ec.logger.Errorf("Events: image storage unavailable: %v", err)
+	} else {
+		ec.storage = store
+	}
+	return ec
+}
+
+// EventListItem is an event in a listing, with its start and end also in its
+// timezone. DistanceKm is only set for near queries; OccurrenceStart
+// identifies an expanded occurrence of a recurring event.
+type EventListItem struct {
+	db.EventModel
+	LocalTimes
+	DistanceKm          *float64   `json:"distanceKm,omitempty"`
+	OccurrenceStart     *time.Time `json:"occurrenceStart,omitempty"`
+	OccurrenceCancelled bool       `json:"occurrenceCancelled,omitempty"`
+}
+
+func newEventListItem(event db.EventModel) EventListItem {
+	return EventListItem{
+		EventModel: event,
+		LocalTimes: localTimes(event.StartsAt, event.EndsAt, event.Timezone),
+	}
+}
+
+// ListEventsResponse is a page of events returned by the events listing
+type ListEventsResponse struct {
+	Events     []EventListItem `json:"events"`
+	NextCursor *string         `json:"nextCursor"`
+}
+
+// newListEventsResponse builds a page from the fetched events. events may hold
+// one row more than pageSize, which signals that another page exists. For near
+// queries each event gets its distance from origin.
+func newListEventsResponse(events []db.EventModel, pageSize int, origin *geo.Point) ListEventsResponse {
+	response := ListEventsResponse{Events: []EventListItem{}}
+	if len(events) > pageSize {
+		events = events[:pageSize]
+		nextCursor := events[pageSize-1].ID
+		response.NextCursor = &nextCursor
+	}
+
+	for _, event := range events {
+		item := newEventListItem(event)
+		if origin != nil {
+			if distance, ok := distanceKm(&event, *origin); ok {
+				item.DistanceKm = &distance
+			}
+		}
+		response.Events = append(response.Events, item)
+	}
+
+	return response
+}
+
+// distanceKm returns the distance of the event from origin, if the event has
+// coordinates
+func distanceKm(event *db.EventModel, origin geo.Point) (float64, bool) {
+	lat, hasLat := event.Latitude()
+	lon, hasLon := event.Longitude()
+	if !hasLat || !hasLon {
+		return 0, false
+	}
+	return geo.DistanceKm(origin, geo.Point{Lat: lat, Lon: lon}), true
+}
+
+// distanceWithin returns the distance of the event from origin and whether
+// the event has coordinates within radiusKm of it
+func distanceWithin(event *db.EventModel, origin geo.Point, radiusKm float64) (float64, bool) {
+	distance, ok := distanceKm(event, origin)
+	return distance, ok && distance <= radiusKm
 }
 
 // GetEvents godoc
 // @Summary      List events
-// @Description  Returns a list of all events
+// @Description  Returns a page of published events, plus the caller's own events in any state.
+// @Description  Pass the returned nextCursor as cursor to fetch the next page.
 // @Tags         events
 // @Produce      json
-// @Success      200  {array}   map[string]interface{}
+// @Param        cursor       query     string   false  "Cursor returned by the previous page"
+// @Param        limit        query     int      false  "Page size (default 20, max 100)"
+// @Param        sort         query     string   false  "startsAt, price, createdAt or distance (requires near); prefix with - for descending"
+// @Param        category     query     string   false  "Category slug; includes its subcategories"
+// @Param        categoryId   query     string   false  "Category ID; includes its subcategories"
+// @Param        tags         query     string   false  "Comma-separated tags"
+// @Param        tagMatch     query     string   false  "any (default): events with one of the tags; all: events with every tag"
+// @Param        organizerId  query     string   false  "Organizer ID"
+// @Param        startDate    query     string   false  "Only events ending at or after this instant (RFC3339)"
+// @Param        endDate      query     string   false  "Only events starting at or before this instant (RFC3339)"
+// @Param        minPrice     query     number   false  "Minimum price"
+// @Param        maxPrice     query     number   false  "Maximum price"
+// @Param        free         query     bool     false  "Only free events"
+// @Param        location     query     string   false  "Location contains (case-insensitive)"
+// @Param        near         query     string   false  "Only events near this point, as lat,lon"
+// @Param        radius       query     number   false  "Radius in km for near (default 10, max 500)"
+// @Param        expand       query     bool     false  "Replace recurring events with their occurrences between startDate and endDate; limit counts series"
+// @Success      200  {object}  ListEventsResponse
+// @Success      304
+// @Failure      400  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]interface{}
 // @Router       /events [get]
 func (ec *Controller) GetEvents(c *gin.Context) {
-	ctx := c.Request.Context()
+	var query ListEventsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+
+	ec.listEvents(c, query)
+}
+
+// GetOrganizerEvents godoc
+// @Summary      List events of an organizer
+// @Description  Returns a page of the organizer's published events, or all of its events for the organizer's
+// @Description  linked user and admins. Accepts the same filters as GET /events.
+// @Tags         organizers
+// @Produce      json
+// @Param        id         path      string  true   "Organizer ID"
+// @Param        cursor     query     string  false  "Cursor returned by the previous page"
+// @Param        limit      query     int     false  "Page size (default 20, max 100)"
+// @Param        sort       query     string  false  "startsAt, price or createdAt; prefix with - for descending"
+// @Param        startDate  query     string  false  "Only events ending at or after this instant (RFC3339)"
+// @Param        endDate    query     string  false  "Only events starting at or before this instant (RFC3339)"
+// @Success      200  {object}  ListEventsResponse
+// @Success      304
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /organizers/{id}/events [get]
+func (ec *Controller) GetOrganizerEvents(c *gin.Context) {
+	var query ListEventsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
 
-	events, err := ec.dbService.GetClient().Event.FindMany().Exec(ctx)
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	organizer, err := ec.dbService.GetClient().Organizer.FindFirst(
+		db.Organizer.ID.Equals(c.Param("id")),
+		db.Organizer.TenantID.Equals(tenantID),
+	).Exec(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch events",
-			"details": err.Error(),
-		})
+		if db.IsErrNotFound(err) {
+			problem.Respond(c, http.StatusNotFound, "organizer_not_found", "Organizer not found", "")
+			return
+		}
+		problem.Internal(c, "Failed to fetch organizer", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, events)
+	query.OrganizerID = organizer.ID
+	ec.listEvents(c, query)
+}
+
+// listEvents responds with a page of the events matching query
+func (ec *Controller) listEvents(c *gin.Context, query ListEventsQuery) {
+	filters, err := query.whereParams()
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+	orderBy, err := query.orderBy()
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+	origin, err := query.origin()
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+	if ec.checkListFresh(c) {
+		return
+	}
+
+	// Fetch one extra row to find out whether another page exists. Near
+	// queries only match the events within the radius, so that pages are
+	// full; sorting by distance needs all of them.
+	pageSize := query.pageSize()
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	where := append(visibleEventsFilter(c), filters...)
+	events, err := ec.findListPage(c, func(ctx context.Context) ([]db.EventModel, error) {
+		filtered := where
+		if origin != nil {
+			ids, err := ec.findNearbyIDs(ctx, tenantID, *origin, query.radiusKm())
+			if err != nil {
+				return nil, err
+			}
+			filtered = append([]db.EventWhereParam{db.Event.ID.In(ids)}, where...)
+		}
+		findMany := ec.dbService.GetClient().Event.FindMany(filtered...).With(
+			db.Event.Category.Fetch(),
+			db.Event.Tags.Fetch(),
+		).OrderBy(orderBy...)
+		if query.sortsByDistance() {
+			events, err := findMany.Exec(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return pageByDistance(events, *origin, query.Cursor, pageSize+1, strings.HasPrefix(query.Sort, "-")), nil
+		}
+		findMany = findMany.Take(pageSize + 1)
+		if query.Cursor != "" {
+			findMany = findMany.Cursor(db.Event.ID.Cursor(query.Cursor)).Skip(1)
+		}
+		return findMany.Exec(ctx)
+	})
+	if err != nil {
+		problem.Internal(c, "Failed to fetch events", err)
+		return
+	}
+
+	response := newListEventsResponse(events, pageSize, origin)
+
+	if query.Expand {
+		from, to, _ := expansionWindow(query.StartDate, query.EndDate)
+		overrides, err := ec.findOverrides(c, recurringIDs(response.Events))
+		if err != nil {
+			problem.Internal(c, "Failed to fetch occurrences", err)
+			return
+		}
+		response.Events = expandItems(response.Events, overrides, from, to)
+		query.sortItems(response.Events)
+	}
+
+	ec.respondList(c, response)
 }
 
 // GetEventByID godoc
 // @Summary      Get event by ID
-// @Description  Returns a single event by its ID
+// @Description  Returns a single event by its ID, including the remaining availability of each ticket type.
+// @Description  The ETag header starts with the event's version; send it as If-Match when changing the event.
+// @Description  Answers If-None-Match and If-Modified-Since with 304 when the event did not change.
 // @Tags         events
 // @Produce      json
-// @Param        id   path      string  true  "Event ID"
-// @Success      200  {object}  map[string]interface{}
+// @Param        id                 path      string  true   "Event ID"
+// @Param        If-None-Match      header    string  false  "ETag of the client's copy"
+// @Param        If-Modified-Since  header    string  false  "Last-Modified of the client's copy"
+// @Success      200  {object}  EventDetail
+// @Success      304
+// @Header       200  {string}  ETag           "Version of the event and hash of the response"
+// @Header       200  {string}  Last-Modified  "Time of the latest change"
 // @Failure      404  {object}  map[string]interface{}
 // @Router       /events/{id} [get]
 func (ec *Controller) GetEventByID(c *gin.Context) {
-	ctx := c.Request.Context()
-	eventID := c.Param("id")
-
-	event, err := ec.dbService.GetClient().Event.FindUnique(
-		db.Event.ID.Equals(eventID),
-	).Exec(ctx)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Event not found",
-			"details": err.Error(),
-		})
+	// Drafts are hidden from everyone who may not manage them
+	event, ok := ec.findVisibleEvent(c, c.Param("id"))
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, event)
+	ec.respondDetail(c, event, time.Now())
 }
 
 // CreateEventRequest represents the JSON payload for creating an event
@@ -77,60 +345,406 @@ func (ec *Controller) GetEventByID(c *gin.Context) {
 type CreateEventRequest struct {
 	Name        string          `json:"name" binding:"required"`
 	Description string          `json:"description" binding:"required"`
-	StartDate   time.Time       `json:"startDate" binding:"required"`
-	StartTime   time.Time       `json:"startTime" binding:"required"`
 	Price       decimal.Decimal `json:"price" binding:"required"`
-	EndDate     time.Time       `json:"endDate" binding:"required"`
 	Location    string          `json:"location" binding:"required"`
-	Capacity    int             `json:"capacity" binding:"required"`
-	ImageURL    string          `json:"imageUrl" binding:"required"`
-	Category    string          `json:"category" binding:"required"`
-	OrganizerID string          `json:"organizerId" binding:"required"`
+	Latitude    *float64        `json:"latitude" binding:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude   *float64        `json:"longitude" binding:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+	Capacity    int             `json:"capacity"`
+	// StartsAt and EndsAt are the instants the event starts and ends
+	StartsAt *time.Time `json:"startsAt"`
+	EndsAt   *time.Time `json:"endsAt"`
+	// Timezone is the IANA timezone the event takes place in, e.g.
+	// "Europe/Stockholm"; it defaults to events.default_timezone
+	Timezone string `json:"timezone"`
+	// AllDay events run from midnight of the day of startsAt to midnight of
+	// the day of endsAt in the event's timezone
+	AllDay bool `json:"allDay"`
+	// StartDate, StartTime and EndDate are read when startsAt or endsAt is
+	// missing. Deprecated: use startsAt and endsAt.
+	StartDate *time.Time `json:"startDate"`
+	StartTime *time.Time `json:"startTime"`
+	EndDate   *time.Time `json:"endDate"`
+	// CategoryID is the ID of the category the event is filed under
+	CategoryID string `json:"categoryId"`
+	// Category names the category by slug or display name instead of
+	// categoryId. Deprecated: use categoryId.
+	Category string `json:"category"`
+	// Tags are free-form labels, e.g. "outdoor"; they are stored lowercase with hyphens between words
+	Tags []string `json:"tags"`
+	// ImageURL is optional; an image can also be uploaded with POST /events/{id}/image
+	ImageURL string `json:"imageUrl"`
+	// OrganizerID defaults to the organizer linked to the caller; only admins may name another organizer
+	OrganizerID string `json:"organizerId"`
+	// RecurrenceRule is an optional iCalendar RRULE, e.g. "FREQ=WEEKLY;BYDAY=WE"
+	RecurrenceRule       string      `json:"recurrenceRule"`
+	RecurrenceExceptions []time.Time `json:"recurrenceExceptions"`
 }
 
 // CreateEvent godoc
 // @Summary      Create a new event
-// @Description  Creates a new event
+// @Description  Creates a new event in the DRAFT state. Publish it to make it visible to students.
 // @Tags         events
 // @Accept       json
 // @Produce      json
 // @Param        event  body      CreateEventRequest  true  "Event to create"
 // @Success      201    {object}  map[string]interface{}
 // @Failure      400    {object}  map[string]interface{}
+// @Failure      422    {object}  map[string]interface{}
 // @Failure      500    {object}  map[string]interface{}
 // @Router       /events [post]
 func (ec *Controller) CreateEvent(c *gin.Context) {
 	ctx := c.Request.Context()
 
 	var req CreateEventRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request payload",
-			"details": err.Error(),
-		})
+	if !decodeJSON(c, &req) {
+		return
+	}
+	categories, ok := ec.tenantCategories(c)
+	if !ok {
+		return
+	}
+	if errs := ec.validateCreateEvent(&req, categories); len(errs) > 0 {
+		problem.Validation(c, errs)
+		return
+	}
+
+	when := req.scheduled()
+	series, err := seriesParams(req.RecurrenceRule, req.RecurrenceExceptions, when.start(), when.EndsAt)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, "invalid_recurrence_rule", "Invalid recurrence rule", err.Error())
 		return
 	}
 
+	organizerID, ok := ec.resolveOrganizer(c, req.OrganizerID)
+	if !ok {
+		return
+	}
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	tagIDs, err := ec.ensureTags(ctx, tenantID, req.Tags)
+	if err != nil {
+		problem.Internal(c, "Failed to store tags", err)
+		return
+	}
+
+	optional := append([]db.EventSetParam{
+		db.Event.AllDay.Set(when.AllDay),
+		db.Event.Latitude.SetIfPresent(req.Latitude),
+		db.Event.Longitude.SetIfPresent(req.Longitude),
+	}, series...)
+	optional = append(optional, linkTags(tagIDs)...)
+
 	event, err := ec.dbService.GetClient().Event.CreateOne(
 		db.Event.Name.Set(req.Name),
 		db.Event.Description.Set(req.Description),
-		db.Event.StartDate.Set(req.StartDate),
-		db.Event.StartTime.Set(req.StartTime),
+		db.Event.StartsAt.Set(when.StartsAt),
+		db.Event.EndsAt.Set(when.EndsAt),
+		db.Event.Timezone.Set(when.Timezone),
 		db.Event.Price.Set(req.Price),
-		db.Event.EndDate.Set(req.EndDate),
 		db.Event.Location.Set(req.Location),
 		db.Event.Capacity.Set(req.Capacity),
 		db.Event.ImageURL.Set(req.ImageURL),
-		db.Event.Category.Set(req.Category),
-		db.Event.Organizer.Link(db.Organizer.ID.Equals(req.OrganizerID)),
+		db.Event.Tenant.Link(db.Tenant.ID.Equals(tenantID)),
+		db.Event.Organizer.Link(db.Organizer.ID.Equals(organizerID)),
+		db.Event.Category.Link(db.Category.ID.Equals(req.CategoryID)),
+		optional...,
 	).Exec(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create event",
-			"details": err.Error(),
-		})
+		problem.Internal(c, "Failed to create event", err)
 		return
 	}
+	ec.announceChange(tenantID, event.ID)
 
+	c.Header("ETag", eventETag(event))
 	c.JSON(http.StatusCreated, event)
 }
+
+// UpdateEventRequest represents the JSON payload for replacing an event
+// @Description  Event replacement payload
+type UpdateEventRequest struct {
+	Name        string          `json:"name" binding:"required"`
+	Description string          `json:"description" binding:"required"`
+	Price       decimal.Decimal `json:"price" binding:"required"`
+	Location    string          `json:"location" binding:"required"`
+	Latitude    *float64        `json:"latitude" binding:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude   *float64        `json:"longitude" binding:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+	Capacity    int             `json:"capacity"`
+	CategoryID  string          `json:"categoryId"`
+	// StartsAt and EndsAt are the instants the event starts and ends
+	StartsAt *time.Time `json:"startsAt"`
+	EndsAt   *time.Time `json:"endsAt"`
+	// Timezone is the IANA timezone the event takes place in, e.g.
+	// "Europe/Stockholm"; it defaults to events.default_timezone
+	Timezone string `json:"timezone"`
+	// AllDay events run from midnight of the day of startsAt to midnight of
+	// the day of endsAt in the event's timezone
+	AllDay bool `json:"allDay"`
+	// StartDate, StartTime and EndDate are read when startsAt or endsAt is
+	// missing. Deprecated: use startsAt and endsAt.
+	StartDate *time.Time `json:"startDate"`
+	StartTime *time.Time `json:"startTime"`
+	EndDate   *time.Time `json:"endDate"`
+	// Category names the category by slug or display name. Deprecated: use categoryId.
+	Category string `json:"category"`
+	// Tags replace the event's tags
+	Tags []string `json:"tags"`
+	// ImageURL replaces the image; thumbnails of an uploaded image are dropped when it changes
+	ImageURL string `json:"imageUrl"`
+	// RecurrenceRule is an optional iCalendar RRULE; omit it to make the event a single event
+	RecurrenceRule       string      `json:"recurrenceRule"`
+	RecurrenceExceptions []time.Time `json:"recurrenceExceptions"`
+}
+
+// PatchEventRequest represents the JSON payload for partially updating an event.
+// Omitted fields are left unchanged; provided fields follow the same rules as CreateEventRequest.
+// @Description  Event partial update payload
+type PatchEventRequest struct {
+	Name        *string          `json:"name"`
+	Description *string          `json:"description"`
+	StartsAt    *time.Time       `json:"startsAt"`
+	EndsAt      *time.Time       `json:"endsAt"`
+	Timezone    *string          `json:"timezone"`
+	AllDay      *bool            `json:"allDay"`
+	Price       *decimal.Decimal `json:"price"`
+	Location    *string          `json:"location"`
+	Latitude    *float64         `json:"latitude" binding:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude   *float64         `json:"longitude" binding:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+	Capacity    *int             `json:"capacity"`
+	ImageURL    *string          `json:"imageUrl" binding:"omitempty,min=1"`
+	CategoryID  *string          `json:"categoryId"`
+	// Category names the category by slug or display name. Deprecated: use categoryId.
+	Category *string `json:"category"`
+	// Tags replace the event's tags; an empty list removes them
+	Tags *[]string `json:"tags"`
+	// StartDate, StartTime and EndDate are read when startsAt or endsAt is
+	// missing. Deprecated: use startsAt and endsAt.
+	StartDate *time.Time `json:"startDate"`
+	StartTime *time.Time `json:"startTime"`
+	EndDate   *time.Time `json:"endDate"`
+	// RecurrenceRule replaces the series' rule; an empty string makes the event a single event
+	RecurrenceRule       *string     `json:"recurrenceRule"`
+	RecurrenceExceptions []time.Time `json:"recurrenceExceptions"`
+}
+
+// setParams converts the provided fields into Prisma update parameters
+func (req *PatchEventRequest) setParams() []db.EventSetParam {
+	var params []db.EventSetParam
+	if req.Name != nil {
+		params = append(params, db.Event.Name.Set(*req.Name))
+	}
+	if req.Description != nil {
+		params = append(params, db.Event.Description.Set(*req.Description))
+	}
+	if req.Price != nil {
+		params = append(params, db.Event.Price.Set(*req.Price))
+	}
+	if req.Location != nil {
+		params = append(params, db.Event.Location.Set(*req.Location))
+	}
+	if req.Latitude != nil {
+		params = append(params,
+			db.Event.Latitude.Set(*req.Latitude),
+			db.Event.Longitude.Set(*req.Longitude),
+		)
+	}
+	if req.Capacity != nil {
+		params = append(params, db.Event.Capacity.Set(*req.Capacity))
+	}
+	if req.ImageURL != nil {
+		// Thumbnails belong to the uploaded image they were made from
+		params = append(params,
+			db.Event.ImageURL.Set(*req.ImageURL),
+			db.Event.ImageThumbnails.Set([]string{}),
+		)
+	}
+	if req.CategoryID != nil {
+		params = append(params, db.Event.Category.Link(db.Category.ID.Equals(*req.CategoryID)))
+	}
+	return params
+}
+
+// UpdateEvent godoc
+// @Summary      Update an event
+// @Description  Replaces all editable fields of an event. Only the owning organiser or an admin may update it.
+// @Tags         events
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string              true  "Event ID"
+// @Param        event  body      UpdateEventRequest  true  "Updated event"
+// @Param        If-Match  header  string  false  "ETag of the version the update is based on; required for dashboard clients"
+// @Success      200    {object}  map[string]interface{}
+// @Failure      400    {object}  map[string]interface{}
+// @Failure      403    {object}  map[string]interface{}
+// @Failure      404    {object}  map[string]interface{}
+// @Failure      409    {object}  map[string]interface{}
+// @Failure      412    {object}  map[string]interface{}
+// @Failure      422    {object}  map[string]interface{}
+// @Failure      428    {object}  map[string]interface{}
+// @Failure      500    {object}  map[string]interface{}
+// @Router       /events/{id} [put]
+func (ec *Controller) UpdateEvent(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID := c.Param("id")
+
+	var req UpdateEventRequest
+	if !decodeJSON(c, &req) {
+		return
+	}
+	categories, ok := ec.tenantCategories(c)
+	if !ok {
+		return
+	}
+	if errs := ec.validateUpdateEvent(&req, categories); len(errs) > 0 {
+		problem.Validation(c, errs)
+		return
+	}
+
+	current, ok := ec.authorizeEventWrite(c, eventID)
+	if !ok || !ec.checkIfMatch(c, current) {
+		return
+	}
+	if !isEditable(current.Status) {
+		problem.Respond(c, http.StatusConflict, "event_not_editable", "Event can no longer be changed",
+			"event is "+string(current.Status))
+		return
+	}
+	if !capacityCovers(current, req.Capacity) {
+		problem.Respond(c, http.StatusConflict, "capacity_below_taken_seats", "Capacity is below the seats already taken",
+			"capacity must cover the sold and held seats")
+		return
+	}
+
+	when := req.scheduled()
+	series, err := seriesParams(req.RecurrenceRule, req.RecurrenceExceptions, when.start(), when.EndsAt)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, "invalid_recurrence_rule", "Invalid recurrence rule", err.Error())
+		return
+	}
+
+	params := append([]db.EventSetParam{
+		db.Event.Name.Set(req.Name),
+		db.Event.Description.Set(req.Description),
+		db.Event.Price.Set(req.Price),
+		db.Event.Location.Set(req.Location),
+		db.Event.Latitude.SetOptional(req.Latitude),
+		db.Event.Longitude.SetOptional(req.Longitude),
+		db.Event.Capacity.Set(req.Capacity),
+		db.Event.ImageURL.Set(req.ImageURL),
+		db.Event.Category.Link(db.Category.ID.Equals(req.CategoryID)),
+		db.Event.UpdatedAt.Set(time.Now()),
+	}, when.params()...)
+	params = append(params, series...)
+	if req.ImageURL != current.ImageURL {
+		params = append(params, db.Event.ImageThumbnails.Set([]string{}))
+	}
+	tagIDs, err := ec.ensureTags(ctx, current.TenantID, req.Tags)
+	if err != nil {
+		problem.Internal(c, "Failed to store tags", err)
+		return
+	}
+	params = append(params, replaceTags(current.RelationsEvent.Tags, tagIDs)...)
+
+	event, ok := ec.updateEvent(c, current, "Failed to update event", params...)
+	if !ok {
+		return
+	}
+	ec.announceChange(event.TenantID, event.ID)
+
+	c.Header("ETag", eventETag(event))
+	c.JSON(http.StatusOK, event)
+}
+
+// PatchEvent godoc
+// @Summary      Partially update an event
+// @Description  Updates only the provided fields of an event. Only the owning organiser or an admin may update it.
+// @Tags         events
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string             true  "Event ID"
+// @Param        event  body      PatchEventRequest  true  "Fields to update"
+// @Param        If-Match  header  string  false  "ETag of the version the update is based on; required for dashboard clients"
+// @Success      200    {object}  map[string]interface{}
+// @Failure      400    {object}  map[string]interface{}
+// @Failure      403    {object}  map[string]interface{}
+// @Failure      404    {object}  map[string]interface{}
+// @Failure      409    {object}  map[string]interface{}
+// @Failure      412    {object}  map[string]interface{}
+// @Failure      422    {object}  map[string]interface{}
+// @Failure      428    {object}  map[string]interface{}
+// @Failure      500    {object}  map[string]interface{}
+// @Router       /events/{id} [patch]
+func (ec *Controller) PatchEvent(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID := c.Param("id")
+
+	var req PatchEventRequest
+	if !decodeJSON(c, &req) {
+		return
+	}
+	var categories categoryIndex
+	if req.changesCategory() {
+		var ok bool
+		if categories, ok = ec.tenantCategories(c); !ok {
+			return
+		}
+	}
+	if errs := ec.validatePatchEvent(&req, categories); len(errs) > 0 {
+		problem.Validation(c, errs)
+		return
+	}
+
+	current, ok := ec.authorizeEventWrite(c, eventID)
+	if !ok || !ec.checkIfMatch(c, current) {
+		return
+	}
+	if !isEditable(current.Status) {
+		problem.Respond(c, http.StatusConflict, "event_not_editable", "Event can no longer be changed",
+			"event is "+string(current.Status))
+		return
+	}
+	when, errs := req.patchedSchedule(current)
+	if len(errs) > 0 {
+		problem.Validation(c, errs)
+		return
+	}
+	if req.Capacity != nil && !capacityCovers(current, *req.Capacity) {
+		problem.Respond(c, http.StatusConflict, "capacity_below_taken_seats", "Capacity is below the seats already taken",
+			"capacity must cover the sold and held seats")
+		return
+	}
+
+	params := append(req.setParams(), db.Event.UpdatedAt.Set(time.Now()))
+	if req.scheduleFields().sent() {
+		params = append(params, when.params()...)
+	}
+	if req.Tags != nil {
+		tagIDs, err := ec.ensureTags(ctx, current.TenantID, *req.Tags)
+		if err != nil {
+			problem.Internal(c, "Failed to store tags", err)
+			return
+		}
+		params = append(params, replaceTags(current.RelationsEvent.Tags, tagIDs)...)
+	}
+
+	// The stored end of the series depends on the rule and the event times, so
+	// it is recomputed whenever one of them changes
+	if req.changesSeries() {
+		rule, exceptions := req.mergeSeries(current)
+		if _, recurring := current.RecurrenceRule(); recurring || rule != "" {
+			series, err := seriesParams(rule, exceptions, when.start(), when.EndsAt)
+			if err != nil {
+				problem.Respond(c, http.StatusBadRequest, "invalid_recurrence_rule", "Invalid recurrence rule", err.Error())
+				return
+			}
+			params = append(params, series...)
+		}
+	}
+
+	event, ok := ec.updateEvent(c, current, "Failed to update event", params...)
+	if !ok {
+		return
+	}
+	ec.announceChange(event.TenantID, event.ID)
+
+	c.Header("ETag", eventETag(event))
+	c.JSON(http.StatusOK, event)
+}
@@ -1,12 +1,15 @@
 package events
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
 )
 
 // Hilfsfunktion: Router nur für CreateEvent bauen
@@ -34,8 +37,8 @@ func TestCreateEvent_InvalidJSON_Returns400(t *testing.T) {
 	}
 }
 
-func TestCreateEvent_MissingRequiredField_Returns400(t *testing.T) {
-	ec := &Controller{}
+func TestCreateEvent_MissingRequiredField_Returns422(t *testing.T) {
+	ec := &Controller{categoryStore: testCategories}
 	r := setupRouterForCreate(ec)
 
 	// name fehlt absichtlich (binding:"required")
@@ -58,7 +61,181 @@ func TestCreateEvent_MissingRequiredField_Returns400(t *testing.T) {
 
 	r.ServeHTTP(w, req)
 
-	if w.Code != http.StatusBadRequest {
-		t.Fatalf("expected status 400, got %d. body=%s", w.Code, w.Body.String())
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d. body=%s", w.Code, w.Body.String())
+	}
+}
+
+func setupRouterForUpdate(controller *Controller) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PUT("/events/:id", controller.UpdateEvent)
+	r.PATCH("/events/:id", controller.PatchEvent)
+	return r
+}
+
+func TestUpdateEvent_MissingRequiredField_Returns422(t *testing.T) {
+	ec := &Controller{categoryStore: testCategories}
+	r := setupRouterForUpdate(ec)
+
+	// PUT ersetzt das ganze Event, daher sind alle Felder Pflicht
+	req := httptest.NewRequest("PUT", "/events/evt-1", strings.NewReader(`{"name":"Only a name"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d. body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestCreateEvent_LatitudeWithoutLongitude_Returns422(t *testing.T) {
+	ec := &Controller{categoryStore: testCategories}
+	r := setupRouterForCreate(ec)
+
+	body := `{
+		"name":"Pub quiz",
+		"description":"desc",
+		"startDate":"2026-01-01T00:00:00Z",
+		"startTime":"2026-01-01T10:00:00Z",
+		"price":"0",
+		"endDate":"2026-01-02T00:00:00Z",
+		"location":"Luleå",
+		"latitude":65.58,
+		"capacity":10,
+		"imageUrl":"https://example.com/a.jpg",
+		"category":"workshop",
+		"organizerId":"org-1"
+	}`
+
+	req := httptest.NewRequest("POST", "/events", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d. body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestPatchEvent_InvalidFields(t *testing.T) {
+	ec := &Controller{}
+	r := setupRouterForUpdate(ec)
+
+	tests := []struct {
+		body       string
+		wantStatus int
+	}{
+		{`{invalid json`, http.StatusBadRequest},
+		{`{"name":""}`, http.StatusUnprocessableEntity},
+		{`{"capacity":0}`, http.StatusUnprocessableEntity},
+		{`{"latitude":91,"longitude":0}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("PATCH", "/events/evt-1", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Fatalf("body %s: expected status %d, got %d. body=%s", tt.body, tt.wantStatus, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestPatchEventRequest_SetParams(t *testing.T) {
+	name := "Renamed"
+	capacity := 50
+	req := PatchEventRequest{Name: &name, Capacity: &capacity}
+
+	if got := len(req.setParams()); got != 2 {
+		t.Fatalf("expected 2 set params, got %d", got)
+	}
+	if got := len((&PatchEventRequest{}).setParams()); got != 0 {
+		t.Fatalf("expected no set params for empty patch, got %d", got)
+	}
+}
+
+func newContextWithUser(userID string, roles ...string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx := context.WithValue(context.Background(), middlewares.UserIDKey, userID)
+	if len(roles) > 0 {
+		ctx = context.WithValue(ctx, middlewares.UserRolesKey, roles)
+	}
+	c.Request = httptest.NewRequest("PATCH", "/events/evt-1", nil).WithContext(ctx)
+	return c
+}
+
+func TestCanManageEvent(t *testing.T) {
+	owner := "kc-owner"
+	event := &db.EventModel{}
+	event.RelationsEvent.Organizer = &db.OrganizerModel{}
+	event.RelationsEvent.Organizer.InnerOrganizer.KeycloakID = &owner
+
+	tests := []struct {
+		name   string
+		ctx    *gin.Context
+		event  *db.EventModel
+		expect bool
+	}{
+		{"owner", newContextWithUser(owner, middlewares.RoleOrganiser), event, true},
+		{"other_organiser", newContextWithUser("kc-other", middlewares.RoleOrganiser), event, false},
+		{"admin", newContextWithUser("kc-admin", middlewares.RoleAdmin), event, true},
+		{"unlinked_organizer", newContextWithUser(owner), &db.EventModel{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canManageEvent(tt.ctx, tt.event); got != tt.expect {
+				t.Fatalf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from   db.EventStatus
+		to     db.EventStatus
+		expect bool
+	}{
+		{db.EventStatusDraft, db.EventStatusPublished, true},
+		{db.EventStatusDraft, db.EventStatusPostponed, false},
+		{db.EventStatusPublished, db.EventStatusPostponed, true},
+		{db.EventStatusPublished, db.EventStatusCancelled, true},
+		{db.EventStatusPostponed, db.EventStatusPublished, true},
+		{db.EventStatusCancelled, db.EventStatusPublished, false},
+		{db.EventStatusCancelled, db.EventStatusArchived, true},
+		{db.EventStatusArchived, db.EventStatusPublished, false},
+	}
+
+	for _, tt := range tests {
+		if got := canTransition(tt.from, tt.to); got != tt.expect {
+			t.Errorf("%s -> %s: expected %v, got %v", tt.from, tt.to, tt.expect, got)
+		}
+	}
+}
+
+func TestCanViewEvent_DraftHiddenFromOthers(t *testing.T) {
+	owner := "kc-owner"
+	event := &db.EventModel{}
+	event.Status = db.EventStatusDraft
+	event.RelationsEvent.Organizer = &db.OrganizerModel{}
+	event.RelationsEvent.Organizer.InnerOrganizer.KeycloakID = &owner
+
+	if canViewEvent(newContextWithUser("kc-student"), event) {
+		t.Fatal("draft must not be visible to other users")
+	}
+	if !canViewEvent(newContextWithUser(owner), event) {
+		t.Fatal("draft must be visible to its organizer")
+	}
+
+	event.Status = db.EventStatusCancelled
+	if !canViewEvent(newContextWithUser("kc-student"), event) {
+		t.Fatal("cancelled event must stay visible")
 	}
 }
@@ -0,0 +1,338 @@
+package events
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// Formats events can be exported to. ExportFormatExcel is CSV with a byte
+// order mark and CRLF line endings, which spreadsheet applications open with
+// the right encoding.
+const (
+	ExportFormatCSV   = "csv"
+	ExportFormatJSONL = "jsonl"
+	ExportFormatExcel = "excel"
+)
+
+// exportBatchSize is the number of events fetched and written at a time, so
+// exports never hold more than one batch in memory
+const exportBatchSize = 500
+
+// ExportEventsQuery holds the query parameters of an export: the filters of
+// the events listing plus the format. Cursor, limit and expand are not used.
+type ExportEventsQuery struct {
+	ListEventsQuery
+	Format string `form:"format" binding:"omitempty,oneof=csv jsonl excel"`
+}
+
+// exportColumn is a CSV column of an export. Text columns hold user input and
+// are guarded against formula injection in both CSV formats.
+type exportColumn struct {
+	name  string
+	text  bool
+	value func(event *db.EventModel) string
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// exportColumns are named like the fields of CreateEventRequest, so exported
+// rows can be edited and imported again after dropping the read-only columns
+var exportColumns = []exportColumn{
+	{"id", false, func(e *db.EventModel) string { return e.ID }},
+	{"name", true, func(e *db.EventModel) string { return e.Name }},
+	{"description", true, func(e *db.EventModel) string { return e.Description }},
+	{"status", false, func(e *db.EventModel) string { return string(e.Status) }},
+	{"startsAt", false, func(e *db.EventModel) string { return formatTime(e.StartsAt) }},
+	{"endsAt", false, func(e *db.EventModel) string { return formatTime(e.EndsAt) }},
+	{"timezone", false, func(e *db.EventModel) string { return e.Timezone }},
+	{"allDay", false, func(e *db.EventModel) string { return strconv.FormatBool(e.AllDay) }},
+	{"location", true, func(e *db.EventModel) string { return e.Location }},
+	{"latitude", false, func(e *db.EventModel) string {
+		if lat, ok := e.Latitude(); ok {
+			return strconv.FormatFloat(lat, 'f', -1, 64)
+		}
+		return ""
+	}},
+	{"longitude", false, func(e *db.EventModel) string {
+		if lon, ok := e.Longitude(); ok {
+			return strconv.FormatFloat(lon, 'f', -1, 64)
+		}
+		return ""
+	}},
+	{"capacity", false, func(e *db.EventModel) string { return strconv.Itoa(e.Capacity) }},
+	{"seatsSold", false, func(e *db.EventModel) string { return strconv.Itoa(e.SeatsSold) }},
+	{"price", false, func(e *db.EventModel) string { return e.Price.String() }},
+	{"category", true, func(e *db.EventModel) string {
+		if category := e.RelationsEvent.Category; category != nil {
+			return category.Slug
+		}
+		return ""
+	}},
+	{"tags", true, func(e *db.EventModel) string { return strings.Join(tagNames(e), ";") }},
+	{"imageUrl", true, func(e *db.EventModel) string { return e.ImageURL }},
+	{"organizerId", false, func(e *db.EventModel) string { return e.OrganizerID }},
+	{"recurrenceRule", false, func(e *db.EventModel) string {
+		rule, _ := e.RecurrenceRule()
+		return rule
+	}},
+	{"recurrenceExceptions", false, func(e *db.EventModel) string {
+		exceptions := make([]string, len(e.RecurrenceExceptions))
+		for i, exception := range e.RecurrenceExceptions {
+			exceptions[i] = formatTime(exception)
+		}
+		return strings.Join(exceptions, ";")
+	}},
+	{"createdAt", false, func(e *db.EventModel) string { return formatTime(e.CreatedAt) }},
+	{"updatedAt", false, func(e *db.EventModel) string { return formatTime(e.UpdatedAt) }},
+}
+
+// eventWriter writes the events of an export one at a time
+type eventWriter interface {
+	Write(event *db.EventModel) error
+	// Flush sends the buffered events to the client
+	Flush() error
+}
+
+type csvEventWriter struct {
+	w     *csv.Writer
+	excel bool
+}
+
+func newCSVEventWriter(w io.Writer, excel bool) (*csvEventWriter, error) {
+	if excel {
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return nil, err
+		}
+	}
+	cw := &csvEventWriter{w: csv.NewWriter(w), excel: excel}
+	cw.w.UseCRLF = excel
+
+	header := make([]string, len(exportColumns))
+	for i, col := range exportColumns {
+		header[i] = col.name
+	}
+	return cw, cw.w.Write(header)
+}
+
+func (cw *csvEventWriter) Write(event *db.EventModel) error {
+	record := make([]string, len(exportColumns))
+	for i, col := range exportColumns {
+		record[i] = col.value(event)
+		if col.text {
+			record[i] = escapeFormula(record[i])
+		}
+	}
+	return cw.w.Write(record)
+}
+
+func (cw *csvEventWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// formulaPrefixes are the characters that make spreadsheet applications read
+// a cell as a formula
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeFormula keeps spreadsheet applications from evaluating text that
+// starts like a formula, e.g. an event named "=HYPERLINK(...)". CSV files are
+// opened in spreadsheets as well, so both CSV formats escape text.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeFormula reverses escapeFormula, so exports can be imported again
+func unescapeFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+// jsonlEventWriter writes every event as a JSON object on its own line, in
+// the same form as the API returns it
+type jsonlEventWriter struct {
+	enc *json.Encoder
+}
+
+func (jw *jsonlEventWriter) Write(event *db.EventModel) error {
+	return jw.enc.Encode(event)
+}
+
+func (jw *jsonlEventWriter) Flush() error {
+	return nil
+}
+
+// startExport writes the response headers and returns the writer for the format
+func startExport(c *gin.Context, format string) (eventWriter, error) {
+	filename := "events-" + time.Now().UTC().Format("20060102")
+	c.Header("Cache-Control", "no-store")
+	if format == ExportFormatJSONL {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.jsonl"`)
+		c.Status(http.StatusOK)
+		return &jsonlEventWriter{enc: json.NewEncoder(c.Writer)}, nil
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+	c.Status(http.StatusOK)
+	return newCSVEventWriter(c.Writer, format == ExportFormatExcel)
+}
+
+// exportScope restricts an export to the events the caller may pull: admins
+// export the events of the whole tenant, everyone else those of the organizer
+// linked to them. On failure the response is already written and false is
+// returned.
+func (ec *Controller) exportScope(c *gin.Context, query *ListEventsQuery) bool {
+	if middlewares.HasRole(c, middlewares.RoleAdmin) {
+		return true
+	}
+	organizer, ok := ec.ownOrganizer(c)
+	if !ok {
+		return false
+	}
+	if query.OrganizerID != "" && query.OrganizerID != organizer.ID {
+		problem.Respond(c, http.StatusForbidden, "not_own_organizer", "Only the events of your own organizer can be exported", "")
+		return false
+	}
+	query.OrganizerID = organizer.ID
+	return true
+}
+
+// ExportEvents godoc
+// @Summary      Export events
+// @Description  Streams the events matching the filters of GET /events as CSV, Excel-compatible CSV or JSON Lines, in any state.
+// @Description  Admins export the events of the tenant; everyone else the events of the organizer linked to them.
+// @Tags         events
+// @Produce      text/csv,application/x-ndjson
+// @Param        format       query     string   false  "csv (default), excel or jsonl"
+// @Param        sort         query     string   false  "startsAt, price or createdAt; prefix with - for descending (distance is not supported)"
+// @Param        category     query     string   false  "Category slug; includes its subcategories"
+// @Param        categoryId   query     string   false  "Category ID; includes its subcategories"
+// @Param        tags         query     string   false  "Comma-separated tags"
+// @Param        tagMatch     query     string   false  "any (default): events with one of the tags; all: events with every tag"
+// @Param        organizerId  query     string   false  "Organizer ID; only admins may name another organizer"
+// @Param        startDate    query     string   false  "Only events ending at or after this instant (RFC3339)"
+// @Param        endDate      query     string   false  "Only events starting at or before this instant (RFC3339)"
+// @Param        minPrice     query     number   false  "Minimum price"
+// @Param        maxPrice     query     number   false  "Maximum price"
+// @Param        free         query     bool     false  "Only free events"
+// @Param        location     query     string   false  "Location contains (case-insensitive)"
+// @Param        near         query     string   false  "Only events near this point, as lat,lon"
+// @Param        radius       query     number   false  "Radius in km for near (default 10, max 500)"
+// @Success      200  {file}    file
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /events/export [get]
+func (ec *Controller) ExportEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var query ExportEventsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+	if query.Expand {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters",
+			"expand is not supported by exports")
+		return
+	}
+	if query.sortsByDistance() {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters",
+			"sort by distance is not supported by exports")
+		return
+	}
+	filters, err := query.whereParams()
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+	orderBy, err := query.orderBy()
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+	origin, err := query.origin()
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if !ec.exportScope(c, &query.ListEventsQuery) {
+		return
+	}
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	where := append([]db.EventWhereParam{
+		db.Event.TenantID.Equals(tenantID),
+	}, filters...)
+	if query.OrganizerID != "" {
+		where = append(where, db.Event.OrganizerID.Equals(query.OrganizerID))
+	}
+
+	// The export is fetched in batches using the event ID as cursor and
+	// written as it arrives. Once the first batch is written the status is
+	// sent, so later failures can only end the response early.
+	var out eventWriter
+	cursor := ""
+	for {
+		findMany := ec.dbService.GetClient().Event.FindMany(where...).With(
+			db.Event.Category.Fetch(),
+			db.Event.Tags.Fetch(),
+		).OrderBy(orderBy...).Take(exportBatchSize)
+		if cursor != "" {
+			findMany = findMany.Cursor(db.Event.ID.Cursor(cursor)).Skip(1)
+		}
+		batch, err := findMany.Exec(ctx)
+		if err != nil {
+			if out == nil {
+				problem.Internal(c, "Failed to fetch events", err)
+				return
+			}
+			ec.logger.Errorf("Events: export aborted after a failed fetch: %v", err)
+			return
+		}
+
+		if out == nil {
+			if out, err = startExport(c, query.Format); err != nil {
+				return
+			}
+		}
+		for i := range batch {
+			if origin != nil {
+				if _, ok := distanceWithin(&batch[i], *origin, query.radiusKm()); !ok {
+					continue
+				}
+			}
+			if err := out.Write(&batch[i]); err != nil {
+				// The client went away
+				return
+			}
+		}
+		if err := out.Flush(); err != nil {
+			return
+		}
+		c.Writer.Flush()
+
+		if len(batch) < exportBatchSize {
+			return
+		}
+		cursor = batch[len(batch)-1].ID
+	}
+}
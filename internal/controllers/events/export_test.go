@@ -0,0 +1,147 @@
+package events
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+	"github.com/shopspring/decimal"
+)
+
+func exportedEvent() *db.EventModel {
+	event := &db.EventModel{}
+	event.ID = "evt-1"
+	event.Name = "=HYPERLINK(\"http://evil\")"
+	event.Description = "Quiz, with \"quotes\""
+	event.Status = db.EventStatusPublished
+	event.StartsAt = time.Date(2026, 9, 3, 17, 0, 0, 0, time.UTC)
+	event.EndsAt = event.StartsAt.Add(3 * time.Hour)
+	event.Timezone = "Europe/Stockholm"
+	event.Location = "Luleå"
+	event.ImageURL = "=HYPERLINK(\"http://evil\",\"x\")"
+	event.Capacity = 40
+	event.Price = decimal.RequireFromString("12.50")
+	party := newCategory("cat-party", "party", "Party")
+	event.CategoryID = party.ID
+	event.RelationsEvent.Category = &party
+	event.RecurrenceExceptions = []time.Time{
+		time.Date(2026, 9, 10, 19, 0, 0, 0, time.UTC),
+		time.Date(2026, 9, 17, 19, 0, 0, 0, time.UTC),
+	}
+	return event
+}
+
+func TestCSVEventWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		excel    bool
+		wantName string
+	}{
+		{"csv", false, "'=HYPERLINK(\"http://evil\")"},
+		{"excel", true, "'=HYPERLINK(\"http://evil\")"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w, err := newCSVEventWriter(&buf, tt.excel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Write(exportedEvent()); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		out := buf.String()
+		if hasBOM := strings.HasPrefix(out, "\ufeff"); hasBOM != tt.excel {
+			t.Errorf("%s: expected byte order mark %v, got %q", tt.name, tt.excel, out)
+		}
+		if hasCRLF := strings.Contains(out, "\r\n"); hasCRLF != tt.excel {
+			t.Errorf("%s: expected CRLF line endings %v", tt.name, tt.excel)
+		}
+
+		records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(out, "\ufeff"))).ReadAll()
+		if err != nil {
+			t.Fatalf("%s: export is not valid CSV: %v", tt.name, err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("%s: expected a header and one row, got %d records", tt.name, len(records))
+		}
+		row := map[string]string{}
+		for i, column := range records[0] {
+			row[column] = records[1][i]
+		}
+		want := map[string]string{
+			"id":                   "evt-1",
+			"name":                 tt.wantName,
+			"description":          "Quiz, with \"quotes\"",
+			"status":               "PUBLISHED",
+			"startsAt":             "2026-09-03T17:00:00Z",
+			"timezone":             "Europe/Stockholm",
+			"allDay":               "false",
+			"latitude":             "",
+			"capacity":             "40",
+			"price":                "12.5",
+			"category":             "party",
+			"imageUrl":             "'=HYPERLINK(\"http://evil\",\"x\")",
+			"recurrenceExceptions": "2026-09-10T19:00:00Z;2026-09-17T19:00:00Z",
+		}
+		for column, value := range want {
+			if row[column] != value {
+				t.Errorf("%s: expected %s %q, got %q", tt.name, column, value, row[column])
+			}
+		}
+	}
+}
+
+func TestEscapeFormula(t *testing.T) {
+	for _, value := range []string{"=1+1", "+46 70", "-5", "@SUM(A1)", "Quiz night", ""} {
+		if got := unescapeFormula(escapeFormula(value)); got != value {
+			t.Errorf("expected %q to survive escaping, got %q", value, got)
+		}
+	}
+	if got := escapeFormula("@SUM(A1)"); got != "'@SUM(A1)" {
+		t.Errorf("expected a formula to be escaped, got %q", got)
+	}
+	if got := unescapeFormula("'quoted"); got != "'quoted" {
+		t.Errorf("expected other quoted text to be kept, got %q", got)
+	}
+}
+
+func TestExportColumns_CanBeImported(t *testing.T) {
+	// Apart from the read-only columns, an export can be imported again
+	readOnly := map[string]bool{"id": true, "status": true, "seatsSold": true, "createdAt": true, "updatedAt": true}
+	for _, col := range exportColumns {
+		if _, ok := importColumns[strings.ToLower(col.name)]; !ok && !readOnly[col.name] {
+			t.Errorf("column %s cannot be imported", col.name)
+		}
+	}
+}
+
+func TestExportEvents_InvalidQuery_Returns400(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// The query is checked before the caller's organizer is loaded, so no database is needed
+	ec := &Controller{}
+	r := gin.New()
+	r.GET("/events/export", ec.ExportEvents)
+
+	for _, url := range []string{
+		"/events/export?format=xlsx",
+		"/events/export?expand=true",
+		"/events/export?sort=name",
+		"/events/export?minPrice=cheap",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d. body=%s", url, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package events
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultPriceBuckets are used when events.price_buckets is not configured
+var DefaultPriceBuckets = []float64{100, 250, 500}
+
+// TagFacet is the number of matching events carrying a tag
+type TagFacet struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// CategoryFacet is the number of matching events filed under a category.
+// Events of a subcategory count towards its parent as well.
+type CategoryFacet struct {
+	ID       string  `json:"id"`
+	Slug     string  `json:"slug"`
+	Name     string  `json:"name"`
+	ParentID *string `json:"parentId"`
+	Count    int     `json:"count"`
+}
+
+// PriceFacet is the number of matching events in a price range. MinPrice is
+// inclusive and MaxPrice exclusive; the free range holds the events priced 0
+// and the last range has no MaxPrice.
+type PriceFacet struct {
+	Key      string           `json:"key"`
+	MinPrice decimal.Decimal  `json:"minPrice"`
+	MaxPrice *decimal.Decimal `json:"maxPrice"`
+	Count    int              `json:"count"`
+}
+
+// FacetsResponse holds the counts of the events matching the filters of the
+// events listing
+type FacetsResponse struct {
+	Total      int             `json:"total"`
+	Tags       []TagFacet      `json:"tags"`
+	Categories []CategoryFacet `json:"categories"`
+	Prices     []PriceFacet    `json:"prices"`
+}
+
+// priceBucket is a range of prices counted by the facets endpoint
+type priceBucket struct {
+	key string
+	min decimal.Decimal
+	max *decimal.Decimal
+}
+
+func (b priceBucket) contains(price decimal.Decimal) bool {
+	if b.max != nil && b.max.IsZero() {
+		return price.IsZero()
+	}
+	return price.IsPositive() && price.GreaterThanOrEqual(b.min) && (b.max == nil || price.LessThan(*b.max))
+}
+
+// newPriceBuckets returns the free range followed by the ranges between the
+// given bounds, e.g. free, 0-100, 100-250 and 250+ for 100 and 250. Bounds
+// that are not positive or not ascending are ignored.
+func newPriceBuckets(bounds []float64) []priceBucket {
+	zero := decimal.Zero
+	buckets := []priceBucket{{key: "free", min: zero, max: &zero}}
+	min := zero
+	for _, bound := range bounds {
+		max := decimal.NewFromFloat(bound)
+		if !max.GreaterThan(min) {
+			continue
+		}
+		buckets = append(buckets, priceBucket{key: min.String() + "-" + max.String(), min: min, max: &max})
+		min = max
+	}
+	return append(buckets, priceBucket{key: min.String() + "+", min: min})
+}
+
+// facetCounter counts the facets of the events added to it
+type facetCounter struct {
+	categories categoryIndex
+	buckets    []priceBucket
+
+	total          int
+	tagCounts      map[string]int
+	categoryCounts map[string]int
+	priceCounts    []int
+}
+
+func newFacetCounter(categories categoryIndex, buckets []priceBucket) *facetCounter {
+	return &facetCounter{
+		categories:     categories,
+		buckets:        buckets,
+		tagCounts:      map[string]int{},
+		categoryCounts: map[string]int{},
+		priceCounts:    make([]int, len(buckets)),
+	}
+}
+
+// add counts an event, which must be loaded with its tags
+func (fc *facetCounter) add(event *db.EventModel) {
+	fc.total++
+	for _, tag := range event.RelationsEvent.Tags {
+		fc.tagCounts[tag.Name]++
+	}
+	if category, ok := fc.categories.byID(event.CategoryID); ok {
+		fc.categoryCounts[category.ID]++
+		if parent, ok := category.ParentID(); ok {
+			fc.categoryCounts[parent]++
+		}
+	}
+	for i, bucket := range fc.buckets {
+		if bucket.contains(event.Price) {
+			fc.priceCounts[i]++
+			break
+		}
+	}
+}
+
+// response returns the counted facets. Tags and categories without events are
+// left out and the rest ordered by count, most frequent first; every price
+// range is returned in ascending order.
+func (fc *facetCounter) response() FacetsResponse {
+	response := FacetsResponse{
+		Total:      fc.total,
+		Tags:       []TagFacet{},
+		Categories: []CategoryFacet{},
+		Prices:     make([]PriceFacet, len(fc.buckets)),
+	}
+	for name, count := range fc.tagCounts {
+		response.Tags = append(response.Tags, TagFacet{Name: name, Count: count})
+	}
+	sort.Slice(response.Tags, func(i, j int) bool {
+		a, b := response.Tags[i], response.Tags[j]
+		return a.Count > b.Count || a.Count == b.Count && a.Name < b.Name
+	})
+
+	for _, category := range fc.categories {
+		count := fc.categoryCounts[category.ID]
+		if count == 0 {
+			continue
+		}
+		facet := CategoryFacet{ID: category.ID, Slug: category.Slug, Name: category.Name, Count: count}
+		if parent, ok := category.ParentID(); ok {
+			facet.ParentID = &parent
+		}
+		response.Categories = append(response.Categories, facet)
+	}
+	sort.SliceStable(response.Categories, func(i, j int) bool {
+		return response.Categories[i].Count > response.Categories[j].Count
+	})
+
+	for i, bucket := range fc.buckets {
+		response.Prices[i] = PriceFacet{Key: bucket.key, MinPrice: bucket.min, MaxPrice: bucket.max, Count: fc.priceCounts[i]}
+	}
+	return response
+}
+
+// GetEventFacets godoc
+// @Summary      Count events by facet
+// @Description  Counts the events matching the filters of GET /events by tag, category and price range, for
+// @Description  building filter chips. The counts cover every matching event, not a single page.
+// @Tags         events
+// @Produce      json
+// @Param        category     query     string   false  "Category slug; includes its subcategories"
+// @Param        categoryId   query     string   false  "Category ID; includes its subcategories"
+// @Param        tags         query     string   false  "Comma-separated tags"
+// @Param        tagMatch     query     string   false  "any (default): events with one of the tags; all: events with every tag"
+// @Param        organizerId  query     string   false  "Organizer ID"
+// @Param        startDate    query     string   false  "Only events ending at or after this instant (RFC3339)"
+// @Param        endDate      query     string   false  "Only events starting at or before this instant (RFC3339)"
+// @Param        minPrice     query     number   false  "Minimum price"
+// @Param        maxPrice     query     number   false  "Maximum price"
+// @Param        free         query     bool     false  "Only free events"
+// @Param        location     query     string   false  "Location contains (case-insensitive)"
+// @Param        near         query     string   false  "Only events near this point, as lat,lon"
+// @Param        radius       query     number   false  "Radius in km for near (default 10, max 500)"
+// @Success      200  {object}  FacetsResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /events/facets [get]
+func (ec *Controller) GetEventFacets(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var query ListEventsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+	if query.Expand {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters",
+			"expand is not supported by facets")
+		return
+	}
+	filters, err := query.whereParams()
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+	origin, err := query.origin()
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	categories, err := ec.categoryStore.FindCategories(ctx, tenantID)
+	if err != nil {
+		problem.Internal(c, "Failed to fetch categories", err)
+		return
+	}
+	counter := newFacetCounter(categories, ec.priceBuckets)
+
+	// Like exports, the matching events are fetched in batches using the
+	// event ID as cursor
+	where := append(visibleEventsFilter(c), filters...)
+	cursor := ""
+	for {
+		findMany := ec.dbService.GetClient().Event.FindMany(where...).With(
+			db.Event.Tags.Fetch(),
+		).OrderBy(db.Event.ID.Order(db.SortOrderAsc)).Take(exportBatchSize)
+		if cursor != "" {
+			findMany = findMany.Cursor(db.Event.ID.Cursor(cursor)).Skip(1)
+		}
+		batch, err := findMany.Exec(ctx)
+		if err != nil {
+			problem.Internal(c, "Failed to fetch events", err)
+			return
+		}
+		for i := range batch {
+			if origin != nil {
+				if _, ok := distanceWithin(&batch[i], *origin, query.radiusKm()); !ok {
+					continue
+				}
+			}
+			counter.add(&batch[i])
+		}
+		if len(batch) < exportBatchSize {
+			break
+		}
+		cursor = batch[len(batch)-1].ID
+	}
+
+	c.JSON(http.StatusOK, counter.response())
+}
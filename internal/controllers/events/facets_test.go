@@ -0,0 +1,127 @@
+package events
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+	"github.com/shopspring/decimal"
+)
+
+func TestNewPriceBuckets(t *testing.T) {
+	buckets := newPriceBuckets([]float64{100, 50, 250})
+
+	keys := []string{"free", "0-100", "100-250", "250+"}
+	if len(buckets) != len(keys) {
+		t.Fatalf("expected %d buckets, got %+v", len(keys), buckets)
+	}
+	for i, key := range keys {
+		if buckets[i].key != key {
+			t.Errorf("bucket %d: expected %q, got %q", i, key, buckets[i].key)
+		}
+	}
+
+	tests := []struct {
+		price  string
+		bucket string
+	}{
+		{"0", "free"},
+		{"0.50", "0-100"},
+		{"99.99", "0-100"},
+		{"100", "100-250"},
+		{"250", "250+"},
+		{"1000", "250+"},
+	}
+	for _, tt := range tests {
+		price := decimal.RequireFromString(tt.price)
+		for _, bucket := range buckets {
+			if got := bucket.contains(price); got != (bucket.key == tt.bucket) {
+				t.Errorf("price %s: bucket %s contains = %v", tt.price, bucket.key, got)
+			}
+		}
+	}
+}
+
+func facetEvent(categoryID, price string, tags ...string) db.EventModel {
+	var event db.EventModel
+	event.InnerEvent.CategoryID = categoryID
+	event.InnerEvent.Price = decimal.RequireFromString(price)
+	for _, tag := range tags {
+		event.RelationsEvent.Tags = append(event.RelationsEvent.Tags, db.TagModel{InnerTag: db.InnerTag{Name: tag}})
+	}
+	return event
+}
+
+func TestFacetCounter(t *testing.T) {
+	music := newCategory("cat-music", "music", "Music")
+	parent := "cat-music"
+	jazz := newCategory("cat-jazz", "jazz", "Jazz")
+	jazz.InnerCategory.ParentID = &parent
+	categories := categoryIndex{music, jazz, newCategory("cat-party", "party", "Party")}
+
+	counter := newFacetCounter(categories, newPriceBuckets(DefaultPriceBuckets))
+	events := []db.EventModel{
+		facetEvent("cat-jazz", "0", "quiz", "english-friendly"),
+		facetEvent("cat-music", "120", "english-friendly"),
+		facetEvent("cat-jazz", "20", "outdoor"),
+	}
+	for i := range events {
+		counter.add(&events[i])
+	}
+	response := counter.response()
+
+	if response.Total != 3 {
+		t.Errorf("expected 3 events, got %d", response.Total)
+	}
+	wantTags := []TagFacet{{"english-friendly", 2}, {"outdoor", 1}, {"quiz", 1}}
+	if len(response.Tags) != len(wantTags) {
+		t.Fatalf("expected tags %v, got %v", wantTags, response.Tags)
+	}
+	for i, tag := range wantTags {
+		if response.Tags[i] != tag {
+			t.Errorf("tag %d: expected %v, got %v", i, tag, response.Tags[i])
+		}
+	}
+
+	// Events of a subcategory count towards the parent, and categories
+	// without events are left out
+	if len(response.Categories) != 2 ||
+		response.Categories[0].Slug != "music" || response.Categories[0].Count != 3 ||
+		response.Categories[1].Slug != "jazz" || response.Categories[1].Count != 2 ||
+		*response.Categories[1].ParentID != "cat-music" {
+		t.Errorf("unexpected categories %+v", response.Categories)
+	}
+
+	wantPrices := map[string]int{"free": 1, "0-100": 1, "100-250": 1, "250-500": 0, "500+": 0}
+	if len(response.Prices) != len(wantPrices) {
+		t.Fatalf("expected %d price ranges, got %+v", len(wantPrices), response.Prices)
+	}
+	for _, price := range response.Prices {
+		if price.Count != wantPrices[price.Key] {
+			t.Errorf("price range %s: expected %d, got %d", price.Key, wantPrices[price.Key], price.Count)
+		}
+	}
+}
+
+func TestGetEventFacets_InvalidQuery_Returns400(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ec := &Controller{}
+	r := gin.New()
+	r.GET("/events/facets", ec.GetEventFacets)
+
+	urls := []string{
+		"/events/facets?expand=true",
+		"/events/facets?tagMatch=some",
+		"/events/facets?minPrice=abc",
+		"/events/facets?near=65.58",
+	}
+	for _, url := range urls {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d. body=%s", url, w.Code, w.Body.String())
+		}
+	}
+}
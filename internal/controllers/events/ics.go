@@ -0,0 +1,47 @@
+package events
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/ical"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// GetEventICS godoc
+// @Summary      Export an event as iCalendar
+// @Description  Returns the event as an .ics file with a single VEVENT. Recurring events carry their RRULE;
+// @Description  changed occurrences are added as VEVENTs with a RECURRENCE-ID. The UID only depends on the
+// @Description  event ID, so importing the file again updates the event instead of duplicating it.
+// @Tags         events
+// @Produce      text/calendar
+// @Param        id   path      string  true  "Event ID"
+// @Success      200  {string}  string
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /events/{id}/ics [get]
+func (ec *Controller) GetEventICS(c *gin.Context) {
+	event, ok := ec.findVisibleEvent(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var overrides []db.EventOccurrenceOverrideModel
+	if _, recurring := event.RecurrenceRule(); recurring {
+		byEvent, err := ec.findOverrides(c, []string{event.ID})
+		if err != nil {
+			problem.Internal(c, "Failed to fetch occurrences", err)
+			return
+		}
+		overrides = byEvent[event.ID]
+	}
+
+	calendar := ical.Calendar{
+		Name:   event.Name,
+		Events: ical.FromEvent(event, overrides, ec.uidDomain),
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+event.ID+`.ics"`)
+	c.Data(http.StatusOK, ical.ContentType, []byte(calendar.String()))
+}
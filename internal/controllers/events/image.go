@@ -0,0 +1,211 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/imaging"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// DefaultMaxImageBytes is used when storage.max_image_bytes is not configured
+const DefaultMaxImageBytes int64 = 5 << 20
+
+// DefaultThumbnailWidths is used when storage.thumbnail_widths is not configured
+var DefaultThumbnailWidths = []int{320, 800}
+
+// multipartOverhead is allowed on top of the image size for the multipart
+// boundaries and part headers
+const multipartOverhead = 64 << 10
+
+// readImageUpload reads the "image" part of a multipart request, enforcing the
+// size limit and the supported types. On failure the response is already
+// written and false is returned.
+func (ec *Controller) readImageUpload(c *gin.Context) ([]byte, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, ec.maxImageBytes+multipartOverhead)
+
+	file, header, err := c.Request.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ec.imageTooLarge(c)
+			return nil, false
+		}
+		problem.Respond(c, http.StatusBadRequest, "image_required", "Image is required",
+			"send the image as the multipart form field \"image\": "+err.Error())
+		return nil, false
+	}
+	defer file.Close()
+
+	if header.Size > ec.maxImageBytes {
+		ec.imageTooLarge(c)
+		return nil, false
+	}
+	data, err := io.ReadAll(io.LimitReader(file, ec.maxImageBytes+1))
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, "invalid_image", "Failed to read image", err.Error())
+		return nil, false
+	}
+	if int64(len(data)) > ec.maxImageBytes {
+		ec.imageTooLarge(c)
+		return nil, false
+	}
+
+	// The type is taken from the content; the client's Content-Type is not trusted
+	if _, err := imaging.DetectType(data); err != nil {
+		ec.unsupportedImage(c, err)
+		return nil, false
+	}
+	return data, true
+}
+
+func (ec *Controller) imageTooLarge(c *gin.Context) {
+	problem.Respond(c, http.StatusRequestEntityTooLarge, "image_too_large", "Image is too large",
+		fmt.Sprintf("images may be at most %d bytes", ec.maxImageBytes))
+}
+
+func (ec *Controller) unsupportedImage(c *gin.Context, err error) {
+	problem.Respond(c, http.StatusUnsupportedMediaType, "unsupported_image_type", "Unsupported image type",
+		err.Error()+"; supported types are "+supportedImageTypes())
+}
+
+func supportedImageTypes() string {
+	types := make([]string, 0, len(imaging.Extensions))
+	for contentType := range imaging.Extensions {
+		types = append(types, contentType)
+	}
+	sort.Strings(types)
+	return strings.Join(types, ", ")
+}
+
+// thumbnailWidthsFor returns the configured thumbnail widths that are
+// narrower than the image, smallest first
+func (ec *Controller) thumbnailWidthsFor(imageWidth int) []int {
+	var widths []int
+	for _, width := range ec.thumbnailWidths {
+		if width > 0 && width < imageWidth {
+			widths = append(widths, width)
+		}
+	}
+	sort.Ints(widths)
+	return widths
+}
+
+// UploadEventImage godoc
+// @Summary      Upload an event image
+// @Description  Stores the image of an event and resized copies of it, and sets the event's imageUrl and imageThumbnails.
+// @Description  Send the image as the multipart form field "image". JPEG, PNG and GIF are supported; the type is detected from the content.
+// @Description  Only the owning organiser or an admin may upload it.
+// @Tags         events
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id     path      string  true  "Event ID"
+// @Param        image  formData  file    true  "Image file"
+// @Param        If-Match  header  string  false  "ETag of the version the upload is based on; required for dashboard clients"
+// @Success      200    {object}  map[string]interface{}
+// @Failure      400    {object}  map[string]interface{}
+// @Failure      403    {object}  map[string]interface{}
+// @Failure      404    {object}  map[string]interface{}
+// @Failure      409    {object}  map[string]interface{}
+// @Failure      412    {object}  map[string]interface{}
+// @Failure      413    {object}  map[string]interface{}
+// @Failure      415    {object}  map[string]interface{}
+// @Failure      428    {object}  map[string]interface{}
+// @Failure      500    {object}  map[string]interface{}
+// @Failure      503    {object}  map[string]interface{}
+// @Router       /events/{id}/image [post]
+func (ec *Controller) UploadEventImage(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID := c.Param("id")
+
+	if ec.storage == nil {
+		problem.Respond(c, http.StatusServiceUnavailable, "image_storage_unavailable", "Image uploads are unavailable",
+			"no image storage is configured")
+		return
+	}
+
+	data, ok := ec.readImageUpload(c)
+	if !ok {
+		return
+	}
+
+	current, ok := ec.authorizeEventWrite(c, eventID)
+	if !ok || !ec.checkIfMatch(c, current) {
+		return
+	}
+	if !isEditable(current.Status) {
+		problem.Respond(c, http.StatusConflict, "event_not_editable", "Event can no longer be changed",
+			"event is "+string(current.Status))
+		return
+	}
+
+	img, err := imaging.Decode(data)
+	if err != nil {
+		if errors.Is(err, imaging.ErrTooManyPixels) {
+			problem.Respond(c, http.StatusRequestEntityTooLarge, "image_too_large", "Image is too large", err.Error())
+			return
+		}
+		ec.unsupportedImage(c, err)
+		return
+	}
+
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	prefix := tenantID + "/events/" + eventID + "/" + uuid.NewString()
+
+	// Keys of the stored objects, so they can be removed if a later step fails
+	var stored []string
+	cleanup := func() {
+		for _, key := range stored {
+			_ = ec.storage.Delete(ctx, key)
+		}
+	}
+
+	key := prefix + imaging.Extensions[img.ContentType]
+	imageURL, err := ec.storage.Put(ctx, key, data, img.ContentType)
+	if err != nil {
+		problem.Internal(c, "Failed to store image", err)
+		return
+	}
+	stored = append(stored, key)
+
+	thumbnails := []string{}
+	for _, width := range ec.thumbnailWidthsFor(img.Bounds().Dx()) {
+		thumbnail, contentType, err := img.Thumbnail(width)
+		if err == nil {
+			key = fmt.Sprintf("%s-%dw%s", prefix, width, imaging.Extensions[contentType])
+			var thumbnailURL string
+			if thumbnailURL, err = ec.storage.Put(ctx, key, thumbnail, contentType); err == nil {
+				stored = append(stored, key)
+				thumbnails = append(thumbnails, thumbnailURL)
+			}
+		}
+		if err != nil {
+			cleanup()
+			problem.Internal(c, "Failed to store image thumbnail", err)
+			return
+		}
+	}
+
+	event, ok := ec.updateEvent(c, current, "Failed to update event",
+		db.Event.ImageURL.Set(imageURL),
+		db.Event.ImageThumbnails.Set(thumbnails),
+		db.Event.UpdatedAt.Set(time.Now()),
+	)
+	if !ok {
+		cleanup()
+		return
+	}
+	ec.announceChange(event.TenantID, event.ID)
+
+	c.Header("ETag", eventETag(event))
+	c.JSON(http.StatusOK, event)
+}
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/storage"
+)
+
+func newImageUpload(t *testing.T, field string, data []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, "cover.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/events/123/image", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestUploadEventImage_InvalidUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// The upload is checked before the event is loaded, so no database is needed
+	ec := &Controller{storage: storage.NewLocal(t.TempDir(), ""), maxImageBytes: 1024}
+	r := gin.New()
+	r.POST("/events/:id/image", ec.UploadEventImage)
+
+	tests := []struct {
+		name       string
+		req        *http.Request
+		wantStatus int
+	}{
+		{"no multipart body", httptest.NewRequest("POST", "/events/123/image", strings.NewReader(`{}`)), http.StatusBadRequest},
+		{"wrong field", newImageUpload(t, "file", []byte("\x89PNG\r\n\x1a\n")), http.StatusBadRequest},
+		{"too large", newImageUpload(t, "image", bytes.Repeat([]byte{0xff}, 2048)), http.StatusRequestEntityTooLarge},
+		{"not an image", newImageUpload(t, "image", []byte("hello world")), http.StatusUnsupportedMediaType},
+		{"svg", newImageUpload(t, "image", []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`)), http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, tt.req)
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: expected status %d, got %d. body=%s", tt.name, tt.wantStatus, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestUploadEventImage_WithoutStorage_Returns503(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ec := &Controller{maxImageBytes: DefaultMaxImageBytes}
+	r := gin.New()
+	r.POST("/events/:id/image", ec.UploadEventImage)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newImageUpload(t, "image", []byte("\x89PNG\r\n\x1a\n")))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d. body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestThumbnailWidthsFor(t *testing.T) {
+	ec := &Controller{thumbnailWidths: []int{800, 320, 1600}}
+
+	if got := ec.thumbnailWidthsFor(1200); !reflect.DeepEqual(got, []int{320, 800}) {
+		t.Errorf("expected the widths below the image width smallest first, got %v", got)
+	}
+	if got := ec.thumbnailWidthsFor(200); len(got) != 0 {
+		t.Errorf("expected no thumbnails for small images, got %v", got)
+	}
+}
@@ -0,0 +1,561 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+	"github.com/shopspring/decimal"
+)
+
+// Formats events can be imported from
+const (
+	ImportFormatCSV   = "csv"
+	ImportFormatJSONL = "jsonl"
+)
+
+// DefaultImportMaxRows is used when events.import_max_rows is not configured
+const DefaultImportMaxRows = 1000
+
+// maxImportBytes limits the size of an uploaded import file
+const maxImportBytes = 10 << 20
+
+// maxImportLineBytes limits the length of one JSON Lines row
+const maxImportLineBytes = 1 << 20
+
+// Statuses of the rows of an import report
+const (
+	// ImportRowValid marks a row that would be created; only used for dry runs
+	ImportRowValid   = "valid"
+	ImportRowCreated = "created"
+	ImportRowInvalid = "invalid"
+	// ImportRowSkipped marks a valid row that was not created because another
+	// row of an all-or-nothing import is invalid
+	ImportRowSkipped = "skipped"
+	// ImportRowFailed marks a valid row of a best-effort import that could not be stored
+	ImportRowFailed = "failed"
+)
+
+// ErrInvalidImport is returned for files that cannot be read as a whole, e.g.
+// a CSV file with an unknown column or more rows than allowed
+var ErrInvalidImport = errors.New("invalid import")
+
+// ImportOptions controls an import
+type ImportOptions struct {
+	// Format is ImportFormatCSV or ImportFormatJSONL
+	Format string
+	// DryRun validates the rows without creating events
+	DryRun bool
+	// BestEffort creates the valid rows even when other rows are invalid. By
+	// default the events are only created when every row is valid.
+	BestEffort bool
+	TenantID   string
+	// OrganizerID owns the imported events; rows may only name this organizer
+	OrganizerID string
+}
+
+// ImportRowResult reports what happened to one row. Line is the line of the
+// file the row starts on, counting a CSV header as line 1.
+type ImportRowResult struct {
+	Line    int               `json:"line"`
+	Status  string            `json:"status"`
+	Name    string            `json:"name,omitempty"`
+	EventID string            `json:"eventId,omitempty"`
+	Errors  validation.Errors `json:"errors,omitempty"`
+	// Error explains why a valid row could not be stored
+	Error string `json:"error,omitempty"`
+}
+
+// ImportReport is the result of an import
+type ImportReport struct {
+	DryRun     bool              `json:"dryRun"`
+	BestEffort bool              `json:"bestEffort"`
+	Total      int               `json:"total"`
+	Valid      int               `json:"valid"`
+	Invalid    int               `json:"invalid"`
+	Created    int               `json:"created"`
+	Failed     int               `json:"failed"`
+	Rows       []ImportRowResult `json:"rows"`
+}
+
+// importRow is a row read from an import file together with the errors found
+// while reading it
+type importRow struct {
+	line int
+	req  CreateEventRequest
+	errs validation.Errors
+}
+
+// Import reads events from r and creates them for opts.OrganizerID in
+// opts.TenantID. Every row is validated like a CreateEventRequest. Unless
+// opts.BestEffort is set, the events are created in one transaction and only
+// when every row is valid. Dry runs only read the categories of the tenant.
+func (ec *Controller) Import(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	maxRows := ec.importMaxRows
+	if maxRows <= 0 {
+		maxRows = DefaultImportMaxRows
+	}
+	rows, err := readImport(r, opts.Format, maxRows)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := ec.categoryStore.FindCategories(ctx, opts.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch categories: %w", err)
+	}
+
+	report := &ImportReport{
+		DryRun:     opts.DryRun,
+		BestEffort: opts.BestEffort,
+		Total:      len(rows),
+		Rows:       make([]ImportRowResult, len(rows)),
+	}
+	// Recurrence parameters of the valid rows, by row index
+	valid := make(map[int][]db.EventSetParam, len(rows))
+	for i := range rows {
+		row := &rows[i]
+		result := &report.Rows[i]
+		result.Line, result.Name = row.line, row.req.Name
+
+		series, errs := ec.validateImportRow(row, opts.OrganizerID, categories)
+		if len(errs) > 0 {
+			result.Status, result.Errors = ImportRowInvalid, errs
+			report.Invalid++
+			continue
+		}
+		result.Status = ImportRowValid
+		report.Valid++
+		valid[i] = series
+	}
+	if opts.DryRun {
+		return report, nil
+	}
+	if !opts.BestEffort && (report.Invalid > 0 || len(valid) == 0) {
+		for i := range valid {
+			report.Rows[i].Status = ImportRowSkipped
+		}
+		return report, nil
+	}
+
+	// Queries creating the valid rows, by row index. Events are linked to
+	// their tags by name, as the tags are only created in the transaction
+	// storing the events, so imports that fail leave no tags behind.
+	txs := make(map[int]db.PrismaTransaction, len(valid))
+	for i, series := range valid {
+		req := &rows[i].req
+		report.Rows[i].EventID = uuid.NewString()
+		params := append(series, linkTagNames(opts.TenantID, req.Tags)...)
+		txs[i] = ec.createEventTx(report.Rows[i].EventID, req, opts.TenantID, opts.OrganizerID, params)
+	}
+
+	if !opts.BestEffort {
+		var names []string
+		for i := range valid {
+			names = append(names, rows[i].req.Tags...)
+		}
+		all := make([]db.PrismaTransaction, 0, len(names)+len(txs))
+		for _, name := range normalizeTags(names) {
+			all = append(all, ec.upsertTagTx(opts.TenantID, name))
+		}
+		for i := range rows {
+			if tx, ok := txs[i]; ok {
+				all = append(all, tx)
+			}
+		}
+		if err := ec.dbService.GetClient().Prisma.Transaction(all...).Exec(ctx); err != nil {
+			return nil, fmt.Errorf("create events: %w", err)
+		}
+		for i := range txs {
+			report.Rows[i].Status = ImportRowCreated
+			ec.announceChange(opts.TenantID, report.Rows[i].EventID)
+		}
+		report.Created = len(txs)
+		return report, nil
+	}
+
+	for i := range rows {
+		tx, ok := txs[i]
+		if !ok {
+			continue
+		}
+		result := &report.Rows[i]
+		row := make([]db.PrismaTransaction, 0, len(rows[i].req.Tags)+1)
+		for _, name := range rows[i].req.Tags {
+			row = append(row, ec.upsertTagTx(opts.TenantID, name))
+		}
+		if err := ec.dbService.GetClient().Prisma.Transaction(append(row, tx)...).Exec(ctx); err != nil {
+			ec.logger.Errorf("Events: import of line %d failed: %v", result.Line, err)
+			result.Status, result.EventID, result.Error = ImportRowFailed, "", "the event could not be stored"
+			report.Failed++
+			continue
+		}
+		result.Status = ImportRowCreated
+		report.Created++
+		ec.announceChange(opts.TenantID, result.EventID)
+	}
+	return report, nil
+}
+
+// validateImportRow applies the rules of CreateEventRequest to a row and
+// returns the recurrence parameters of a valid row
+func (ec *Controller) validateImportRow(row *importRow, organizerID string, categories categoryIndex) ([]db.EventSetParam, validation.Errors) {
+	// Rows that could not be read at all are not validated further
+	if row.errs.Has("") {
+		return nil, row.errs
+	}
+
+	errs := append(validation.Errors(nil), row.errs...)
+	for _, fe := range ec.validateCreateEvent(&row.req, categories) {
+		// Values that could not be read are reported once
+		if !row.errs.Has(fe.Field) {
+			errs = append(errs, fe)
+		}
+	}
+	if row.req.OrganizerID != "" && row.req.OrganizerID != organizerID {
+		errs.Add("organizerId", validation.CodeInvalid, "must be empty or the organizer the events are imported for")
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	when := row.req.scheduled()
+	series, err := seriesParams(row.req.RecurrenceRule, row.req.RecurrenceExceptions, when.start(), when.EndsAt)
+	if err != nil {
+		errs.Add("recurrenceRule", validation.CodeInvalid, err.Error())
+		return nil, errs
+	}
+	return series, nil
+}
+
+// createEventTx returns the query creating an imported event with the given
+// ID. params holds the recurrence and tag parameters.
+func (ec *Controller) createEventTx(id string, req *CreateEventRequest, tenantID, organizerID string, params []db.EventSetParam) db.PrismaTransaction {
+	optional := append([]db.EventSetParam{
+		db.Event.ID.Set(id),
+		db.Event.AllDay.Set(req.AllDay),
+		db.Event.Latitude.SetIfPresent(req.Latitude),
+		db.Event.Longitude.SetIfPresent(req.Longitude),
+	}, params...)
+
+	return ec.dbService.GetClient().Event.CreateOne(
+		db.Event.Name.Set(req.Name),
+		db.Event.Description.Set(req.Description),
+		db.Event.StartsAt.Set(*req.StartsAt),
+		db.Event.EndsAt.Set(*req.EndsAt),
+		db.Event.Timezone.Set(req.Timezone),
+		db.Event.Price.Set(req.Price),
+		db.Event.Location.Set(req.Location),
+		db.Event.Capacity.Set(req.Capacity),
+		db.Event.ImageURL.Set(req.ImageURL),
+		db.Event.Tenant.Link(db.Tenant.ID.Equals(tenantID)),
+		db.Event.Organizer.Link(db.Organizer.ID.Equals(organizerID)),
+		db.Event.Category.Link(db.Category.ID.Equals(req.CategoryID)),
+		optional...,
+	).Tx()
+}
+
+// readImport reads the rows of an import file
+func readImport(r io.Reader, format string, maxRows int) ([]importRow, error) {
+	switch format {
+	case ImportFormatCSV:
+		return readCSVImport(r, maxRows)
+	case ImportFormatJSONL:
+		return readJSONLImport(r, maxRows)
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q, use %s or %s", ErrInvalidImport, format, ImportFormatCSV, ImportFormatJSONL)
+	}
+}
+
+func tooManyRows(maxRows int) error {
+	return fmt.Errorf("%w: at most %d rows can be imported at once", ErrInvalidImport, maxRows)
+}
+
+// readJSONLImport reads one CreateEventRequest object per line. Blank lines
+// are skipped.
+func readJSONLImport(r io.Reader, maxRows int) ([]importRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxImportLineBytes)
+
+	var rows []importRow
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if len(rows) == maxRows {
+			return nil, tooManyRows(maxRows)
+		}
+
+		row := importRow{line: line}
+		if err := json.Unmarshal([]byte(text), &row.req); err != nil {
+			if errs, ok := validation.FromError(err, &row.req); ok {
+				row.errs = errs
+			} else {
+				row.errs.Add("", validation.CodeInvalid, "is not a valid JSON object: "+err.Error())
+			}
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+	}
+	return rows, nil
+}
+
+// importColumn is a CSV column. Its name is the JSON name of the
+// CreateEventRequest field it fills.
+type importColumn struct {
+	name  string
+	index []int
+	// raw cells hold JSON numbers and are decoded as they are
+	raw bool
+	// list cells hold several values separated by ";"
+	list bool
+	// expected describes valid cells in error messages
+	expected string
+}
+
+// importColumns maps the lower-cased JSON names of the CreateEventRequest
+// fields to their CSV columns
+var importColumns = func() map[string]importColumn {
+	columns := map[string]importColumn{}
+	reqType := reflect.TypeOf(CreateEventRequest{})
+	for i := 0; i < reqType.NumField(); i++ {
+		field := reqType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		col := importColumn{name: name, index: field.Index}
+		t := field.Type
+		if t.Kind() == reflect.Slice {
+			col.list, t = true, t.Elem()
+		}
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch {
+		case t == reflect.TypeOf(time.Time{}):
+			col.expected = "an RFC 3339 timestamp, e.g. 2026-10-16T18:00:00+02:00"
+		case t == reflect.TypeOf(decimal.Decimal{}):
+			col.expected = "a decimal number"
+		case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+			col.raw, col.expected = true, "a whole number"
+		case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+			col.raw, col.expected = true, "a number"
+		case t.Kind() == reflect.Bool:
+			col.raw, col.expected = true, "true or false"
+		default:
+			col.expected = "text"
+		}
+		if col.list {
+			col.expected = "a list of " + col.expected + " values separated by \";\""
+		}
+		columns[strings.ToLower(name)] = col
+	}
+	return columns
+}()
+
+// decode stores the cell in dst, the field of the column
+func (col importColumn) decode(cell string, dst reflect.Value) error {
+	data := col.encode(cell)
+	if col.list {
+		items := []string{}
+		for _, item := range strings.Split(cell, ";") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, col.encode(item))
+			}
+		}
+		data = "[" + strings.Join(items, ",") + "]"
+	}
+	return json.Unmarshal([]byte(data), dst.Addr().Interface())
+}
+
+// encode turns a cell value into JSON
+func (col importColumn) encode(value string) string {
+	if col.raw {
+		return value
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// readCSVImport reads a CSV file whose header names the CreateEventRequest
+// fields, e.g. "name,description,startDate,...". Empty cells leave the field
+// unset; blank rows are skipped.
+func readCSVImport(r io.Reader, maxRows int) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	// Rows with the wrong number of cells are reported instead of aborting the import
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+	}
+	columns := make([]importColumn, len(header))
+	seen := map[string]bool{}
+	for i, name := range header {
+		if i == 0 {
+			// Spreadsheet applications start UTF-8 files with a byte order mark
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		key := strings.ToLower(strings.TrimSpace(name))
+		col, ok := importColumns[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidImport, name)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: duplicate column %q", ErrInvalidImport, name)
+		}
+		seen[key] = true
+		columns[i] = col
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(rows) == maxRows {
+			return nil, tooManyRows(maxRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := importRow{line: line}
+		if len(record) != len(columns) {
+			row.errs.Add("", validation.CodeInvalid, fmt.Sprintf("has %d cells but the header has %d columns", len(record), len(columns)))
+			rows = append(rows, row)
+			continue
+		}
+
+		req := reflect.ValueOf(&row.req).Elem()
+		for i, col := range columns {
+			cell := unescapeFormula(strings.TrimSpace(record[i]))
+			if cell == "" {
+				continue
+			}
+			if err := col.decode(cell, req.FieldByIndex(col.index)); err != nil {
+				row.errs.Add(col.name, validation.CodeInvalidType, "must be "+col.expected)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ImportEventsQuery holds the query parameters of an import
+type ImportEventsQuery struct {
+	// Format defaults to the one of the Content-Type
+	Format      string `form:"format" binding:"omitempty,oneof=csv jsonl"`
+	DryRun      bool   `form:"dryRun"`
+	BestEffort  bool   `form:"bestEffort"`
+	OrganizerID string `form:"organizerId"`
+}
+
+// importFormat returns the requested format, else the one of the Content-Type
+func importFormat(c *gin.Context, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	switch c.ContentType() {
+	case "text/csv":
+		return ImportFormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return ImportFormatJSONL
+	}
+	return ""
+}
+
+// ImportEvents godoc
+// @Summary      Import events
+// @Description  Creates events in the DRAFT state from a CSV or JSON Lines file sent as the request body.
+// @Description  CSV files start with a header naming the CreateEventRequest fields, e.g. name,description,startDate; lists such as recurrenceExceptions are separated by ";".
+// @Description  Every row is validated like a CreateEventRequest and reported with its line. By default the events are created in one transaction and only when every row is valid;
+// @Description  with bestEffort=true the valid rows are created and the invalid ones reported. With dryRun=true nothing is created.
+// @Tags         events
+// @Accept       text/csv,application/x-ndjson
+// @Produce      json
+// @Param        format       query     string  false  "csv or jsonl (default: taken from the Content-Type)"
+// @Param        dryRun       query     bool    false  "Only validate the rows"
+// @Param        bestEffort   query     bool    false  "Create the valid rows even when others are invalid"
+// @Param        organizerId  query     string  false  "Organizer the events belong to; required for admins"
+// @Success      200          {object}  ImportReport
+// @Success      201          {object}  ImportReport
+// @Failure      400          {object}  map[string]interface{}
+// @Failure      403          {object}  map[string]interface{}
+// @Failure      413          {object}  map[string]interface{}
+// @Failure      415          {object}  map[string]interface{}
+// @Failure      500          {object}  map[string]interface{}
+// @Router       /events/import [post]
+func (ec *Controller) ImportEvents(c *gin.Context) {
+	var query ImportEventsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+	format := importFormat(c, query.Format)
+	if format == "" {
+		problem.Respond(c, http.StatusUnsupportedMediaType, "unsupported_import_format", "Unsupported import format",
+			"send text/csv or application/x-ndjson, or set the format query parameter")
+		return
+	}
+
+	organizerID, ok := ec.resolveOrganizer(c, query.OrganizerID)
+	if !ok {
+		return
+	}
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	report, err := ec.Import(c.Request.Context(), c.Request.Body, ImportOptions{
+		Format:      format,
+		DryRun:      query.DryRun,
+		BestEffort:  query.BestEffort,
+		TenantID:    tenantID,
+		OrganizerID: organizerID,
+	})
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			problem.Respond(c, http.StatusRequestEntityTooLarge, "import_too_large", "Import file is too large",
+				fmt.Sprintf("import files may be at most %d bytes", maxImportBytes))
+		case errors.Is(err, ErrInvalidImport):
+			problem.Respond(c, http.StatusBadRequest, "invalid_import", "Invalid import file", err.Error())
+		default:
+			problem.Internal(c, "Failed to import events", err)
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if report.Created > 0 {
+		status = http.StatusCreated
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,170 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+)
+
+func dryRun(t *testing.T, ec *Controller, format, data string) *ImportReport {
+	t.Helper()
+	report, err := ec.Import(context.Background(), strings.NewReader(data), ImportOptions{
+		Format:      format,
+		DryRun:      true,
+		OrganizerID: "org-1",
+	})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	return report
+}
+
+// jsonLine compacts a JSON object onto one line
+func jsonLine(t *testing.T, object string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(object)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String() + "\n"
+}
+
+func TestImport_CSV_DryRun(t *testing.T) {
+	// Dry runs validate without touching the database
+	ec := &Controller{categoryStore: testCategories}
+	data := "\ufeffName,description,startDate,startTime,price,endDate,location,capacity,category,recurrenceExceptions\n" +
+		"Pub quiz,Quiz night,2026-01-01T00:00:00Z,2026-01-01T19:00:00Z,5.50,2026-01-01T00:00:00Z,Luleå,40,party,\n" +
+		",,,,,,,,,\n" +
+		"Hike,Day trip,2026-02-01T00:00:00Z,2026-02-01T09:00:00Z,\"12,50\",2026-01-01T00:00:00Z,Abisko,ten,Workshop,2026-02-08T09:00:00Z;bad\n" +
+		"Short row,only two cells\n"
+
+	report := dryRun(t, ec, ImportFormatCSV, data)
+
+	if report.Total != 3 || report.Valid != 1 || report.Invalid != 2 || report.Created != 0 {
+		t.Fatalf("unexpected counts: %+v", report)
+	}
+
+	valid := report.Rows[0]
+	if valid.Line != 2 || valid.Status != ImportRowValid || valid.Name != "Pub quiz" || valid.EventID != "" {
+		t.Errorf("unexpected valid row: %+v", valid)
+	}
+
+	invalid := report.Rows[1]
+	if invalid.Line != 4 || invalid.Status != ImportRowInvalid {
+		t.Fatalf("unexpected invalid row: %+v", invalid)
+	}
+	want := map[string]string{
+		"price":                validation.CodeInvalidType,
+		"capacity":             validation.CodeInvalidType,
+		"recurrenceExceptions": validation.CodeInvalidType,
+		"endDate":              validation.CodeBeforeStart,
+	}
+	for _, fe := range invalid.Errors {
+		if want[fe.Field] != fe.Code {
+			t.Errorf("unexpected error %+v", fe)
+		}
+		delete(want, fe.Field)
+	}
+	if len(want) > 0 {
+		t.Errorf("missing errors for %v, got %+v", want, invalid.Errors)
+	}
+
+	short := report.Rows[2]
+	if short.Line != 5 || len(short.Errors) != 1 || short.Errors[0].Field != "" {
+		t.Errorf("expected the short row to be reported once, got %+v", short)
+	}
+}
+
+func TestImport_JSONL_DryRun(t *testing.T) {
+	ec := &Controller{categoryStore: testCategories}
+	data := jsonLine(t, validCreateEventBody("")) + "\n" +
+		jsonLine(t, validCreateEventBody(`"capacity":"ten"`)) +
+		jsonLine(t, validCreateEventBody(`"organizerId":"org-2"`)) +
+		jsonLine(t, validCreateEventBody(`"category":"Parties"`)) +
+		jsonLine(t, validCreateEventBody(`"tags":["quiz","#!"]`)) +
+		"{not json\n"
+
+	report := dryRun(t, ec, ImportFormatJSONL, data)
+
+	tests := []struct {
+		line      int
+		status    string
+		wantField string
+	}{
+		{1, ImportRowValid, ""},
+		{3, ImportRowInvalid, "capacity"},
+		{4, ImportRowInvalid, "organizerId"},
+		{5, ImportRowInvalid, "category"},
+		{6, ImportRowInvalid, "tags"},
+		{7, ImportRowInvalid, ""},
+	}
+	if len(report.Rows) != len(tests) {
+		t.Fatalf("expected %d rows, got %+v", len(tests), report.Rows)
+	}
+	for i, tt := range tests {
+		row := report.Rows[i]
+		if row.Line != tt.line || row.Status != tt.status {
+			t.Errorf("row %d: expected line %d %s, got %+v", i, tt.line, tt.status, row)
+		}
+		if tt.status == ImportRowInvalid && !row.Errors.Has(tt.wantField) {
+			t.Errorf("row %d: expected an error for %q, got %+v", i, tt.wantField, row.Errors)
+		}
+	}
+}
+
+func TestImport_InvalidFile(t *testing.T) {
+	ec := &Controller{importMaxRows: 2, categoryStore: testCategories}
+	row := jsonLine(t, validCreateEventBody(""))
+
+	tests := []struct {
+		name   string
+		format string
+		data   string
+	}{
+		{"unknown format", "xlsx", ""},
+		{"unknown column", ImportFormatCSV, "name,colour\nQuiz,red\n"},
+		{"duplicate column", ImportFormatCSV, "name,Name\nQuiz,Quiz\n"},
+		{"too many rows", ImportFormatJSONL, row + row + row},
+	}
+	for _, tt := range tests {
+		_, err := ec.Import(context.Background(), strings.NewReader(tt.data), ImportOptions{Format: tt.format, DryRun: true})
+		if !errors.Is(err, ErrInvalidImport) {
+			t.Errorf("%s: expected ErrInvalidImport, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestImportEvents_RejectsUnknownFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// The format is checked before the organizer is resolved, so no database is needed
+	ec := &Controller{}
+	r := gin.New()
+	r.POST("/events/import", ec.ImportEvents)
+
+	tests := []struct {
+		name        string
+		url         string
+		contentType string
+		wantStatus  int
+	}{
+		{"unknown content type", "/events/import", "application/json", http.StatusUnsupportedMediaType},
+		{"invalid format parameter", "/events/import?format=xlsx", "text/csv", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", tt.url, strings.NewReader("name\nQuiz\n"))
+		req.Header.Set("Content-Type", tt.contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: expected status %d, got %d. body=%s", tt.name, tt.wantStatus, w.Code, w.Body.String())
+		}
+	}
+}
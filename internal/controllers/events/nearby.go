@@ -0,0 +1,81 @@
+package events
+
+import (
+	"context"
+	"sort"
+
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/geo"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// nearbyEventsSQL returns the IDs of the tenant's events within a radius of a
+// point. The bounding box lets PostgreSQL use the coordinate index; the
+// haversine formula, the same as geo.DistanceKm, then applies the exact
+// radius. The longitude bounds are skipped when the box wraps.
+const nearbyEventsSQL = `
+SELECT e."id"
+FROM "public"."Event" e
+WHERE e."tenantId" = $1
+  AND e."latitude" BETWEEN $2::float8 AND $3::float8
+  AND ($4::boolean OR e."longitude" BETWEEN $5::float8 AND $6::float8)
+  AND 2 * $7::float8 * asin(least(1, sqrt(
+          power(sin(radians(e."latitude" - $8::float8) / 2), 2) +
+          cos(radians($8::float8)) * cos(radians(e."latitude")) *
+          power(sin(radians(e."longitude" - $9::float8) / 2), 2)
+      ))) <= $10::float8`
+
+// nearbyEvent is a row of nearbyEventsSQL
+type nearbyEvent struct {
+	ID string `json:"id"`
+}
+
+// findNearbyIDs returns the IDs of the tenant's events within radiusKm of
+// origin, so listings can filter on them before a page is taken
+func (ec *Controller) findNearbyIDs(ctx context.Context, tenantID string, origin geo.Point, radiusKm float64) ([]string, error) {
+	box := geo.BoundingBoxAround(origin, radiusKm)
+	var rows []nearbyEvent
+	if err := ec.dbService.GetClient().Prisma.QueryRaw(
+		nearbyEventsSQL, tenantID,
+		box.MinLat, box.MaxLat, box.WrapsLongitude, box.MinLon, box.MaxLon,
+		geo.EarthRadiusKm, origin.Lat, origin.Lon, radiusKm,
+	).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	return ids, nil
+}
+
+// pageByDistance sorts events by their distance from origin, nearest first
+// unless descending, and returns up to limit of them following the event with
+// the cursor ID. Ties are broken by ID, as in the other sort orders.
+func pageByDistance(events []db.EventModel, origin geo.Point, cursor string, limit int, descending bool) []db.EventModel {
+	distances := make(map[string]float64, len(events))
+	for i := range events {
+		distances[events[i].ID], _ = distanceKm(&events[i], origin)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if distances[a.ID] != distances[b.ID] {
+			return (distances[a.ID] < distances[b.ID]) != descending
+		}
+		return (a.ID < b.ID) != descending
+	})
+
+	if cursor != "" {
+		after := len(events)
+		for i := range events {
+			if events[i].ID == cursor {
+				after = i + 1
+				break
+			}
+		}
+		events = events[after:]
+	}
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events
+}
@@ -0,0 +1,133 @@
+package events
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// eventETag returns the entity tag of the event's current version. It is a
+// strong tag, so clients can send it back with If-Match.
+func eventETag(event *db.EventModel) string {
+	return `"` + strconv.Itoa(event.Version) + `"`
+}
+
+// matchesETag reports whether an If-Match header lists the entity tag or is
+// "*". Weak tags never match, as If-Match uses the strong comparison. The
+// detail view tags its body as "<version>-<hash>", of which only the version
+// is compared, so availability changes do not fail writes.
+func matchesETag(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if version, _, ok := strings.Cut(tag, "-"); ok && strings.HasPrefix(version, `"`) {
+			tag = version + `"`
+		}
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// conditional reports whether a write is bound to the version its If-Match
+// header names
+func conditional(c *gin.Context) bool {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	return ifMatch != "" && ifMatch != "*"
+}
+
+// requiresIfMatch reports whether the caller's Keycloak client must send
+// If-Match with its writes
+func (ec *Controller) requiresIfMatch(c *gin.Context) bool {
+	clientID, ok := middlewares.GetUserClientFromContext(c)
+	return ok && ec.ifMatchClients[clientID]
+}
+
+// checkIfMatch checks the If-Match header of a write against the current
+// version of the event. Writes without If-Match pass unless the caller's
+// client must send it. On failure the response is already written and false
+// is returned.
+func (ec *Controller) checkIfMatch(c *gin.Context, current *db.EventModel) bool {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		if ec.requiresIfMatch(c) {
+			problem.Respond(c, http.StatusPreconditionRequired, "if_match_required", "If-Match header required",
+				"send the ETag returned by GET /api/v1/events/"+current.ID)
+			return false
+		}
+		return true
+	}
+	if !matchesETag(ifMatch, eventETag(current)) {
+		c.Header("ETag", eventETag(current))
+		versionConflict(c)
+		return false
+	}
+	return true
+}
+
+// versionConflict reports that the event changed since the client fetched it
+func versionConflict(c *gin.Context) {
+	problem.Respond(c, http.StatusPreconditionFailed, "etag_mismatch", "Event was changed in the meantime",
+		"fetch the event again and reapply your changes")
+}
+
+// updateEvent writes params to the event and moves it to its next version.
+// Conditional writes only update the version their If-Match names, so of
+// several writes based on the same version only the first applies; the
+// others get 412 like a stale If-Match. On failure the response is already
+// written and false is returned.
+func (ec *Controller) updateEvent(c *gin.Context, current *db.EventModel, message string, params ...db.EventSetParam) (*db.EventModel, bool) {
+	client := ec.dbService.GetClient()
+	params = append(params, db.Event.Version.Increment(1))
+
+	query := client.Event.FindUnique(db.Event.ID.Equals(current.ID))
+	if conditional(c) {
+		query = client.Event.FindUnique(db.Event.IDVersion(
+			db.Event.ID.Equals(current.ID),
+			db.Event.Version.Equals(current.Version),
+		))
+	}
+	event, err := query.Update(params...).Exec(c.Request.Context())
+	if err != nil {
+		ec.failedUpdate(c, current, message, err)
+		return nil, false
+	}
+	return event, true
+}
+
+// updateEventTx returns the query of updateEvent, for writes to records of the
+// event that must only apply together with its next version
+func (ec *Controller) updateEventTx(c *gin.Context, current *db.EventModel, params ...db.EventSetParam) db.PrismaTransaction {
+	client := ec.dbService.GetClient()
+	params = append(params, db.Event.Version.Increment(1))
+
+	query := client.Event.FindUnique(db.Event.ID.Equals(current.ID))
+	if conditional(c) {
+		query = client.Event.FindUnique(db.Event.IDVersion(
+			db.Event.ID.Equals(current.ID),
+			db.Event.Version.Equals(current.Version),
+		))
+	}
+	return query.Update(params...).Tx()
+}
+
+// failedUpdate responds to a failed write to the event. A conditional write
+// fails when another write moved the event past the version it is based on.
+func (ec *Controller) failedUpdate(c *gin.Context, current *db.EventModel, message string, err error) {
+	if conditional(c) {
+		latest, findErr := ec.dbService.GetClient().Event.FindUnique(
+			db.Event.ID.Equals(current.ID),
+		).Exec(c.Request.Context())
+		if findErr == nil && latest.Version != current.Version {
+			c.Header("ETag", eventETag(latest))
+			versionConflict(c)
+			return
+		}
+	}
+	problem.Internal(c, message, err)
+}
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+func TestMatchesETag(t *testing.T) {
+	tests := []struct {
+		header string
+		expect bool
+	}{
+		{`"3"`, true},
+		{`"2", "3"`, true},
+		{`*`, true},
+		{`"2"`, false},
+		{`W/"3"`, false},
+		{`"3-Yx9kQ2"`, true},
+		{`"2-Yx9kQ2"`, false},
+		{`W/"3-Yx9kQ2"`, false},
+	}
+	for _, tt := range tests {
+		if got := matchesETag(tt.header, `"3"`); got != tt.expect {
+			t.Errorf("%s: expected %v, got %v", tt.header, tt.expect, got)
+		}
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ec := &Controller{ifMatchClients: map[string]bool{"dws-frontend": true}}
+	event := &db.EventModel{}
+	event.ID = "evt-1"
+	event.Version = 3
+
+	tests := []struct {
+		name       string
+		client     string
+		ifMatch    string
+		wantOK     bool
+		wantStatus int
+	}{
+		{"current version", "dws-frontend", `"3"`, true, http.StatusOK},
+		{"stale version", "dws-frontend", `"2"`, false, http.StatusPreconditionFailed},
+		{"dashboard without If-Match", "dws-frontend", "", false, http.StatusPreconditionRequired},
+		{"other client without If-Match", "mobile-app", "", true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		ctx := context.WithValue(context.Background(), middlewares.UserClientKey, tt.client)
+		c.Request = httptest.NewRequest("PATCH", "/events/evt-1", nil).WithContext(ctx)
+		if tt.ifMatch != "" {
+			c.Request.Header.Set("If-Match", tt.ifMatch)
+		}
+
+		if ok := ec.checkIfMatch(c, event); ok != tt.wantOK {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.wantOK, ok)
+		}
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.wantStatus, w.Code)
+		}
+		if tt.wantStatus == http.StatusPreconditionFailed && w.Header().Get("ETag") != `"3"` {
+			t.Errorf("%s: expected the current ETag, got %q", tt.name, w.Header().Get("ETag"))
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package events
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/geo"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// DefaultPageSize is used when the client does not request a page size
+	DefaultPageSize = 20
+	// MaxPageSize is the largest page the server will return, regardless of the requested limit
+	MaxPageSize = 100
+
+	// DefaultRadiusKm is the search radius used when near is given without radius
+	DefaultRadiusKm = 10.0
+	// MaxRadiusKm is the largest search radius accepted for near queries
+	MaxRadiusKm = 500.0
+)
+
+// ListEventsQuery represents the query parameters accepted by the events listing
+type ListEventsQuery struct {
+	Cursor      string     `form:"cursor"`
+	Limit       int        `form:"limit" binding:"omitempty,min=1"`
+	Sort        string     `form:"sort"`
+	Category    string     `form:"category"`
+	CategoryID  string     `form:"categoryId"`
+	Tags        string     `form:"tags"`
+	TagMatch    string     `form:"tagMatch" binding:"omitempty,oneof=any all"`
+	OrganizerID string     `form:"organizerId"`
+	StartDate   *time.Time `form:"startDate"`
+	EndDate     *time.Time `form:"endDate"`
+	MinPrice    string     `form:"minPrice"`
+	MaxPrice    string     `form:"maxPrice"`
+	Free        bool       `form:"free"`
+	Location    string     `form:"location"`
+	Near        string     `form:"near"`
+	Radius      float64    `form:"radius" binding:"omitempty,gt=0"`
+	Expand      bool       `form:"expand"`
+}
+
+// origin returns the point given by the near parameter, or nil if the query
+// is not a geospatial one
+func (q *ListEventsQuery) origin() (*geo.Point, error) {
+	if q.Near == "" {
+		if q.Radius != 0 {
+			return nil, errors.New("radius requires near")
+		}
+		return nil, nil
+	}
+
+	point, err := geo.ParsePoint(q.Near)
+	if err != nil {
+		return nil, errors.New("near: " + err.Error())
+	}
+	if q.Radius > MaxRadiusKm {
+		return nil, errors.New("radius must not exceed 500 km")
+	}
+	return &point, nil
+}
+
+// radiusKm returns the requested search radius or the default
+func (q *ListEventsQuery) radiusKm() float64 {
+	if q.Radius <= 0 {
+		return DefaultRadiusKm
+	}
+	return q.Radius
+}
+
+// pageSize returns the requested page size clamped to the server limits
+func (q *ListEventsQuery) pageSize() int {
+	return clampPageSize(q.Limit)
+}
+
+// clampPageSize applies the default and maximum page size to a requested limit
+func clampPageSize(limit int) int {
+	if limit <= 0 {
+		return DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		return MaxPageSize
+	}
+	return limit
+}
+
+// sortsByDistance reports whether events are sorted by their distance from
+// near, which is done once the events within the radius are known
+func (q *ListEventsQuery) sortsByDistance() bool {
+	return strings.TrimPrefix(q.Sort, "-") == "distance"
+}
+
+// orderBy converts the sort parameter into Prisma order parameters.
+// A leading "-" sorts descending; startDate is the former name of startsAt.
+// The event ID is always added as a tie-breaker so cursor pagination stays
+// stable. Sorting by distance requires near and only orders by ID here.
+func (q *ListEventsQuery) orderBy() ([]db.EventOrderByParam, error) {
+	key := q.Sort
+	if key == "" {
+		key = "startsAt"
+	}
+
+	order := db.SortOrderAsc
+	if strings.HasPrefix(key, "-") {
+		order = db.SortOrderDesc
+		key = strings.TrimPrefix(key, "-")
+	}
+
+	var primary db.EventOrderByParam
+	switch key {
+	case "startsAt", "startDate":
+		primary = db.Event.StartsAt.Order(order)
+	case "price":
+		primary = db.Event.Price.Order(order)
+	case "createdAt":
+		primary = db.Event.CreatedAt.Order(order)
+	case "distance":
+		if q.Near == "" {
+			return nil, errors.New("sort by distance requires near")
+		}
+		return []db.EventOrderByParam{db.Event.ID.Order(order)}, nil
+	default:
+		return nil, errors.New("sort must be one of startsAt, price, createdAt, distance (prefix with - for descending)")
+	}
+
+	return []db.EventOrderByParam{primary, db.Event.ID.Order(order)}, nil
+}
+
+// sortItems sorts expanded occurrences in the order orderBy gives their
+// series, so that occurrences of different series interleave. Ties are broken
+// by event ID and then by start, in the same direction as the sort.
+func (q *ListEventsQuery) sortItems(items []EventListItem) {
+	key := q.Sort
+	if key == "" {
+		key = "startsAt"
+	}
+	descending := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+
+	compare := func(a, b *EventListItem) int {
+		switch key {
+		case "startsAt", "startDate":
+			return a.StartsAt.Compare(b.StartsAt)
+		case "price":
+			return a.Price.Cmp(b.Price)
+		case "createdAt":
+			return a.CreatedAt.Compare(b.CreatedAt)
+		case "distance":
+			if a.DistanceKm != nil && b.DistanceKm != nil && *a.DistanceKm != *b.DistanceKm {
+				if *a.DistanceKm < *b.DistanceKm {
+					return -1
+				}
+				return 1
+			}
+		}
+		return 0
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := &items[i], &items[j]
+		order := compare(a, b)
+		if order == 0 {
+			order = strings.Compare(a.ID, b.ID)
+		}
+		if order == 0 {
+			order = a.StartsAt.Compare(b.StartsAt)
+		}
+		return (order < 0) != descending && order != 0
+	})
+}
+
+// inCategory matches the events filed under the category with the given slug
+// or under one of its subcategories
+func inCategory(slug string) db.EventWhereParam {
+	return db.Event.Category.Where(db.Category.Or(
+		db.Category.Slug.Equals(slug),
+		db.Category.Parent.Where(db.Category.Slug.Equals(slug)),
+	))
+}
+
+// whereParams converts the filters into Prisma where parameters
+func (q *ListEventsQuery) whereParams() ([]db.EventWhereParam, error) {
+	var params []db.EventWhereParam
+
+	// A category matches its own events and those of its subcategories
+	if q.Category != "" {
+		params = append(params, inCategory(q.Category))
+	}
+	if q.CategoryID != "" {
+		params = append(params, db.Event.Category.Where(db.Category.Or(
+			db.Category.ID.Equals(q.CategoryID),
+			db.Category.ParentID.Equals(q.CategoryID),
+		)))
+	}
+	if tags := splitTags(q.Tags); len(tags) > 0 {
+		if q.TagMatch == TagMatchAll {
+			for _, tag := range tags {
+				params = append(params, db.Event.Tags.Some(db.Tag.Name.Equals(tag)))
+			}
+		} else {
+			params = append(params, db.Event.Tags.Some(db.Tag.Name.In(tags)))
+		}
+	}
+	if q.OrganizerID != "" {
+		params = append(params, db.Event.OrganizerID.Equals(q.OrganizerID))
+	}
+
+	// The date range matches every event that overlaps [startDate, endDate].
+	// A series matches if it starts before the range ends and its last
+	// occurrence (if any) ends after the range starts.
+	if q.StartDate != nil && q.EndDate != nil && q.EndDate.Before(*q.StartDate) {
+		return nil, errors.New("endDate must not be before startDate")
+	}
+	if q.Expand {
+		if _, _, err := expansionWindow(q.StartDate, q.EndDate); err != nil {
+			return nil, err
+		}
+	}
+	if q.StartDate != nil || q.EndDate != nil {
+		single := []db.EventWhereParam{db.Event.RecurrenceRule.EqualsOptional(nil)}
+		series := []db.EventWhereParam{db.Event.Not(db.Event.RecurrenceRule.EqualsOptional(nil))}
+		if q.StartDate != nil {
+			single = append(single, db.Event.EndsAt.Gte(*q.StartDate))
+			series = append(series, db.Event.Or(
+				db.Event.RecurrenceEndsAt.EqualsOptional(nil),
+				db.Event.RecurrenceEndsAt.Gte(*q.StartDate),
+			))
+		}
+		if q.EndDate != nil {
+			single = append(single, db.Event.StartsAt.Lte(*q.EndDate))
+			series = append(series, db.Event.StartsAt.Lte(*q.EndDate))
+		}
+		params = append(params, db.Event.Or(
+			db.Event.And(single...),
+			db.Event.And(series...),
+		))
+	}
+
+	if q.MinPrice != "" {
+		minPrice, err := decimal.NewFromString(q.MinPrice)
+		if err != nil {
+			return nil, errors.New("minPrice must be a number")
+		}
+		params = append(params, db.Event.Price.Gte(minPrice))
+	}
+	if q.MaxPrice != "" {
+		maxPrice, err := decimal.NewFromString(q.MaxPrice)
+		if err != nil {
+			return nil, errors.New("maxPrice must be a number")
+		}
+		params = append(params, db.Event.Price.Lte(maxPrice))
+	}
+	if q.Free {
+		params = append(params, db.Event.Price.Equals(decimal.Zero))
+	}
+
+	if q.Location != "" {
+		params = append(params,
+			db.Event.Location.Contains(q.Location),
+			db.Event.Location.Mode(db.QueryModeInsensitive),
+		)
+	}
+
+	// Near queries are pre-filtered with a bounding box; the exact radius is
+	// applied by nearbyEventsSQL or once the distances are known
+	origin, err := q.origin()
+	if err != nil {
+		return nil, err
+	}
+	if origin != nil {
+		box := geo.BoundingBoxAround(*origin, q.radiusKm())
+		params = append(params,
+			db.Event.Latitude.Gte(box.MinLat),
+			db.Event.Latitude.Lte(box.MaxLat),
+		)
+		if !box.WrapsLongitude {
+			params = append(params,
+				db.Event.Longitude.Gte(box.MinLon),
+				db.Event.Longitude.Lte(box.MaxLon),
+			)
+		}
+	}
+
+	return params, nil
+}
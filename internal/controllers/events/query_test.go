@@ -0,0 +1,207 @@
+package events
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/geo"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+func TestListEventsQuery_PageSize(t *testing.T) {
+	tests := []struct {
+		limit  int
+		expect int
+	}{
+		{0, DefaultPageSize},
+		{5, 5},
+		{MaxPageSize, MaxPageSize},
+		{MaxPageSize + 1, MaxPageSize},
+	}
+
+	for _, tt := range tests {
+		q := ListEventsQuery{Limit: tt.limit}
+		if got := q.pageSize(); got != tt.expect {
+			t.Errorf("limit %d: expected %d, got %d", tt.limit, tt.expect, got)
+		}
+	}
+}
+
+func TestListEventsQuery_OrderBy(t *testing.T) {
+	for _, sort := range []string{"", "startDate", "-startDate", "price", "-createdAt"} {
+		q := ListEventsQuery{Sort: sort}
+		if _, err := q.orderBy(); err != nil {
+			t.Errorf("sort %q: unexpected error %v", sort, err)
+		}
+	}
+
+	q := ListEventsQuery{Sort: "name"}
+	if _, err := q.orderBy(); err == nil {
+		t.Error("expected error for unsupported sort key")
+	}
+
+	q = ListEventsQuery{Sort: "-distance"}
+	if _, err := q.orderBy(); err == nil {
+		t.Error("expected error for sorting by distance without near")
+	}
+	q.Near = "65.5848,22.1547"
+	if _, err := q.orderBy(); err != nil || !q.sortsByDistance() {
+		t.Errorf("expected sorting by distance with near, got %v", err)
+	}
+}
+
+func TestListEventsQuery_WhereParams_InvalidValues(t *testing.T) {
+	queries := []ListEventsQuery{
+		{MinPrice: "cheap"},
+		{MaxPrice: "1,5"},
+	}
+
+	for _, q := range queries {
+		if _, err := q.whereParams(); err == nil {
+			t.Errorf("expected error for %+v", q)
+		}
+	}
+}
+
+func TestGetEvents_InvalidQuery_Returns400(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ec := &Controller{}
+	r := gin.New()
+	r.GET("/events", ec.GetEvents)
+
+	urls := []string{
+		"/events?sort=name",
+		"/events?limit=-1",
+		"/events?startDate=yesterday",
+		"/events?startDate=2026-02-01T00:00:00Z&endDate=2026-01-01T00:00:00Z",
+		"/events?minPrice=abc",
+		"/events?near=65.58",
+		"/events?radius=-3&near=65.58,22.15",
+		"/events?tags=quiz&tagMatch=some",
+	}
+
+	for _, url := range urls {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d. body=%s", url, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestListEventsQuery_BindsQueryString(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/events?limit=10&sort=-price&startDate=2026-01-01T00:00:00Z&free=true&category=music&categoryId=cat-1&tags=quiz,English%20friendly&tagMatch=all", nil)
+
+	var q ListEventsQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		t.Fatalf("unexpected bind error: %v", err)
+	}
+	if q.Limit != 10 || q.Sort != "-price" || !q.Free || q.Category != "music" || q.CategoryID != "cat-1" {
+		t.Fatalf("unexpected query: %+v", q)
+	}
+	if tags := splitTags(q.Tags); len(tags) != 2 || tags[1] != "english-friendly" || q.TagMatch != TagMatchAll {
+		t.Fatalf("unexpected tags %v matching %q", tags, q.TagMatch)
+	}
+	if q.StartDate == nil || q.StartDate.Year() != 2026 {
+		t.Fatalf("expected startDate to be parsed, got %v", q.StartDate)
+	}
+}
+
+func eventAt(id string, lat, lon float64) db.EventModel {
+	event := db.EventModel{}
+	event.ID = id
+	event.InnerEvent.Latitude = &lat
+	event.InnerEvent.Longitude = &lon
+	return event
+}
+
+func TestNewListEventsResponse_Pagination(t *testing.T) {
+	events := []db.EventModel{{}, {}, {}}
+	events[0].ID, events[1].ID, events[2].ID = "a", "b", "c"
+
+	page := newListEventsResponse(events, 2, nil)
+	if len(page.Events) != 2 || page.NextCursor == nil || *page.NextCursor != "b" {
+		t.Fatalf("unexpected page: %d events, cursor %v", len(page.Events), page.NextCursor)
+	}
+
+	last := newListEventsResponse(events[:2], 2, nil)
+	if last.NextCursor != nil {
+		t.Fatalf("expected no next cursor on last page, got %q", *last.NextCursor)
+	}
+}
+
+func TestNewListEventsResponse_Near(t *testing.T) {
+	origin := &geo.Point{Lat: 65.5848, Lon: 22.1547}
+	events := []db.EventModel{
+		eventAt("close", 65.5900, 22.1600),
+		eventAt("far", 65.8000, 22.1547),
+		{},
+	}
+
+	page := newListEventsResponse(events, 10, origin)
+	if len(page.Events) != 3 {
+		t.Fatalf("expected every fetched event, got %+v", page.Events)
+	}
+	if page.Events[0].DistanceKm == nil || *page.Events[0].DistanceKm > 1 {
+		t.Fatalf("expected a distance below 1 km, got %v", page.Events[0].DistanceKm)
+	}
+	if page.Events[1].DistanceKm == nil || *page.Events[1].DistanceKm < 20 {
+		t.Fatalf("expected a distance above 20 km, got %v", page.Events[1].DistanceKm)
+	}
+	if page.Events[2].DistanceKm != nil {
+		t.Fatalf("expected no distance without coordinates, got %v", *page.Events[2].DistanceKm)
+	}
+}
+
+func TestPageByDistance(t *testing.T) {
+	origin := geo.Point{Lat: 65.5848, Lon: 22.1547}
+	events := func() []db.EventModel {
+		return []db.EventModel{
+			eventAt("far", 65.8000, 22.1547),
+			eventAt("close", 65.5900, 22.1600),
+			eventAt("mid", 65.7000, 22.1547),
+		}
+	}
+
+	page := pageByDistance(events(), origin, "", 2, false)
+	if len(page) != 2 || page[0].ID != "close" || page[1].ID != "mid" {
+		t.Fatalf("expected the nearest events first, got %v, %v", page[0].ID, page[1].ID)
+	}
+	next := pageByDistance(events(), origin, "mid", 2, false)
+	if len(next) != 1 || next[0].ID != "far" {
+		t.Fatalf("expected the page after the cursor, got %+v", next)
+	}
+	farthest := pageByDistance(events(), origin, "", 1, true)
+	if len(farthest) != 1 || farthest[0].ID != "far" {
+		t.Fatalf("expected the farthest event first when descending, got %+v", farthest)
+	}
+}
+
+func TestListEventsQuery_Origin(t *testing.T) {
+	q := ListEventsQuery{Near: "65.5848,22.1547"}
+	origin, err := q.origin()
+	if err != nil || origin == nil {
+		t.Fatalf("expected origin, got %v, %v", origin, err)
+	}
+	if q.radiusKm() != DefaultRadiusKm {
+		t.Fatalf("expected default radius, got %v", q.radiusKm())
+	}
+
+	for _, invalid := range []ListEventsQuery{
+		{Near: "north"},
+		{Near: "95,10"},
+		{Radius: 5},
+		{Near: "65,22", Radius: MaxRadiusKm + 1},
+	} {
+		if _, err := invalid.origin(); err == nil {
+			t.Errorf("expected error for %+v", invalid)
+		}
+	}
+}
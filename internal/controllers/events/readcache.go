@@ -0,0 +1,126 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/cache"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/eventchange"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/httpcache"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+const (
+	// DefaultCacheTTL is used when events.cache.ttl is not configured
+	DefaultCacheTTL = 10 * time.Second
+	// DefaultCacheMaxEntries is used when events.cache.max_entries is not configured
+	DefaultCacheMaxEntries = 1000
+)
+
+// readCache holds the results of the queries behind event reads. Events are
+// keyed by ID; listing pages are keyed by tenant first and tenant states by
+// tenant alone, so a write drops everything of its tenant that may show the
+// event.
+type readCache struct {
+	events *cache.Cache[*db.EventModel]
+	lists  *cache.Cache[[]db.EventModel]
+	states *cache.Cache[tenantState]
+}
+
+// newReadCache returns the configured cache, or nil if it is disabled
+func newReadCache(cfg configs.EventsCache) *readCache {
+	if !cfg.Enabled {
+		return nil
+	}
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheMaxEntries
+	}
+	return &readCache{
+		events: cache.New[*db.EventModel](ttl, maxEntries),
+		lists:  cache.New[[]db.EventModel](ttl, maxEntries),
+		states: cache.New[tenantState](ttl, maxEntries),
+	}
+}
+
+// invalidate drops the changed event and everything of its tenant that may
+// show it. Changes without an event drop every event, as events are not keyed
+// by tenant; changes without a tenant drop the listings of every tenant.
+func (rc *readCache) invalidate(change eventchange.Change) {
+	if rc == nil {
+		return
+	}
+	tenantID := change.TenantID
+	if change.EventID != "" {
+		rc.events.Delete(change.EventID)
+	} else {
+		rc.events.DeletePrefix("")
+	}
+	if tenantID == "" {
+		rc.lists.DeletePrefix("")
+		rc.states.DeletePrefix("")
+		return
+	}
+	rc.lists.DeletePrefix(tenantID + "/")
+	rc.states.Delete(tenantID)
+}
+
+// load runs a query through one of the caches. Concurrent misses share the
+// query, so it must not be cancelled with the request that happened to start
+// it.
+func load[V any](ctx context.Context, c *cache.Cache[V], key string, query func(context.Context) (V, error)) (V, error) {
+	if c == nil {
+		return query(ctx)
+	}
+	return c.Do(key, func() (V, error) {
+		return query(context.WithoutCancel(ctx))
+	})
+}
+
+// findEvent returns the event with the relations of its detail view. The
+// caller checks that it belongs to the request's tenant.
+func (ec *Controller) findEvent(ctx context.Context, eventID string) (*db.EventModel, error) {
+	var events *cache.Cache[*db.EventModel]
+	if ec.readCache != nil {
+		events = ec.readCache.events
+	}
+	return load(ctx, events, eventID, func(ctx context.Context) (*db.EventModel, error) {
+		return ec.dbService.GetClient().Event.FindUnique(
+			db.Event.ID.Equals(eventID),
+		).With(
+			db.Event.Organizer.Fetch(),
+			db.Event.Category.Fetch(),
+			db.Event.Tags.Fetch(),
+			db.Event.TicketTypes.Fetch(),
+		).Exec(ctx)
+	})
+}
+
+// findListPage returns the events of a listing page. Pages are cached per
+// URL and viewer, like their entity tags.
+func (ec *Controller) findListPage(c *gin.Context, query func(context.Context) ([]db.EventModel, error)) ([]db.EventModel, error) {
+	var lists *cache.Cache[[]db.EventModel]
+	if ec.readCache != nil {
+		lists = ec.readCache.lists
+	}
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	key := tenantID + "/" + httpcache.Digest(
+		[]byte(c.Request.URL.Path),
+		[]byte(c.Request.URL.RawQuery),
+		[]byte(listViewer(c)),
+	)
+	return load(c.Request.Context(), lists, key, query)
+}
+
+// announceChange reports writes to events of the tenant, so that this and
+// every other replica drop what they cache of them
+func (ec *Controller) announceChange(tenantID string, eventIDs ...string) {
+	ec.changes.Announce(tenantID, eventIDs...)
+}
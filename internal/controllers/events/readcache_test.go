@@ -0,0 +1,84 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/eventchange"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// filledReadCache caches event evt-1 and a listing page and the state of
+// tenants t1 and t2
+func filledReadCache() *readCache {
+	rc := newReadCache(configs.EventsCache{Enabled: true})
+	rc.events.Set("evt-1", &db.EventModel{})
+	for _, tenantID := range []string{"t1", "t2"} {
+		rc.lists.Set(tenantID+"/page", []db.EventModel{})
+		rc.states.Set(tenantID, tenantState{})
+	}
+	return rc
+}
+
+func TestNewReadCache(t *testing.T) {
+	if rc := newReadCache(configs.EventsCache{}); rc != nil {
+		t.Error("expected no cache when disabled")
+	}
+	// A disabled cache drops nothing, without failing
+	var rc *readCache
+	rc.invalidate(eventchange.Change{TenantID: "t1", EventID: "evt-1"})
+}
+
+func TestReadCache_Invalidate(t *testing.T) {
+	rc := filledReadCache()
+	rc.invalidate(eventchange.Change{TenantID: "t1", EventID: "evt-1"})
+
+	if _, ok := rc.events.Get("evt-1"); ok {
+		t.Error("expected the event to be dropped")
+	}
+	if _, ok := rc.lists.Get("t1/page"); ok {
+		t.Error("expected the listings of the event's tenant to be dropped")
+	}
+	if _, ok := rc.states.Get("t1"); ok {
+		t.Error("expected the state of the event's tenant to be dropped")
+	}
+	if _, ok := rc.lists.Get("t2/page"); !ok {
+		t.Error("expected the listings of other tenants to be kept")
+	}
+}
+
+func TestReadCache_InvalidateTenant(t *testing.T) {
+	// Editing an organizer or category may show in any event of the tenant
+	rc := filledReadCache()
+	rc.invalidate(eventchange.Change{TenantID: "t2"})
+
+	if rc.events.Len() != 0 {
+		t.Error("expected every event to be dropped")
+	}
+	if _, ok := rc.lists.Get("t2/page"); ok {
+		t.Error("expected the listings of t2 to be dropped")
+	}
+	if _, ok := rc.lists.Get("t1/page"); !ok {
+		t.Error("expected the listings of t1 to be kept")
+	}
+
+	// Messages of other services may not name the tenant
+	rc = filledReadCache()
+	rc.invalidate(eventchange.Change{EventID: "evt-1"})
+	if rc.lists.Len() != 0 || rc.states.Len() != 0 {
+		t.Error("expected the listings of every tenant to be dropped")
+	}
+}
+
+func TestAnnounceChange(t *testing.T) {
+	ec := &Controller{readCache: filledReadCache(), changes: eventchange.New(nil, "", "event-service")}
+	ec.changes.OnChange(ec.readCache.invalidate)
+	ec.announceChange("t1", "evt-1")
+
+	if _, ok := ec.readCache.events.Get("evt-1"); ok {
+		t.Error("expected the event to be dropped")
+	}
+	if _, ok := ec.readCache.lists.Get("t1/page"); ok {
+		t.Error("expected the listings of t1 to be dropped")
+	}
+}
@@ -0,0 +1,504 @@
+package events
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/rrule"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// MaxExpansionWindow is the longest window for which occurrences are expanded
+const MaxExpansionWindow = 366 * 24 * time.Hour
+
+// SeriesHorizon bounds how far after the event start the first occurrence of
+// a rule without COUNT or UNTIL may lie. It covers leap days, which may be
+// eight years apart.
+const SeriesHorizon = 10 * 366 * 24 * time.Hour
+
+// seriesParams validates a recurrence rule and returns the parameters that
+// store it in canonical form, together with its exception dates and the end of
+// its last occurrence. An empty rule turns the event back into a single event.
+func seriesParams(rule string, exceptions []time.Time, start, end time.Time) ([]db.EventSetParam, error) {
+	if rule == "" {
+		return []db.EventSetParam{
+			db.Event.RecurrenceRule.SetOptional(nil),
+			db.Event.RecurrenceExceptions.Set([]time.Time{}),
+			db.Event.RecurrenceEndsAt.SetOptional(nil),
+		}, nil
+	}
+
+	parsed, err := rrule.Parse(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	var endsAt *time.Time
+	if parsed.IsFinite() {
+		last, ok := parsed.Last(start)
+		if !ok {
+			return nil, errors.New("rrule produces no occurrences after the event start")
+		}
+		lastEnd := end.Add(last.Sub(start)).UTC()
+		endsAt = &lastEnd
+	} else if _, ok := parsed.First(start, start.Add(SeriesHorizon)); !ok {
+		return nil, errors.New("rrule produces no occurrences within ten years of the event start")
+	}
+
+	if exceptions == nil {
+		exceptions = []time.Time{}
+	}
+	normalized := parsed.String()
+
+	return []db.EventSetParam{
+		db.Event.RecurrenceRule.SetOptional(&normalized),
+		db.Event.RecurrenceExceptions.Set(exceptions),
+		db.Event.RecurrenceEndsAt.SetOptional(endsAt),
+	}, nil
+}
+
+// changesSeries reports whether the patch touches the rule, its exceptions or
+// the schedule the series is anchored to
+func (req *PatchEventRequest) changesSeries() bool {
+	return req.RecurrenceRule != nil || req.RecurrenceExceptions != nil || req.scheduleFields().sent()
+}
+
+// mergeSeries returns the rule and exceptions that apply once the patch is applied to current
+func (req *PatchEventRequest) mergeSeries(current *db.EventModel) (string, []time.Time) {
+	rule, _ := current.RecurrenceRule()
+	if req.RecurrenceRule != nil {
+		rule = *req.RecurrenceRule
+	}
+	exceptions := current.RecurrenceExceptions
+	if req.RecurrenceExceptions != nil {
+		exceptions = req.RecurrenceExceptions
+	}
+	return rule, exceptions
+}
+
+// expansionWindow validates an occurrence window
+func expansionWindow(from, to *time.Time) (time.Time, time.Time, error) {
+	if from == nil || to == nil {
+		return time.Time{}, time.Time{}, errors.New("startDate and endDate are required to expand occurrences")
+	}
+	if to.Before(*from) {
+		return time.Time{}, time.Time{}, errors.New("endDate must not be before startDate")
+	}
+	if to.Sub(*from) > MaxExpansionWindow {
+		return time.Time{}, time.Time{}, errors.New("occurrence window must not exceed 366 days")
+	}
+	return *from, *to, nil
+}
+
+// isExcepted reports whether start was removed from the series through an exception date
+func isExcepted(event *db.EventModel, start time.Time) bool {
+	for _, exception := range event.RecurrenceExceptions {
+		if exception.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// isOccurrence reports whether start is an occurrence of the event's series
+func isOccurrence(event *db.EventModel, start time.Time) bool {
+	rule, ok := event.RecurrenceRule()
+	if !ok {
+		return false
+	}
+	parsed, err := rrule.Parse(rule)
+	if err != nil {
+		return false
+	}
+	matches := parsed.Between(eventSchedule(event).start(), start, start)
+	return len(matches) == 1 && !isExcepted(event, start)
+}
+
+// applyOccurrence turns a series item into the occurrence starting at start.
+// The end is shifted by the same offset as the start, then the override's
+// fields (if any) are applied on top.
+func applyOccurrence(item EventListItem, start time.Time, override *db.EventOccurrenceOverrideModel) EventListItem {
+	start = start.UTC()
+	item.EndsAt = item.EndsAt.Add(start.Sub(item.StartsAt))
+	item.StartsAt = start
+	item.OccurrenceStart = &start
+
+	if override != nil {
+		if v, ok := override.StartsAt(); ok {
+			item.StartsAt = v
+		}
+		if v, ok := override.EndsAt(); ok {
+			item.EndsAt = v
+		}
+		if v, ok := override.Name(); ok {
+			item.Name = v
+		}
+		if v, ok := override.Description(); ok {
+			item.Description = v
+		}
+		if v, ok := override.Location(); ok {
+			item.Location = v
+		}
+	}
+	item.LocalTimes = localTimes(item.StartsAt, item.EndsAt, item.Timezone)
+	return item
+}
+
+// expandSeries returns the occurrences of item that overlap [from, to].
+// Single events are returned as they are. Exception dates and cancelled
+// occurrences are left out.
+func expandSeries(item EventListItem, overrides []db.EventOccurrenceOverrideModel, from, to time.Time) []EventListItem {
+	rule, ok := item.RecurrenceRule()
+	if !ok {
+		return []EventListItem{item}
+	}
+	parsed, err := rrule.Parse(rule)
+	if err != nil {
+		return []EventListItem{item}
+	}
+
+	duration := item.EndsAt.Sub(item.StartsAt)
+	if duration < 0 {
+		duration = 0
+	}
+
+	occurrences := []EventListItem{}
+	for _, start := range parsed.Between(eventSchedule(&item.EventModel).start(), from.Add(-duration), to) {
+		if isExcepted(&item.EventModel, start) {
+			continue
+		}
+
+		var override *db.EventOccurrenceOverrideModel
+		for i := range overrides {
+			if overrides[i].OriginalStart.Equal(start) {
+				override = &overrides[i]
+				break
+			}
+		}
+		if override != nil && override.Cancelled {
+			continue
+		}
+
+		occurrences = append(occurrences, applyOccurrence(item, start, override))
+	}
+	return occurrences
+}
+
+// expandItems replaces every recurring event in items with its occurrences in [from, to]
+func expandItems(items []EventListItem, overrides map[string][]db.EventOccurrenceOverrideModel, from, to time.Time) []EventListItem {
+	expanded := []EventListItem{}
+	for _, item := range items {
+		expanded = append(expanded, expandSeries(item, overrides[item.ID], from, to)...)
+	}
+	return expanded
+}
+
+// findOverrides loads the occurrence overrides of the given events, keyed by event ID
+func (ec *Controller) findOverrides(c *gin.Context, eventIDs []string) (map[string][]db.EventOccurrenceOverrideModel, error) {
+	byEvent := map[string][]db.EventOccurrenceOverrideModel{}
+	if len(eventIDs) == 0 {
+		return byEvent, nil
+	}
+
+	overrides, err := ec.dbService.GetClient().EventOccurrenceOverride.FindMany(
+		db.EventOccurrenceOverride.EventID.In(eventIDs),
+	).Exec(c.Request.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, override := range overrides {
+		byEvent[override.EventID] = append(byEvent[override.EventID], override)
+	}
+	return byEvent, nil
+}
+
+// recurringIDs returns the IDs of the recurring events in items
+func recurringIDs(items []EventListItem) []string {
+	var ids []string
+	for _, item := range items {
+		if _, ok := item.RecurrenceRule(); ok {
+			ids = append(ids, item.ID)
+		}
+	}
+	return ids
+}
+
+// OccurrenceWindowQuery represents the window for listing occurrences
+type OccurrenceWindowQuery struct {
+	StartDate *time.Time `form:"startDate" binding:"required"`
+	EndDate   *time.Time `form:"endDate" binding:"required"`
+}
+
+// GetEventOccurrences godoc
+// @Summary      List occurrences of an event
+// @Description  Expands a recurring event into its occurrences within the window, with single-occurrence changes applied.
+// @Description  A single event is returned as its only occurrence.
+// @Tags         events
+// @Produce      json
+// @Param        id         path      string  true  "Event ID"
+// @Param        startDate  query     string  true  "Window start (RFC3339)"
+// @Param        endDate    query     string  true  "Window end (RFC3339), at most 366 days after startDate"
+// @Success      200  {array}   EventListItem
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /events/{id}/occurrences [get]
+func (ec *Controller) GetEventOccurrences(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID := c.Param("id")
+
+	var query OccurrenceWindowQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+	from, to, err := expansionWindow(query.StartDate, query.EndDate)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	event, err := ec.dbService.GetClient().Event.FindFirst(
+		db.Event.ID.Equals(eventID),
+		db.Event.TenantID.Equals(tenantID),
+	).With(
+		db.Event.Organizer.Fetch(),
+	).Exec(ctx)
+	if err != nil || !canViewEvent(c, event) {
+		problem.Respond(c, http.StatusNotFound, "event_not_found", "Event not found", "")
+		return
+	}
+
+	overrides, err := ec.findOverrides(c, []string{eventID})
+	if err != nil {
+		problem.Internal(c, "Failed to fetch occurrences", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, expandSeries(newEventListItem(*event), overrides[eventID], from, to))
+}
+
+// OccurrenceOverrideRequest represents the JSON payload for changing a single occurrence.
+// Omitted fields keep the value of the series.
+// @Description  Single occurrence change payload
+type OccurrenceOverrideRequest struct {
+	Name        *string    `json:"name" binding:"omitempty,min=1"`
+	Description *string    `json:"description" binding:"omitempty,min=1"`
+	Location    *string    `json:"location" binding:"omitempty,min=1"`
+	StartsAt    *time.Time `json:"startsAt"`
+	EndsAt      *time.Time `json:"endsAt"`
+	// StartTime (or StartDate) and EndDate are read when startsAt or endsAt
+	// is missing. Deprecated: use startsAt and endsAt.
+	StartDate *time.Time `json:"startDate"`
+	StartTime *time.Time `json:"startTime"`
+	EndDate   *time.Time `json:"endDate"`
+	Cancelled *bool      `json:"cancelled"`
+}
+
+// setParams converts the provided fields into Prisma parameters
+func (req *OccurrenceOverrideRequest) setParams() []db.EventOccurrenceOverrideSetParam {
+	var params []db.EventOccurrenceOverrideSetParam
+	if req.Name != nil {
+		params = append(params, db.EventOccurrenceOverride.Name.Set(*req.Name))
+	}
+	if req.Description != nil {
+		params = append(params, db.EventOccurrenceOverride.Description.Set(*req.Description))
+	}
+	if req.Location != nil {
+		params = append(params, db.EventOccurrenceOverride.Location.Set(*req.Location))
+	}
+	if startsAt := firstTime(req.StartsAt, req.StartTime, req.StartDate); startsAt != nil {
+		params = append(params, db.EventOccurrenceOverride.StartsAt.Set(startsAt.UTC()))
+	}
+	if endsAt := firstTime(req.EndsAt, req.EndDate); endsAt != nil {
+		params = append(params, db.EventOccurrenceOverride.EndsAt.Set(endsAt.UTC()))
+	}
+	if req.Cancelled != nil {
+		params = append(params, db.EventOccurrenceOverride.Cancelled.Set(*req.Cancelled))
+	}
+	return params
+}
+
+// validateTimes checks that the occurrence, as it currently is, does not end
+// before it starts once the request is applied
+func (req *OccurrenceOverrideRequest) validateTimes(current EventListItem) validation.Errors {
+	var errs validation.Errors
+	startsAt, endsAt := current.StartsAt, current.EndsAt
+	if v := firstTime(req.StartsAt, req.StartTime, req.StartDate); v != nil {
+		startsAt = *v
+	}
+	endField := "endsAt"
+	if req.EndsAt == nil && req.EndDate != nil {
+		endField = "endDate"
+	}
+	if v := firstTime(req.EndsAt, req.EndDate); v != nil {
+		endsAt = *v
+	}
+	if endsAt.Before(startsAt) {
+		errs.Add(endField, validation.CodeBeforeStart, "must not be before the start of the occurrence")
+	}
+	return errs
+}
+
+// firstTime returns the first of the times that is set
+func firstTime(times ...*time.Time) *time.Time {
+	for _, t := range times {
+		if t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// PatchEventOccurrence godoc
+// @Summary      Change a single occurrence
+// @Description  Changes one occurrence of a recurring event without touching the rest of the series.
+// @Description  Send "cancelled": false to restore a cancelled occurrence.
+// @Tags         events
+// @Accept       json
+// @Produce      json
+// @Param        id          path      string                     true  "Event ID"
+// @Param        occurrence  path      string                     true  "Original start of the occurrence (RFC3339, UTC)"
+// @Param        changes     body      OccurrenceOverrideRequest  true  "Fields to change"
+// @Param        If-Match  header  string  false  "ETag of the version the change is based on; required for dashboard clients"
+// @Success      200  {object}  EventListItem
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      422  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /events/{id}/occurrences/{occurrence} [patch]
+func (ec *Controller) PatchEventOccurrence(c *gin.Context) {
+	var req OccurrenceOverrideRequest
+	if !decodeJSON(c, &req) {
+		return
+	}
+	if errs := validation.Struct(&req); len(errs) > 0 {
+		problem.Validation(c, errs)
+		return
+	}
+
+	ec.overrideOccurrence(c, &req)
+}
+
+// CancelEventOccurrence godoc
+// @Summary      Cancel a single occurrence
+// @Description  Cancels one occurrence of a recurring event. The rest of the series is unchanged.
+// @Tags         events
+// @Produce      json
+// @Param        id          path      string  true  "Event ID"
+// @Param        occurrence  path      string  true  "Original start of the occurrence (RFC3339, UTC)"
+// @Param        If-Match  header  string  false  "ETag of the version the change is based on; required for dashboard clients"
+// @Success      200  {object}  EventListItem
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /events/{id}/occurrences/{occurrence}/cancel [post]
+func (ec *Controller) CancelEventOccurrence(c *gin.Context) {
+	cancelled := true
+	ec.overrideOccurrence(c, &OccurrenceOverrideRequest{Cancelled: &cancelled})
+}
+
+// overrideOccurrence applies the request to the override of the occurrence
+// named in the path and responds with the resulting occurrence. Like other
+// writes to the event it checks If-Match and moves the event to its next
+// version.
+func (ec *Controller) overrideOccurrence(c *gin.Context, req *OccurrenceOverrideRequest) {
+	ctx := c.Request.Context()
+	eventID := c.Param("id")
+
+	start, err := time.Parse(time.RFC3339, c.Param("occurrence"))
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, "invalid_occurrence", "Invalid occurrence",
+			"occurrence must be the RFC3339 start of the occurrence")
+		return
+	}
+
+	event, ok := ec.authorizeEventWrite(c, eventID)
+	if !ok || !ec.checkIfMatch(c, event) {
+		return
+	}
+	if !isEditable(event.Status) {
+		problem.Respond(c, http.StatusConflict, "event_not_editable", "Event can no longer be changed",
+			"event is "+string(event.Status))
+		return
+	}
+	if !isOccurrence(event, start) {
+		problem.Respond(c, http.StatusNotFound, "occurrence_not_found", "Occurrence not found", "")
+		return
+	}
+
+	client := ec.dbService.GetClient()
+	existing, err := client.EventOccurrenceOverride.FindFirst(
+		db.EventOccurrenceOverride.EventID.Equals(eventID),
+		db.EventOccurrenceOverride.OriginalStart.Equals(start),
+	).Exec(ctx)
+
+	var override *db.EventOccurrenceOverrideModel
+	if err == nil {
+		override = existing
+	}
+	if errs := req.validateTimes(applyOccurrence(newEventListItem(*event), start, override)); len(errs) > 0 {
+		problem.Validation(c, errs)
+		return
+	}
+	params := req.setParams()
+
+	var write db.PrismaTransaction
+	switch {
+	case err == nil:
+		write = client.EventOccurrenceOverride.FindUnique(
+			db.EventOccurrenceOverride.ID.Equals(existing.ID),
+		).Update(params...).Tx()
+	case db.IsErrNotFound(err):
+		write = client.EventOccurrenceOverride.CreateOne(
+			db.EventOccurrenceOverride.OriginalStart.Set(start),
+			db.EventOccurrenceOverride.Event.Link(db.Event.ID.Equals(eventID)),
+			params...,
+		).Tx()
+	default:
+		problem.Internal(c, "Failed to fetch occurrence", err)
+		return
+	}
+
+	// The series moves to its next version with the override, so clients
+	// notice that one of its occurrences changed
+	if err := client.Prisma.Transaction(
+		write,
+		ec.updateEventTx(c, event, db.Event.UpdatedAt.Set(time.Now())),
+	).Exec(ctx); err != nil {
+		ec.failedUpdate(c, event, "Failed to update occurrence", err)
+		return
+	}
+	ec.announceChange(event.TenantID, eventID)
+
+	updated, err := client.Event.FindUnique(
+		db.Event.ID.Equals(eventID),
+	).With(
+		db.Event.OccurrenceOverrides.Fetch(db.EventOccurrenceOverride.OriginalStart.Equals(start)),
+	).Exec(ctx)
+	if err != nil || len(updated.OccurrenceOverrides()) == 0 {
+		problem.Internal(c, "Failed to fetch occurrence", err)
+		return
+	}
+	override = &updated.OccurrenceOverrides()[0]
+
+	c.Header("ETag", eventETag(updated))
+	item := applyOccurrence(newEventListItem(*event), start, override)
+	item.OccurrenceCancelled = override.Cancelled
+	c.JSON(http.StatusOK, item)
+}
@@ -0,0 +1,223 @@
+package events
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+func weeklySeries() EventListItem {
+	rule := "FREQ=WEEKLY;COUNT=4"
+	start := time.Date(2026, 3, 2, 18, 0, 0, 0, time.UTC)
+
+	var item EventListItem
+	item.InnerEvent.ID = "series"
+	item.InnerEvent.Name = "Jazz night"
+	item.InnerEvent.StartsAt = start
+	item.InnerEvent.EndsAt = start.Add(3 * time.Hour)
+	item.InnerEvent.Timezone = "UTC"
+	item.InnerEvent.RecurrenceRule = &rule
+	return item
+}
+
+func TestExpandSeries_ShiftsDatesOfEachOccurrence(t *testing.T) {
+	item := weeklySeries()
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	occurrences := expandSeries(item, nil, from, to)
+	if len(occurrences) != 4 {
+		t.Fatalf("expected 4 occurrences, got %d", len(occurrences))
+	}
+
+	third := occurrences[2]
+	expectStart := time.Date(2026, 3, 16, 18, 0, 0, 0, time.UTC)
+	if !third.StartsAt.Equal(expectStart) || third.OccurrenceStart == nil || !third.OccurrenceStart.Equal(expectStart) {
+		t.Errorf("expected third occurrence at %s, got %s", expectStart, third.StartsAt)
+	}
+	if !third.EndsAt.Equal(expectStart.Add(3 * time.Hour)) {
+		t.Errorf("expected the end to keep the duration, got %s", third.EndsAt)
+	}
+	if third.StartsAtLocal != "2026-03-16T18:00:00Z" {
+		t.Errorf("expected the local start to be shifted, got %s", third.StartsAtLocal)
+	}
+}
+
+func TestExpandSeries_SkipsExceptionsAndCancelledOccurrences(t *testing.T) {
+	item := weeklySeries()
+	item.InnerEvent.RecurrenceExceptions = []time.Time{time.Date(2026, 3, 9, 18, 0, 0, 0, time.UTC)}
+
+	renamed := "Jazz night (unplugged)"
+	var cancelled, changed db.EventOccurrenceOverrideModel
+	cancelled.InnerEventOccurrenceOverride.OriginalStart = time.Date(2026, 3, 16, 18, 0, 0, 0, time.UTC)
+	cancelled.InnerEventOccurrenceOverride.Cancelled = true
+	changed.InnerEventOccurrenceOverride.OriginalStart = time.Date(2026, 3, 23, 18, 0, 0, 0, time.UTC)
+	changed.InnerEventOccurrenceOverride.Name = &renamed
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	occurrences := expandSeries(item, []db.EventOccurrenceOverrideModel{cancelled, changed}, from, to)
+
+	if len(occurrences) != 2 {
+		t.Fatalf("expected 2 occurrences, got %d", len(occurrences))
+	}
+	if occurrences[0].Name != "Jazz night" {
+		t.Errorf("expected first occurrence to keep the series name, got %q", occurrences[0].Name)
+	}
+	if occurrences[1].Name != renamed {
+		t.Errorf("expected override name %q, got %q", renamed, occurrences[1].Name)
+	}
+}
+
+func TestExpandSeries_IncludesOccurrenceRunningIntoWindow(t *testing.T) {
+	item := weeklySeries()
+	// The first occurrence runs 18:00-21:00, so it overlaps a window starting at 19:00
+	from := time.Date(2026, 3, 2, 19, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	occurrences := expandSeries(item, nil, from, to)
+	if len(occurrences) != 1 {
+		t.Fatalf("expected 1 occurrence, got %d", len(occurrences))
+	}
+}
+
+func TestListEventsQuery_SortItems_InterleavesSeries(t *testing.T) {
+	first := weeklySeries()
+	second := weeklySeries()
+	second.InnerEvent.ID = "another"
+	second.InnerEvent.StartsAt = first.StartsAt.Add(24 * time.Hour)
+	second.InnerEvent.EndsAt = first.EndsAt.Add(24 * time.Hour)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		sort      string
+		expectIDs []string
+	}{
+		{"", []string{"series", "another", "series", "another", "series", "another", "series", "another"}},
+		{"-startsAt", []string{"another", "series", "another", "series", "another", "series", "another", "series"}},
+	}
+
+	for _, tt := range tests {
+		items := expandItems([]EventListItem{first, second}, nil, from, to)
+		query := ListEventsQuery{Sort: tt.sort}
+		query.sortItems(items)
+
+		if len(items) != len(tt.expectIDs) {
+			t.Fatalf("sort %q: expected %d occurrences, got %d", tt.sort, len(tt.expectIDs), len(items))
+		}
+		for i, item := range items {
+			if item.ID != tt.expectIDs[i] {
+				t.Errorf("sort %q: expected %s at position %d, got %s", tt.sort, tt.expectIDs[i], i, item.ID)
+			}
+			if i > 0 && item.StartsAt.Equal(items[i-1].StartsAt) {
+				t.Errorf("sort %q: expected distinct starts, got %s twice", tt.sort, item.StartsAt)
+			}
+		}
+	}
+}
+
+func TestExpandSeries_SingleEventUnchanged(t *testing.T) {
+	var item EventListItem
+	item.InnerEvent.ID = "single"
+
+	occurrences := expandSeries(item, nil, time.Now(), time.Now().Add(time.Hour))
+	if len(occurrences) != 1 || occurrences[0].OccurrenceStart != nil {
+		t.Errorf("expected the single event unchanged, got %+v", occurrences)
+	}
+}
+
+func TestSeriesParams(t *testing.T) {
+	start := time.Date(2026, 3, 2, 18, 0, 0, 0, time.UTC)
+
+	if _, err := seriesParams("FREQ=SOMETIMES", nil, start, start); err == nil {
+		t.Error("expected an error for an invalid rule")
+	}
+	if params, err := seriesParams("", nil, start, start); err != nil || len(params) != 3 {
+		t.Errorf("expected an empty rule to clear the series, got %v, %v", params, err)
+	}
+	if params, err := seriesParams("freq=daily;count=3", nil, start, start); err != nil || len(params) != 3 {
+		t.Errorf("expected a valid rule to be accepted, got %v, %v", params, err)
+	}
+	if _, err := seriesParams("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", nil, start, start); err == nil {
+		t.Error("expected an error for an infinite rule without occurrences")
+	}
+}
+
+func TestOccurrenceOverrideRequest_ValidateTimes(t *testing.T) {
+	start := time.Date(2026, 3, 2, 18, 0, 0, 0, time.UTC)
+	var current EventListItem
+	current.InnerEvent.StartsAt = start
+	current.InnerEvent.EndsAt = start.Add(2 * time.Hour)
+
+	earlyEnd := start.Add(-time.Hour)
+	errs := (&OccurrenceOverrideRequest{EndsAt: &earlyEnd}).validateTimes(current)
+	if len(errs) != 1 || errs[0].Field != "endsAt" || errs[0].Code != validation.CodeBeforeStart {
+		t.Errorf("expected endsAt before the start to fail, got %v", errs)
+	}
+
+	lateStart := start.Add(3 * time.Hour)
+	if errs := (&OccurrenceOverrideRequest{StartsAt: &lateStart}).validateTimes(current); len(errs) != 1 {
+		t.Errorf("expected a start after the current end to fail, got %v", errs)
+	}
+	laterEnd := start.Add(4 * time.Hour)
+	if errs := (&OccurrenceOverrideRequest{StartsAt: &lateStart, EndsAt: &laterEnd}).validateTimes(current); len(errs) != 0 {
+		t.Errorf("expected a moved occurrence to pass, got %v", errs)
+	}
+}
+
+func TestExpansionWindow(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(30 * 24 * time.Hour)
+	tooLate := from.Add(400 * 24 * time.Hour)
+
+	if _, _, err := expansionWindow(&from, &to); err != nil {
+		t.Errorf("expected a 30 day window to be accepted, got %v", err)
+	}
+	if _, _, err := expansionWindow(&from, nil); err == nil {
+		t.Error("expected an error for a window without end")
+	}
+	if _, _, err := expansionWindow(&to, &from); err == nil {
+		t.Error("expected an error for a reversed window")
+	}
+	if _, _, err := expansionWindow(&from, &tooLate); err == nil {
+		t.Error("expected an error for a window longer than 366 days")
+	}
+}
+
+func TestGetEvents_ExpandWithoutWindow_Returns400(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ec := &Controller{}
+	r := gin.New()
+	r.GET("/events", ec.GetEvents)
+
+	req := httptest.NewRequest("GET", "/events?expand=true&startDate=2026-01-01T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d. body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestCancelEventOccurrence_InvalidOccurrence_Returns400(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ec := &Controller{}
+	r := gin.New()
+	r.POST("/events/:id/occurrences/:occurrence/cancel", ec.CancelEventOccurrence)
+
+	req := httptest.NewRequest("POST", "/events/abc/occurrences/tomorrow/cancel", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d. body=%s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,190 @@
+package events
+
+import (
+	"sync"
+	"time"
+	// Timezones resolve on hosts and images without a zoneinfo database
+	_ "time/tzdata"
+
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// DefaultTimezone is used when events.default_timezone is not configured
+const DefaultTimezone = "Europe/Stockholm"
+
+// schedule is when an event takes place, resolved from a payload
+type schedule struct {
+	StartsAt time.Time
+	EndsAt   time.Time
+	Timezone string
+	AllDay   bool
+}
+
+// params returns the parameters storing the schedule
+func (s schedule) params() []db.EventSetParam {
+	return []db.EventSetParam{
+		db.Event.StartsAt.Set(s.StartsAt),
+		db.Event.EndsAt.Set(s.EndsAt),
+		db.Event.Timezone.Set(s.Timezone),
+		db.Event.AllDay.Set(s.AllDay),
+	}
+}
+
+// start returns the start in the event's timezone, which recurrence rules are
+// expanded in so that occurrences keep their local time across DST changes
+func (s schedule) start() time.Time {
+	return s.StartsAt.In(loadLocation(s.Timezone))
+}
+
+// scheduleFields are the fields of a payload that say when the event takes
+// place. StartDate, StartTime and EndDate are the fields before startsAt and
+// endsAt; they are still accepted for one version. Nil fields were not sent.
+type scheduleFields struct {
+	StartsAt  *time.Time
+	EndsAt    *time.Time
+	Timezone  *string
+	AllDay    *bool
+	StartDate *time.Time
+	StartTime *time.Time
+	EndDate   *time.Time
+}
+
+// sent reports whether any of the fields was sent
+func (f scheduleFields) sent() bool {
+	return f.StartsAt != nil || f.EndsAt != nil || f.Timezone != nil || f.AllDay != nil ||
+		f.StartDate != nil || f.StartTime != nil || f.EndDate != nil
+}
+
+// resolveSchedule checks the fields and returns the schedule they describe.
+// Fields that were not sent are taken from base, which holds the defaults of
+// a new event or the stored schedule of the event being changed.
+//
+// startsAt and endsAt win over the deprecated fields. Of those, startTime is
+// the start, or startDate if startTime is missing, and endDate the end. Some
+// clients only sent the day as endDate, so an endDate at midnight UTC before
+// the start is taken as the end of that day. Errors are reported for the
+// fields the client sent. For all-day events only the dates count: the event
+// runs from midnight of the start's day to midnight of the end's day in the
+// event's timezone, and an end with a time of day is rounded up to the next
+// midnight.
+func resolveSchedule(errs *validation.Errors, f scheduleFields, base schedule) schedule {
+	// Stored times are read in the timezone they were stored for, so the days
+	// of an all-day event survive a change of its timezone
+	baseLoc := loadLocation(base.Timezone)
+	s := base
+	s.StartsAt, s.EndsAt = base.StartsAt.In(baseLoc), base.EndsAt.In(baseLoc)
+	if f.Timezone != nil && *f.Timezone != "" {
+		s.Timezone = *f.Timezone
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil || s.Timezone == "" || s.Timezone == "Local" {
+		errs.Add("timezone", validation.CodeInvalid, "must be an IANA timezone, e.g. Europe/Stockholm")
+		loc = time.UTC
+	}
+	if f.AllDay != nil {
+		s.AllDay = *f.AllDay
+	}
+
+	startField, endField := "startsAt", "endsAt"
+	switch {
+	case f.StartsAt != nil:
+		s.StartsAt = *f.StartsAt
+	case f.StartTime != nil:
+		s.StartsAt, startField = *f.StartTime, "startTime"
+	case f.StartDate != nil:
+		s.StartsAt, startField = *f.StartDate, "startDate"
+	}
+	legacyEnd := false
+	switch {
+	case f.EndsAt != nil:
+		s.EndsAt = *f.EndsAt
+	case f.EndDate != nil:
+		s.EndsAt, endField, legacyEnd = *f.EndDate, "endDate", true
+	}
+
+	if s.StartsAt.IsZero() {
+		errs.Add(startField, validation.CodeRequired, "is required")
+	}
+	if s.EndsAt.IsZero() {
+		errs.Add(endField, validation.CodeRequired, "is required")
+	}
+	if errs.Has(startField) || errs.Has(endField) {
+		return s
+	}
+
+	if legacyEnd && s.EndsAt.Before(s.StartsAt) && isMidnight(s.EndsAt.UTC()) {
+		s.EndsAt = s.EndsAt.AddDate(0, 0, 1)
+	}
+
+	if s.AllDay {
+		s.StartsAt = midnight(s.StartsAt, loc)
+		end := midnight(s.EndsAt, loc)
+		if !isMidnight(s.EndsAt) {
+			end = end.AddDate(0, 0, 1)
+		}
+		s.EndsAt = end
+		if !s.EndsAt.After(s.StartsAt) {
+			errs.Add(endField, validation.CodeBeforeStart, "must be a later day than "+startField+" for all-day events")
+		}
+	} else if s.EndsAt.Before(s.StartsAt) {
+		errs.Add(endField, validation.CodeBeforeStart, "must not be before "+startField)
+	}
+
+	s.StartsAt, s.EndsAt = s.StartsAt.UTC(), s.EndsAt.UTC()
+	return s
+}
+
+// midnight returns the start of t's day, taking the date as t is written, in loc
+func midnight(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// isMidnight reports whether t is written as the start of a day
+func isMidnight(t time.Time) bool {
+	h, m, s := t.Clock()
+	return h == 0 && m == 0 && s == 0 && t.Nanosecond() == 0
+}
+
+// eventSchedule returns the stored schedule of an event
+func eventSchedule(event *db.EventModel) schedule {
+	return schedule{
+		StartsAt: event.StartsAt,
+		EndsAt:   event.EndsAt,
+		Timezone: event.Timezone,
+		AllDay:   event.AllDay,
+	}
+}
+
+// locations caches loaded timezones by name
+var locations sync.Map
+
+// loadLocation returns the timezone with the given IANA name, or UTC if the
+// name is unknown
+func loadLocation(name string) *time.Location {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		loc = time.UTC
+	}
+	locations.Store(name, loc)
+	return loc
+}
+
+// LocalTimes renders the start and end of an event in its timezone, e.g.
+// 2026-08-20T19:00:00+02:00, next to the UTC instants
+type LocalTimes struct {
+	StartsAtLocal string `json:"startsAtLocal"`
+	EndsAtLocal   string `json:"endsAtLocal"`
+}
+
+func localTimes(startsAt, endsAt time.Time, timezone string) LocalTimes {
+	loc := loadLocation(timezone)
+	return LocalTimes{
+		StartsAtLocal: startsAt.In(loc).Format(time.RFC3339),
+		EndsAtLocal:   endsAt.In(loc).Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+)
+
+func timeAt(value string) *time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestResolveSchedule(t *testing.T) {
+	stockholm := "Europe/Stockholm"
+	allDay, mars := true, "Mars/Olympus"
+	base := schedule{Timezone: stockholm}
+
+	tests := []struct {
+		name      string
+		fields    scheduleFields
+		wantStart string
+		wantEnd   string
+		wantError string
+	}{
+		{
+			name:      "instants",
+			fields:    scheduleFields{StartsAt: timeAt("2026-08-20T19:00:00+02:00"), EndsAt: timeAt("2026-08-20T22:00:00+02:00")},
+			wantStart: "2026-08-20T17:00:00Z",
+			wantEnd:   "2026-08-20T20:00:00Z",
+		},
+		{
+			name: "deprecated fields",
+			fields: scheduleFields{
+				StartDate: timeAt("2026-08-20T00:00:00Z"),
+				StartTime: timeAt("2026-08-20T17:00:00Z"),
+				EndDate:   timeAt("2026-08-20T00:00:00Z"),
+			},
+			wantStart: "2026-08-20T17:00:00Z",
+			wantEnd:   "2026-08-21T00:00:00Z",
+		},
+		{
+			name:      "start date only",
+			fields:    scheduleFields{StartDate: timeAt("2026-08-20T00:00:00Z"), EndDate: timeAt("2026-08-21T00:00:00Z")},
+			wantStart: "2026-08-20T00:00:00Z",
+			wantEnd:   "2026-08-21T00:00:00Z",
+		},
+		{
+			name:      "all day",
+			fields:    scheduleFields{StartsAt: timeAt("2026-06-19T00:00:00Z"), EndsAt: timeAt("2026-06-20T12:00:00Z"), AllDay: &allDay},
+			wantStart: "2026-06-18T22:00:00Z",
+			wantEnd:   "2026-06-20T22:00:00Z",
+		},
+		{
+			name:      "all day ending on its first day",
+			fields:    scheduleFields{StartsAt: timeAt("2026-06-19T00:00:00Z"), EndsAt: timeAt("2026-06-19T00:00:00Z"), AllDay: &allDay},
+			wantError: "endsAt",
+		},
+		{
+			name:      "end before start",
+			fields:    scheduleFields{StartsAt: timeAt("2026-08-20T19:00:00Z"), EndDate: timeAt("2026-08-19T00:00:00Z")},
+			wantError: "endDate",
+		},
+		{
+			name:      "missing start",
+			fields:    scheduleFields{EndsAt: timeAt("2026-08-20T19:00:00Z")},
+			wantError: "startsAt",
+		},
+		{
+			name:      "unknown timezone",
+			fields:    scheduleFields{StartsAt: timeAt("2026-08-20T19:00:00Z"), EndsAt: timeAt("2026-08-20T19:00:00Z"), Timezone: &mars},
+			wantError: "timezone",
+		},
+	}
+	for _, tt := range tests {
+		var errs validation.Errors
+		s := resolveSchedule(&errs, tt.fields, base)
+		if tt.wantError != "" {
+			if !errs.Has(tt.wantError) {
+				t.Errorf("%s: expected an error on %s, got %+v", tt.name, tt.wantError, errs)
+			}
+			continue
+		}
+		if len(errs) > 0 {
+			t.Errorf("%s: expected no errors, got %+v", tt.name, errs)
+			continue
+		}
+		if got := formatTime(s.StartsAt); got != tt.wantStart {
+			t.Errorf("%s: expected start %s, got %s", tt.name, tt.wantStart, got)
+		}
+		if got := formatTime(s.EndsAt); got != tt.wantEnd {
+			t.Errorf("%s: expected end %s, got %s", tt.name, tt.wantEnd, got)
+		}
+		if s.Timezone != stockholm {
+			t.Errorf("%s: expected the base timezone, got %s", tt.name, s.Timezone)
+		}
+	}
+}
+
+func TestResolveSchedule_KeepsDaysOfAllDayEventsAcrossTimezones(t *testing.T) {
+	// Midsummer in Stockholm moved to Helsinki still covers the same two days
+	stored := schedule{
+		StartsAt: *timeAt("2026-06-18T22:00:00Z"),
+		EndsAt:   *timeAt("2026-06-20T22:00:00Z"),
+		Timezone: "Europe/Stockholm",
+		AllDay:   true,
+	}
+	helsinki := "Europe/Helsinki"
+	var errs validation.Errors
+	s := resolveSchedule(&errs, scheduleFields{Timezone: &helsinki}, stored)
+	if len(errs) > 0 {
+		t.Fatalf("expected no errors, got %+v", errs)
+	}
+	local := localTimes(s.StartsAt, s.EndsAt, s.Timezone)
+	if local.StartsAtLocal != "2026-06-19T00:00:00+03:00" || local.EndsAtLocal != "2026-06-21T00:00:00+03:00" {
+		t.Errorf("expected the event to keep its days, got %+v", local)
+	}
+}
@@ -0,0 +1,150 @@
+package events
+
+import (
+	"html"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/shopspring/decimal"
+)
+
+// searchEventsSQL ranks events against a prefix tsquery using the searchVector
+// column (maintained by PostgreSQL, see the event_search migration) and returns
+// highlighted snippets. Matches are marked with the control characters
+// highlightStart and highlightStop, which are removed from the text first;
+// highlightHTML escapes the snippets and turns them into <mark> tags. Events
+// match if visibleEventsFilter would list them: $5 is the tenant, $6 whether
+// the caller sees all of its events and $2 the caller's user ID.
+const searchEventsSQL = `
+SELECT e."id", e."name", e."description", e."location", e."categoryId", c."name" AS "category", e."price",
+       e."startsAt", e."endsAt", e."timezone", e."allDay", e."imageUrl", e."status",
+       ts_rank(e."searchVector", query) AS "rank",
+       ts_headline('simple', translate(e."name", chr(2) || chr(3), ''), query,
+           'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', HighlightAll=true') AS "nameHighlight",
+       ts_headline('simple', translate(e."description", chr(2) || chr(3), ''), query,
+           'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxFragments=2, MaxWords=20, MinWords=5') AS "snippet"
+FROM "public"."Event" e
+JOIN "public"."Organizer" o ON o."id" = e."organizerId"
+JOIN "public"."Category" c ON c."id" = e."categoryId",
+     to_tsquery('simple', $1) query
+WHERE e."searchVector" @@ query
+  AND e."tenantId" = $5
+  AND ($6::boolean OR e."status" = 'PUBLISHED' OR o."keycloakId" = $2)
+ORDER BY "rank" DESC, e."startsAt" ASC
+LIMIT $3 OFFSET $4`
+
+// Markers of the matches in the highlights of searchEventsSQL
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+// highlightReplacer turns the markers into <mark> tags once the text is escaped
+var highlightReplacer = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// highlightHTML HTML-escapes a highlight of searchEventsSQL and wraps its
+// matches in <mark> tags. Escaping after highlighting keeps queries such as
+// "amp" from matching inside entities.
+func highlightHTML(highlight string) string {
+	return highlightReplacer.Replace(html.EscapeString(highlight))
+}
+
+// SearchEventsQuery represents the query parameters accepted by the search endpoint
+type SearchEventsQuery struct {
+	Q      string `form:"q" binding:"required"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+// SearchResult is a single ranked full-text search hit, with its start and
+// end also in the event's timezone
+type SearchResult struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Location    string          `json:"location"`
+	CategoryID  string          `json:"categoryId"`
+	Category    string          `json:"category"`
+	Price       decimal.Decimal `json:"price"`
+	StartsAt    time.Time       `json:"startsAt"`
+	EndsAt      time.Time       `json:"endsAt"`
+	Timezone    string          `json:"timezone"`
+	AllDay      bool            `json:"allDay"`
+	LocalTimes
+	ImageURL      string  `json:"imageUrl"`
+	Status        string  `json:"status"`
+	Rank          float64 `json:"rank"`
+	NameHighlight string  `json:"nameHighlight"`
+	Snippet       string  `json:"snippet"`
+}
+
+// buildPrefixQuery turns free text into a tsquery where every term must match
+// as a prefix, e.g. "jazz nig" becomes "jazz:* & nig:*". Characters with a
+// special meaning in tsquery syntax are dropped. Returns "" if no terms remain.
+func buildPrefixQuery(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		term := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToLower(r)
+			}
+			return -1
+		}, word)
+		if term != "" {
+			terms = append(terms, term+":*")
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+// SearchEvents godoc
+// @Summary      Search events
+// @Description  Full-text search over event name, description and location. Every term matches as a prefix.
+// @Description  Results are ranked by relevance and include HTML-escaped highlights with matches wrapped in <mark> tags.
+// @Tags         events
+// @Produce      json
+// @Param        q       query     string  true   "Search text"
+// @Param        limit   query     int     false  "Page size (default 20, max 100)"
+// @Param        offset  query     int     false  "Number of results to skip"
+// @Success      200  {array}   SearchResult
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /events/search [get]
+func (ec *Controller) SearchEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var query SearchEventsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+
+	tsQuery := buildPrefixQuery(query.Q)
+	if tsQuery == "" {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters",
+			"q must contain at least one letter or digit")
+		return
+	}
+
+	pageSize := clampPageSize(query.Limit)
+	visibility := visibilityOf(c)
+
+	results := []SearchResult{}
+	if err := ec.dbService.GetClient().Prisma.QueryRaw(
+		searchEventsSQL, tsQuery, visibility.userID, pageSize, query.Offset, visibility.tenantID, visibility.all,
+	).Exec(ctx, &results); err != nil {
+		problem.Internal(c, "Failed to search events", err)
+		return
+	}
+	for i := range results {
+		results[i].LocalTimes = localTimes(results[i].StartsAt, results[i].EndsAt, results[i].Timezone)
+		results[i].NameHighlight = highlightHTML(results[i].NameHighlight)
+		results[i].Snippet = highlightHTML(results[i].Snippet)
+	}
+
+	c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,64 @@
+package events
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBuildPrefixQuery(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"jazz", "jazz:*"},
+		{"Jazz  Nig", "jazz:* & nig:*"},
+		{"rock & roll!", "rock:* & roll:*"},
+		{"Göteborg", "göteborg:*"},
+		{"'):* |", ""},
+	}
+
+	for _, tt := range tests {
+		if got := buildPrefixQuery(tt.input); got != tt.expect {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expect, got)
+		}
+	}
+}
+
+func TestSearchEvents_InvalidQuery_Returns400(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ec := &Controller{}
+	r := gin.New()
+	r.GET("/events/search", ec.SearchEvents)
+
+	for _, url := range []string{"/events/search", "/events/search?q=%26%7C", "/events/search?q=jazz&offset=-1"} {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d. body=%s", url, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestHighlightHTML(t *testing.T) {
+	tests := []struct {
+		highlight string
+		expect    string
+	}{
+		// A query for "amp" does not match the escaped ampersand
+		{"Tom & Jerry", "Tom &amp; Jerry"},
+		{"\x02Jazz\x03 <live> & \x02jam\x03", "<mark>Jazz</mark> &lt;live&gt; &amp; <mark>jam</mark>"},
+		{"\x02Quiz\x03 \"night\"", "<mark>Quiz</mark> &#34;night&#34;"},
+	}
+
+	for _, tt := range tests {
+		if got := highlightHTML(tt.highlight); got != tt.expect {
+			t.Errorf("%q: expected %q, got %q", tt.highlight, tt.expect, got)
+		}
+	}
+}
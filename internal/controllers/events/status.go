@@ -0,0 +1,175 @@
+package events
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// statusTransitions lists the lifecycle states each state may move to.
+// Archived is terminal; cancelled events are kept for history and can only be archived.
+var statusTransitions = map[db.EventStatus][]db.EventStatus{
+	db.EventStatusDraft:     {db.EventStatusPublished, db.EventStatusCancelled, db.EventStatusArchived},
+	db.EventStatusPublished: {db.EventStatusPostponed, db.EventStatusCancelled, db.EventStatusArchived},
+	db.EventStatusPostponed: {db.EventStatusPublished, db.EventStatusCancelled, db.EventStatusArchived},
+	db.EventStatusCancelled: {db.EventStatusArchived},
+}
+
+// canTransition reports whether an event may move from one lifecycle state to another
+func canTransition(from, to db.EventStatus) bool {
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// isEditable reports whether the event's details may still be changed
+func isEditable(status db.EventStatus) bool {
+	return status != db.EventStatusCancelled && status != db.EventStatusArchived
+}
+
+// capacityCovers reports whether capacity leaves room for the event's sold and held seats
+func capacityCovers(event *db.EventModel, capacity int) bool {
+	return capacity >= event.SeatsSold+event.SeatsHeld
+}
+
+// PublishEvent godoc
+// @Summary      Publish an event
+// @Description  Makes a draft or postponed event visible to students
+// @Tags         events
+// @Produce      json
+// @Param        id        path      string  true   "Event ID"
+// @Param        If-Match  header    string  false  "ETag of the version the change is based on; required for dashboard clients"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
+// @Router       /events/{id}/publish [post]
+func (ec *Controller) PublishEvent(c *gin.Context) {
+	ec.transitionEvent(c, db.EventStatusPublished)
+}
+
+// PostponeEvent godoc
+// @Summary      Postpone an event
+// @Description  Marks a published event as postponed
+// @Tags         events
+// @Produce      json
+// @Param        id        path      string  true   "Event ID"
+// @Param        If-Match  header    string  false  "ETag of the version the change is based on; required for dashboard clients"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
+// @Router       /events/{id}/postpone [post]
+func (ec *Controller) PostponeEvent(c *gin.Context) {
+	ec.transitionEvent(c, db.EventStatusPostponed)
+}
+
+// CancelEvent godoc
+// @Summary      Cancel an event
+// @Description  Cancels an event. The event is kept for history instead of being deleted.
+// @Tags         events
+// @Produce      json
+// @Param        id        path      string  true   "Event ID"
+// @Param        If-Match  header    string  false  "ETag of the version the change is based on; required for dashboard clients"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
+// @Router       /events/{id}/cancel [post]
+func (ec *Controller) CancelEvent(c *gin.Context) {
+	ec.transitionEvent(c, db.EventStatusCancelled)
+}
+
+// ArchiveEvent godoc
+// @Summary      Archive an event
+// @Description  Archives an event. Archived events can no longer be changed.
+// @Tags         events
+// @Produce      json
+// @Param        id        path      string  true   "Event ID"
+// @Param        If-Match  header    string  false  "ETag of the version the change is based on; required for dashboard clients"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
+// @Router       /events/{id}/archive [post]
+func (ec *Controller) ArchiveEvent(c *gin.Context) {
+	ec.transitionEvent(c, db.EventStatusArchived)
+}
+
+// transitionEvent moves an event to the target state if the caller may manage
+// the event and the transition is allowed from its current state
+func (ec *Controller) transitionEvent(c *gin.Context, target db.EventStatus) {
+	eventID := c.Param("id")
+
+	current, ok := ec.authorizeEventWrite(c, eventID)
+	if !ok || !ec.checkIfMatch(c, current) {
+		return
+	}
+
+	if !canTransition(current.Status, target) {
+		problem.Respond(c, http.StatusConflict, "invalid_status_transition", "Invalid status transition",
+			"cannot move event from "+string(current.Status)+" to "+string(target))
+		return
+	}
+
+	event, ok := ec.updateStatus(c, current, target)
+	if !ok {
+		return
+	}
+	ec.announceChange(event.TenantID, event.ID)
+
+	c.Header("ETag", eventETag(event))
+	c.JSON(http.StatusOK, event)
+}
+
+// updateStatus moves the event to the target state. Conditional writes are
+// bound to the version their If-Match names; others only apply while the
+// event is still in the state the transition was checked against, so of two
+// concurrent transitions only the first applies and the other gets 409. On
+// failure the response is already written and false is returned.
+func (ec *Controller) updateStatus(c *gin.Context, current *db.EventModel, target db.EventStatus) (*db.EventModel, bool) {
+	params := []db.EventSetParam{
+		db.Event.Status.Set(target),
+		db.Event.UpdatedAt.Set(time.Now()),
+	}
+	if conditional(c) {
+		return ec.updateEvent(c, current, "Failed to update event status", params...)
+	}
+
+	ctx := c.Request.Context()
+	client := ec.dbService.GetClient()
+	result, err := client.Event.FindMany(
+		db.Event.ID.Equals(current.ID),
+		db.Event.Status.Equals(current.Status),
+	).Update(append(params, db.Event.Version.Increment(1))...).Exec(ctx)
+	if err != nil {
+		problem.Internal(c, "Failed to update event status", err)
+		return nil, false
+	}
+	if result.Count == 0 {
+		problem.Respond(c, http.StatusConflict, "invalid_status_transition", "Invalid status transition",
+			"the event's status was changed in the meantime")
+		return nil, false
+	}
+
+	event, err := client.Event.FindUnique(db.Event.ID.Equals(current.ID)).Exec(ctx)
+	if err != nil {
+		problem.Internal(c, "Failed to update event status", err)
+		return nil, false
+	}
+	return event, true
+}
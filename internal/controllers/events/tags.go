@@ -0,0 +1,204 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+const (
+	// MaxEventTags is the most tags a single event may carry
+	MaxEventTags = 10
+	// maxTagLength limits the length of a tag name
+	maxTagLength = 40
+)
+
+// Values of the tagMatch parameter of the events listing
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// normalizeTag turns free text into a tag name: lowercase letters and digits
+// with the words joined by hyphens, so "English friendly" and
+// "english-friendly" are the same tag
+func normalizeTag(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// normalizeTags normalizes the names and drops empty names and duplicates
+func normalizeTags(names []string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		tag := normalizeTag(name)
+		if tag != "" && !seen[tag] {
+			tags = append(tags, tag)
+			seen[tag] = true
+		}
+	}
+	return tags
+}
+
+// checkTags normalizes the tags of a payload in place and reports tags that
+// are empty or too long, and too many tags
+func checkTags(errs *validation.Errors, tags *[]string) {
+	if tags == nil || errs.Has("tags") {
+		return
+	}
+	for _, name := range *tags {
+		tag := normalizeTag(name)
+		if tag == "" {
+			errs.Add("tags", validation.CodeInvalid, "must contain letters or digits")
+			return
+		}
+		if len([]rune(tag)) > maxTagLength {
+			errs.Add("tags", validation.CodeTooLarge, "must be at most 40 characters each")
+			return
+		}
+	}
+	*tags = normalizeTags(*tags)
+	if len(*tags) > MaxEventTags {
+		errs.Add("tags", validation.CodeTooLarge, "must not hold more than 10 tags")
+	}
+}
+
+// splitTags reads a comma-separated list of tags from a query parameter
+func splitTags(param string) []string {
+	if param == "" {
+		return nil
+	}
+	return normalizeTags(strings.Split(param, ","))
+}
+
+// ensureTags returns the IDs of the tenant's tags with the given names and
+// creates the tags that do not exist yet. names must be normalized.
+func (ec *Controller) ensureTags(ctx context.Context, tenantID string, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	client := ec.dbService.GetClient()
+	existing, err := client.Tag.FindMany(
+		db.Tag.TenantID.Equals(tenantID),
+		db.Tag.Name.In(names),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := map[string]string{}
+	for _, tag := range existing {
+		ids[tag.Name] = tag.ID
+	}
+
+	result := make([]string, len(names))
+	for i, name := range names {
+		if id, ok := ids[name]; ok {
+			result[i] = id
+			continue
+		}
+		// Another request may create the same tag concurrently, so the tag is
+		// upserted rather than created
+		tag, err := client.Tag.UpsertOne(
+			db.Tag.TenantIDName(
+				db.Tag.TenantID.Equals(tenantID),
+				db.Tag.Name.Equals(name),
+			),
+		).Create(
+			db.Tag.Name.Set(name),
+			db.Tag.Tenant.Link(db.Tenant.ID.Equals(tenantID)),
+		).Update().Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = tag.ID
+	}
+	return result, nil
+}
+
+// upsertTagTx returns the query creating the tenant's tag with the given name
+// unless it exists, for creating tags in the transaction of the events that
+// use them
+func (ec *Controller) upsertTagTx(tenantID, name string) db.PrismaTransaction {
+	return ec.dbService.GetClient().Tag.UpsertOne(
+		db.Tag.TenantIDName(
+			db.Tag.TenantID.Equals(tenantID),
+			db.Tag.Name.Equals(name),
+		),
+	).Create(
+		db.Tag.Name.Set(name),
+		db.Tag.Tenant.Link(db.Tenant.ID.Equals(tenantID)),
+	).Update().Tx()
+}
+
+// linkTagNames returns the parameters linking a new event to the tenant's
+// tags with the given names
+func linkTagNames(tenantID string, names []string) []db.EventSetParam {
+	if len(names) == 0 {
+		return nil
+	}
+	where := make([]db.TagWhereParam, len(names))
+	for i, name := range names {
+		where[i] = db.Tag.TenantIDName(
+			db.Tag.TenantID.Equals(tenantID),
+			db.Tag.Name.Equals(name),
+		)
+	}
+	return []db.EventSetParam{db.Event.Tags.Link(where...)}
+}
+
+// linkTags returns the parameters linking a new event to the tags with the
+// given IDs
+func linkTags(ids []string) []db.EventSetParam {
+	if len(ids) == 0 {
+		return nil
+	}
+	where := make([]db.TagWhereParam, len(ids))
+	for i, id := range ids {
+		where[i] = db.Tag.ID.Equals(id)
+	}
+	return []db.EventSetParam{db.Event.Tags.Link(where...)}
+}
+
+// replaceTags returns the parameters replacing the current tags of an event
+// with the tags with the given IDs
+func replaceTags(current []db.TagModel, ids []string) []db.EventSetParam {
+	keep := map[string]bool{}
+	for _, id := range ids {
+		keep[id] = true
+	}
+	var unlink []db.TagWhereParam
+	var added []string
+	for _, tag := range current {
+		if !keep[tag.ID] {
+			unlink = append(unlink, db.Tag.ID.Equals(tag.ID))
+		}
+		delete(keep, tag.ID)
+	}
+	for _, id := range ids {
+		if keep[id] {
+			added = append(added, id)
+		}
+	}
+
+	params := linkTags(added)
+	if len(unlink) > 0 {
+		params = append(params, db.Event.Tags.Unlink(unlink...))
+	}
+	return params
+}
+
+// tagNames returns the names of the event's tags; the event must be loaded
+// with its tags
+func tagNames(event *db.EventModel) []string {
+	names := make([]string, len(event.RelationsEvent.Tags))
+	for i, tag := range event.RelationsEvent.Tags {
+		names[i] = tag.Name
+	}
+	return names
+}
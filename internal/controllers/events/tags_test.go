@@ -0,0 +1,90 @@
+package events
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		text   string
+		expect string
+	}{
+		{"quiz", "quiz"},
+		{"English friendly", "english-friendly"},
+		{"  English--Friendly! ", "english-friendly"},
+		{"Kräftskiva 2026", "kräftskiva-2026"},
+		{"#!", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeTag(tt.text); got != tt.expect {
+			t.Errorf("%q: expected %q, got %q", tt.text, tt.expect, got)
+		}
+	}
+}
+
+func TestCheckTags(t *testing.T) {
+	tooMany := make([]string, MaxEventTags+1)
+	for i := range tooMany {
+		tooMany[i] = "tag-" + string(rune('a'+i))
+	}
+
+	tests := []struct {
+		name     string
+		tags     []string
+		expect   []string
+		wantCode string
+	}{
+		{"normalized and deduplicated", []string{"Quiz", "quiz ", "English friendly"}, []string{"quiz", "english-friendly"}, ""},
+		{"empty tag", []string{"quiz", "--"}, nil, validation.CodeInvalid},
+		{"long tag", []string{strings.Repeat("a", maxTagLength+1)}, nil, validation.CodeTooLarge},
+		{"too many tags", tooMany, nil, validation.CodeTooLarge},
+	}
+	for _, tt := range tests {
+		errs := &validation.Errors{}
+		tags := tt.tags
+		checkTags(errs, &tags)
+		if tt.wantCode == "" {
+			if len(*errs) > 0 {
+				t.Errorf("%s: unexpected errors %v", tt.name, errs)
+			}
+			if !reflect.DeepEqual(tags, tt.expect) {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.expect, tags)
+			}
+			continue
+		}
+		if len(*errs) != 1 || (*errs)[0].Field != "tags" || (*errs)[0].Code != tt.wantCode {
+			t.Errorf("%s: expected a %s error for tags, got %v", tt.name, tt.wantCode, errs)
+		}
+	}
+}
+
+func TestSplitTags(t *testing.T) {
+	if tags := splitTags(""); tags != nil {
+		t.Errorf("expected no tags, got %v", tags)
+	}
+	if tags := splitTags("Quiz,,english friendly,quiz"); !reflect.DeepEqual(tags, []string{"quiz", "english-friendly"}) {
+		t.Errorf("unexpected tags %v", tags)
+	}
+}
+
+func TestReplaceTags(t *testing.T) {
+	current := []db.TagModel{{InnerTag: db.InnerTag{ID: "tag-1"}}, {InnerTag: db.InnerTag{ID: "tag-2"}}}
+
+	if params := replaceTags(current, []string{"tag-2", "tag-1"}); len(params) != 0 {
+		t.Errorf("expected no changes for the same tags, got %d params", len(params))
+	}
+	if params := replaceTags(current, []string{"tag-2", "tag-3"}); len(params) != 2 {
+		t.Errorf("expected a link and an unlink, got %d params", len(params))
+	}
+	if params := replaceTags(current, nil); len(params) != 1 {
+		t.Errorf("expected an unlink, got %d params", len(params))
+	}
+	if params := replaceTags(nil, []string{"tag-1"}); len(params) != 1 {
+		t.Errorf("expected a link, got %d params", len(params))
+	}
+}
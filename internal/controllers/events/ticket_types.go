@@ -0,0 +1,484 @@
+package events
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+	"github.com/shopspring/decimal"
+)
+
+// TicketTypeItem is a ticket type together with its current availability
+type TicketTypeItem struct {
+	db.TicketTypeModel
+	Available int  `json:"available"`
+	OnSale    bool `json:"onSale"`
+}
+
+// EventDetail is a single event together with its remaining capacity and the
+// availability of its ticket types, with its start and end also in its timezone
+type EventDetail struct {
+	db.EventModel
+	LocalTimes
+	Available   int              `json:"available"`
+	TicketTypes []TicketTypeItem `json:"ticketTypes"`
+}
+
+// remaining returns the seats left once sold and held seats are taken out
+func remaining(total, sold, held int) int {
+	if available := total - sold - held; available > 0 {
+		return available
+	}
+	return 0
+}
+
+// newTicketTypeItem computes the availability of a ticket type at now. Seats
+// reserved by active holds are not available.
+func newTicketTypeItem(ticketType db.TicketTypeModel, now time.Time) TicketTypeItem {
+	available := remaining(ticketType.Quota, ticketType.Sold, ticketType.Held)
+
+	onSale := available > 0
+	if startsAt, ok := ticketType.SaleStartsAt(); ok && now.Before(startsAt) {
+		onSale = false
+	}
+	if endsAt, ok := ticketType.SaleEndsAt(); ok && !now.Before(endsAt) {
+		onSale = false
+	}
+
+	return TicketTypeItem{TicketTypeModel: ticketType, Available: available, OnSale: onSale}
+}
+
+// newTicketTypeItems returns the ticket types ordered by price and name with their availability
+func newTicketTypeItems(ticketTypes []db.TicketTypeModel, now time.Time) []TicketTypeItem {
+	items := []TicketTypeItem{}
+	for _, ticketType := range ticketTypes {
+		items = append(items, newTicketTypeItem(ticketType, now))
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if cmp := items[i].Price.Cmp(items[j].Price); cmp != 0 {
+			return cmp < 0
+		}
+		return items[i].Name < items[j].Name
+	})
+	return items
+}
+
+// newEventDetail builds the detail view of an event loaded with its ticket types
+func newEventDetail(event db.EventModel, now time.Time) EventDetail {
+	return EventDetail{
+		EventModel:  event,
+		LocalTimes:  localTimes(event.StartsAt, event.EndsAt, event.Timezone),
+		Available:   remaining(event.Capacity, event.SeatsSold, event.SeatsHeld),
+		TicketTypes: newTicketTypeItems(event.RelationsEvent.TicketTypes, now),
+	}
+}
+
+// validateTicketType checks the values a ticket type would have after a create
+// or update. taken is the number of tickets sold or held.
+func validateTicketType(price decimal.Decimal, quota, taken int, saleStartsAt, saleEndsAt *time.Time) validation.Errors {
+	var errs validation.Errors
+	if price.IsNegative() {
+		errs.Add("price", validation.CodeTooSmall, "must not be negative")
+	}
+	if quota < 1 {
+		errs.Add("quota", validation.CodeTooSmall, "must be at least 1")
+	} else if quota < taken {
+		errs.Add("quota", validation.CodeTooSmall, "must not be lower than the number of tickets already sold or held")
+	}
+	if saleStartsAt != nil && saleEndsAt != nil && !saleEndsAt.After(*saleStartsAt) {
+		errs.Add("saleEndsAt", validation.CodeBeforeStart, "must be after saleStartsAt")
+	}
+	return errs
+}
+
+// quotaFits reports whether the quotas of all ticket types, with the ticket
+// type identified by ticketTypeID (if any) set to quota, fit the event's capacity
+func quotaFits(capacity int, ticketTypes []db.TicketTypeModel, ticketTypeID string, quota int) bool {
+	total := quota
+	for _, ticketType := range ticketTypes {
+		if ticketType.ID != ticketTypeID {
+			total += ticketType.Quota
+		}
+	}
+	return total <= capacity
+}
+
+// CreateTicketTypeRequest represents the JSON payload for creating a ticket type
+// @Description  Ticket type creation payload
+type CreateTicketTypeRequest struct {
+	Name         string           `json:"name" binding:"required"`
+	Description  *string          `json:"description" binding:"omitempty,min=1"`
+	Price        *decimal.Decimal `json:"price" binding:"required"`
+	Quota        int              `json:"quota" binding:"required,min=1"`
+	SaleStartsAt *time.Time       `json:"saleStartsAt"`
+	SaleEndsAt   *time.Time       `json:"saleEndsAt"`
+}
+
+// PatchTicketTypeRequest represents the JSON payload for partially updating a ticket type.
+// Omitted fields are left unchanged.
+// @Description  Ticket type partial update payload
+type PatchTicketTypeRequest struct {
+	Name         *string          `json:"name" binding:"omitempty,min=1"`
+	Description  *string          `json:"description" binding:"omitempty,min=1"`
+	Price        *decimal.Decimal `json:"price"`
+	Quota        *int             `json:"quota" binding:"omitempty,min=1"`
+	SaleStartsAt *time.Time       `json:"saleStartsAt"`
+	SaleEndsAt   *time.Time       `json:"saleEndsAt"`
+}
+
+// setParams converts the provided fields into Prisma update parameters
+func (req *PatchTicketTypeRequest) setParams() []db.TicketTypeSetParam {
+	var params []db.TicketTypeSetParam
+	if req.Name != nil {
+		params = append(params, db.TicketType.Name.Set(*req.Name))
+	}
+	if req.Description != nil {
+		params = append(params, db.TicketType.Description.Set(*req.Description))
+	}
+	if req.Price != nil {
+		params = append(params, db.TicketType.Price.Set(*req.Price))
+	}
+	if req.Quota != nil {
+		params = append(params, db.TicketType.Quota.Set(*req.Quota))
+	}
+	if req.SaleStartsAt != nil {
+		params = append(params, db.TicketType.SaleStartsAt.Set(*req.SaleStartsAt))
+	}
+	if req.SaleEndsAt != nil {
+		params = append(params, db.TicketType.SaleEndsAt.Set(*req.SaleEndsAt))
+	}
+	return params
+}
+
+// findVisibleEvent loads the event with its organizer, category, tags and ticket types. If the
+// event does not exist in the current tenant or the caller may not see it, 404
+// is written and false is returned.
+func (ec *Controller) findVisibleEvent(c *gin.Context, eventID string) (*db.EventModel, bool) {
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+
+	event, err := ec.findEvent(c.Request.Context(), eventID)
+	if err != nil || event.TenantID != tenantID || !canViewEvent(c, event) {
+		problem.Respond(c, http.StatusNotFound, "event_not_found", "Event not found", "")
+		return nil, false
+	}
+	return event, true
+}
+
+// authorizeTicketTypeWrite checks that the caller may change the ticket types
+// of the event, and its If-Match like for writes to the event itself, and
+// returns the event's current ticket types. On failure the response is
+// already written and false is returned.
+func (ec *Controller) authorizeTicketTypeWrite(c *gin.Context, eventID string) (*db.EventModel, []db.TicketTypeModel, bool) {
+	event, ok := ec.authorizeEventWrite(c, eventID)
+	if !ok || !ec.checkIfMatch(c, event) {
+		return nil, nil, false
+	}
+	if !isEditable(event.Status) {
+		problem.Respond(c, http.StatusConflict, "event_not_editable", "Event can no longer be changed",
+			"event is "+string(event.Status))
+		return nil, nil, false
+	}
+
+	ticketTypes, err := ec.dbService.GetClient().TicketType.FindMany(
+		db.TicketType.EventID.Equals(eventID),
+	).Exec(c.Request.Context())
+	if err != nil {
+		problem.Internal(c, "Failed to fetch ticket types", err)
+		return nil, nil, false
+	}
+	return event, ticketTypes, true
+}
+
+// writeTicketType runs a write to a ticket type together with moving its
+// event to the next version, and responds with the ticket type and the new
+// ETag of the event. Without a ticket type ID, e.g. after deleting one, only
+// the status is written. On failure the response is already written and
+// false is returned.
+func (ec *Controller) writeTicketType(c *gin.Context, event *db.EventModel, write db.PrismaTransaction, ticketTypeID string, status int, message string) bool {
+	ctx := c.Request.Context()
+	client := ec.dbService.GetClient()
+
+	if err := client.Prisma.Transaction(
+		write,
+		ec.updateEventTx(c, event, db.Event.UpdatedAt.Set(time.Now())),
+	).Exec(ctx); err != nil {
+		if _, isConflict := db.IsErrUniqueConstraint(err); isConflict {
+			problem.Respond(c, http.StatusConflict, "ticket_type_exists", "Ticket type already exists",
+				"the event already has a ticket type with this name")
+			return false
+		}
+		if isQuotaBelowTaken(err) {
+			problem.Respond(c, http.StatusConflict, "quota_below_taken_seats", "Quota is below the tickets already taken",
+				"quota must cover the sold and held tickets")
+			return false
+		}
+		ec.failedUpdate(c, event, message, err)
+		return false
+	}
+	ec.announceChange(event.TenantID, event.ID)
+
+	updated, err := client.Event.FindUnique(
+		db.Event.ID.Equals(event.ID),
+	).With(
+		db.Event.TicketTypes.Fetch(db.TicketType.ID.Equals(ticketTypeID)),
+	).Exec(ctx)
+	if err != nil {
+		problem.Internal(c, "Failed to fetch ticket type", err)
+		return false
+	}
+	c.Header("ETag", eventETag(updated))
+	if ticketTypeID == "" {
+		c.Status(status)
+		return true
+	}
+	ticketType := findTicketType(updated.RelationsEvent.TicketTypes, ticketTypeID)
+	if ticketType == nil {
+		problem.Internal(c, "Failed to fetch ticket type", errors.New("ticket type "+ticketTypeID+" not found after writing it"))
+		return false
+	}
+	c.JSON(status, newTicketTypeItem(*ticketType, time.Now()))
+	return true
+}
+
+// isQuotaBelowTaken reports whether err is a quota update rejected by the
+// TicketType_seats_check constraint, because tickets were sold or held after
+// the quota was checked
+func isQuotaBelowTaken(err error) bool {
+	return strings.Contains(err.Error(), "TicketType_seats_check")
+}
+
+// findTicketType returns the ticket type with the given ID, or nil if the event has none
+func findTicketType(ticketTypes []db.TicketTypeModel, ticketTypeID string) *db.TicketTypeModel {
+	for i := range ticketTypes {
+		if ticketTypes[i].ID == ticketTypeID {
+			return &ticketTypes[i]
+		}
+	}
+	return nil
+}
+
+// ListTicketTypes godoc
+// @Summary      List ticket types
+// @Description  Returns the ticket types of an event with their remaining availability, ordered by price
+// @Tags         ticket-types
+// @Produce      json
+// @Param        id   path      string  true  "Event ID"
+// @Success      200  {array}   TicketTypeItem
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /events/{id}/ticket-types [get]
+func (ec *Controller) ListTicketTypes(c *gin.Context) {
+	event, ok := ec.findVisibleEvent(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, newTicketTypeItems(event.RelationsEvent.TicketTypes, time.Now()))
+}
+
+// GetTicketType godoc
+// @Summary      Get a ticket type
+// @Description  Returns a single ticket type of an event with its remaining availability
+// @Tags         ticket-types
+// @Produce      json
+// @Param        id            path      string  true  "Event ID"
+// @Param        ticketTypeId  path      string  true  "Ticket type ID"
+// @Success      200  {object}  TicketTypeItem
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /events/{id}/ticket-types/{ticketTypeId} [get]
+func (ec *Controller) GetTicketType(c *gin.Context) {
+	event, ok := ec.findVisibleEvent(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	ticketType := findTicketType(event.RelationsEvent.TicketTypes, c.Param("ticketTypeId"))
+	if ticketType == nil {
+		problem.Respond(c, http.StatusNotFound, "ticket_type_not_found", "Ticket type not found", "")
+		return
+	}
+
+	c.JSON(http.StatusOK, newTicketTypeItem(*ticketType, time.Now()))
+}
+
+// CreateTicketType godoc
+// @Summary      Create a ticket type
+// @Description  Adds a ticket type to an event. The quotas of all ticket types must fit the event's capacity.
+// @Description  Only the owning organiser or an admin may add ticket types.
+// @Tags         ticket-types
+// @Accept       json
+// @Produce      json
+// @Param        id          path      string                   true  "Event ID"
+// @Param        ticketType  body      CreateTicketTypeRequest  true  "Ticket type to create"
+// @Param        If-Match  header  string  false  "ETag of the version the change is based on; required for dashboard clients"
+// @Success      201  {object}  TicketTypeItem
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      422  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /events/{id}/ticket-types [post]
+func (ec *Controller) CreateTicketType(c *gin.Context) {
+	eventID := c.Param("id")
+
+	var req CreateTicketTypeRequest
+	if !decodeJSON(c, &req) {
+		return
+	}
+	errs := validation.Struct(&req)
+	if len(errs) == 0 {
+		errs = validateTicketType(*req.Price, req.Quota, 0, req.SaleStartsAt, req.SaleEndsAt)
+	}
+	if len(errs) > 0 {
+		problem.Validation(c, errs)
+		return
+	}
+
+	event, ticketTypes, ok := ec.authorizeTicketTypeWrite(c, eventID)
+	if !ok {
+		return
+	}
+	if !quotaFits(event.Capacity, ticketTypes, "", req.Quota) {
+		problem.Respond(c, http.StatusConflict, "ticket_quota_exceeds_capacity", "Ticket quotas exceed event capacity",
+			"the quotas of all ticket types must not exceed the event's capacity")
+		return
+	}
+
+	ticketTypeID := uuid.NewString()
+	create := ec.dbService.GetClient().TicketType.CreateOne(
+		db.TicketType.Name.Set(req.Name),
+		db.TicketType.Price.Set(*req.Price),
+		db.TicketType.Quota.Set(req.Quota),
+		db.TicketType.Event.Link(db.Event.ID.Equals(eventID)),
+		db.TicketType.ID.Set(ticketTypeID),
+		db.TicketType.Description.SetIfPresent(req.Description),
+		db.TicketType.SaleStartsAt.SetIfPresent(req.SaleStartsAt),
+		db.TicketType.SaleEndsAt.SetIfPresent(req.SaleEndsAt),
+	).Tx()
+	ec.writeTicketType(c, event, create, ticketTypeID, http.StatusCreated, "Failed to create ticket type")
+}
+
+// PatchTicketType godoc
+// @Summary      Update a ticket type
+// @Description  Updates only the provided fields of a ticket type. The quota cannot drop below the tickets already sold or held.
+// @Description  Only the owning organiser or an admin may update ticket types.
+// @Tags         ticket-types
+// @Accept       json
+// @Produce      json
+// @Param        id            path      string                  true  "Event ID"
+// @Param        ticketTypeId  path      string                  true  "Ticket type ID"
+// @Param        ticketType    body      PatchTicketTypeRequest  true  "Fields to update"
+// @Param        If-Match  header  string  false  "ETag of the version the change is based on; required for dashboard clients"
+// @Success      200  {object}  TicketTypeItem
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      422  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /events/{id}/ticket-types/{ticketTypeId} [patch]
+func (ec *Controller) PatchTicketType(c *gin.Context) {
+	eventID := c.Param("id")
+	ticketTypeID := c.Param("ticketTypeId")
+
+	var req PatchTicketTypeRequest
+	if !decodeJSON(c, &req) {
+		return
+	}
+	if errs := validation.Struct(&req); len(errs) > 0 {
+		problem.Validation(c, errs)
+		return
+	}
+
+	event, ticketTypes, ok := ec.authorizeTicketTypeWrite(c, eventID)
+	if !ok {
+		return
+	}
+	current := findTicketType(ticketTypes, ticketTypeID)
+	if current == nil {
+		problem.Respond(c, http.StatusNotFound, "ticket_type_not_found", "Ticket type not found", "")
+		return
+	}
+
+	price, quota := current.Price, current.Quota
+	saleStartsAt, saleEndsAt := current.InnerTicketType.SaleStartsAt, current.InnerTicketType.SaleEndsAt
+	if req.Price != nil {
+		price = *req.Price
+	}
+	if req.Quota != nil {
+		quota = *req.Quota
+	}
+	if req.SaleStartsAt != nil {
+		saleStartsAt = req.SaleStartsAt
+	}
+	if req.SaleEndsAt != nil {
+		saleEndsAt = req.SaleEndsAt
+	}
+	if errs := validateTicketType(price, quota, current.Sold+current.Held, saleStartsAt, saleEndsAt); len(errs) > 0 {
+		problem.Validation(c, errs)
+		return
+	}
+	if !quotaFits(event.Capacity, ticketTypes, ticketTypeID, quota) {
+		problem.Respond(c, http.StatusConflict, "ticket_quota_exceeds_capacity", "Ticket quotas exceed event capacity",
+			"the quotas of all ticket types must not exceed the event's capacity")
+		return
+	}
+
+	update := ec.dbService.GetClient().TicketType.FindUnique(
+		db.TicketType.ID.Equals(ticketTypeID),
+	).Update(req.setParams()...).Tx()
+	ec.writeTicketType(c, event, update, ticketTypeID, http.StatusOK, "Failed to update ticket type")
+}
+
+// DeleteTicketType godoc
+// @Summary      Delete a ticket type
+// @Description  Removes a ticket type that has no sold or held tickets.
+// @Description  Only the owning organiser or an admin may delete ticket types.
+// @Tags         ticket-types
+// @Param        id            path  string  true  "Event ID"
+// @Param        ticketTypeId  path  string  true  "Ticket type ID"
+// @Param        If-Match  header  string  false  "ETag of the version the change is based on; required for dashboard clients"
+// @Success      204
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /events/{id}/ticket-types/{ticketTypeId} [delete]
+func (ec *Controller) DeleteTicketType(c *gin.Context) {
+	eventID := c.Param("id")
+	ticketTypeID := c.Param("ticketTypeId")
+
+	event, ticketTypes, ok := ec.authorizeTicketTypeWrite(c, eventID)
+	if !ok {
+		return
+	}
+	current := findTicketType(ticketTypes, ticketTypeID)
+	if current == nil {
+		problem.Respond(c, http.StatusNotFound, "ticket_type_not_found", "Ticket type not found", "")
+		return
+	}
+	if current.Sold > 0 || current.Held > 0 {
+		problem.Respond(c, http.StatusConflict, "ticket_type_has_sales", "Ticket type has sold tickets",
+			"ticket types with sold or held tickets cannot be deleted; set the sale end instead")
+		return
+	}
+
+	remove := ec.dbService.GetClient().TicketType.FindUnique(
+		db.TicketType.ID.Equals(ticketTypeID),
+	).Delete().Tx()
+	ec.writeTicketType(c, event, remove, "", http.StatusNoContent, "Failed to delete ticket type")
+}
@@ -0,0 +1,182 @@
+package events
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+	"github.com/shopspring/decimal"
+)
+
+func newTicketType(id, name string, price int64, quota, sold int) db.TicketTypeModel {
+	var ticketType db.TicketTypeModel
+	ticketType.InnerTicketType.ID = id
+	ticketType.InnerTicketType.Name = name
+	ticketType.InnerTicketType.Price = decimal.NewFromInt(price)
+	ticketType.InnerTicketType.Quota = quota
+	ticketType.InnerTicketType.Sold = sold
+	return ticketType
+}
+
+func TestNewTicketTypeItem_Availability(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+
+	open := newTicketType("a", "Member", 100, 50, 20)
+	held := newTicketType("e", "Student", 50, 10, 4)
+	held.InnerTicketType.Held = 6
+	soldOut := newTicketType("b", "VIP", 500, 10, 10)
+	notYet := newTicketType("c", "Early", 80, 10, 0)
+	notYet.InnerTicketType.SaleStartsAt = &after
+	ended := newTicketType("d", "Late", 120, 10, 0)
+	ended.InnerTicketType.SaleEndsAt = &before
+
+	tests := []struct {
+		ticketType db.TicketTypeModel
+		available  int
+		onSale     bool
+	}{
+		{open, 30, true},
+		{held, 0, false},
+		{soldOut, 0, false},
+		{notYet, 10, false},
+		{ended, 10, false},
+	}
+
+	for _, tt := range tests {
+		item := newTicketTypeItem(tt.ticketType, now)
+		if item.Available != tt.available || item.OnSale != tt.onSale {
+			t.Errorf("%s: expected available=%d onSale=%v, got available=%d onSale=%v",
+				tt.ticketType.Name, tt.available, tt.onSale, item.Available, item.OnSale)
+		}
+	}
+}
+
+func TestNewTicketTypeItems_OrderedByPrice(t *testing.T) {
+	items := newTicketTypeItems([]db.TicketTypeModel{
+		newTicketType("vip", "VIP", 500, 10, 0),
+		newTicketType("non", "Non-member", 200, 100, 0),
+		newTicketType("mem", "Member", 100, 100, 0),
+	}, time.Now())
+
+	if len(items) != 3 || items[0].ID != "mem" || items[1].ID != "non" || items[2].ID != "vip" {
+		t.Errorf("expected ticket types ordered by price, got %+v", items)
+	}
+}
+
+func TestValidateTicketType(t *testing.T) {
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	if err := validateTicketType(decimal.NewFromInt(100), 10, 0, &start, &end); err != nil {
+		t.Errorf("expected valid ticket type, got %v", err)
+	}
+	if err := validateTicketType(decimal.Zero, 10, 0, nil, nil); err != nil {
+		t.Errorf("expected free ticket type to be valid, got %v", err)
+	}
+	if err := validateTicketType(decimal.NewFromInt(-1), 10, 0, nil, nil); err == nil {
+		t.Error("expected an error for a negative price")
+	}
+	if err := validateTicketType(decimal.NewFromInt(100), 5, 6, nil, nil); err == nil {
+		t.Error("expected an error for a quota below the sold and held tickets")
+	}
+	if err := validateTicketType(decimal.NewFromInt(100), 10, 0, &end, &start); err == nil {
+		t.Error("expected an error for a sale window ending before it starts")
+	}
+}
+
+func TestIsQuotaBelowTaken(t *testing.T) {
+	if !isQuotaBelowTaken(errors.New(`new row for relation "TicketType" violates check constraint "TicketType_seats_check"`)) {
+		t.Error("expected the seats check of the ticket type to be recognised")
+	}
+	if isQuotaBelowTaken(errors.New("connection refused")) {
+		t.Error("expected other errors not to be recognised")
+	}
+}
+
+func TestQuotaFits(t *testing.T) {
+	ticketTypes := []db.TicketTypeModel{
+		newTicketType("a", "Member", 100, 60, 0),
+		newTicketType("b", "VIP", 500, 30, 0),
+	}
+
+	if !quotaFits(100, ticketTypes, "", 10) {
+		t.Error("expected a new quota of 10 to fit a capacity of 100")
+	}
+	if quotaFits(100, ticketTypes, "", 11) {
+		t.Error("expected a new quota of 11 not to fit a capacity of 100")
+	}
+	if !quotaFits(100, ticketTypes, "b", 40) {
+		t.Error("expected the updated ticket type's old quota to be ignored")
+	}
+}
+
+func TestCreateTicketType_InvalidPayload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ec := &Controller{}
+	r := gin.New()
+	r.POST("/events/:id/ticket-types", ec.CreateTicketType)
+
+	tests := []struct {
+		body       string
+		wantStatus int
+	}{
+		{`{invalid json`, http.StatusBadRequest},
+		{`{"name":"VIP","quota":10}`, http.StatusUnprocessableEntity},
+		{`{"name":"VIP","price":100,"quota":"ten"}`, http.StatusUnprocessableEntity},
+		{`{"name":"VIP","price":-5,"quota":10}`, http.StatusUnprocessableEntity},
+		{`{"name":"VIP","price":100,"quota":0}`, http.StatusUnprocessableEntity},
+		{`{"name":"VIP","price":100,"quota":10,"saleStartsAt":"2026-05-02T00:00:00Z","saleEndsAt":"2026-05-01T00:00:00Z"}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/events/abc/ticket-types", bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: expected status %d, got %d. body=%s", tt.body, tt.wantStatus, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestPatchTicketTypeRequest_SetParams(t *testing.T) {
+	name := "Student"
+	quota := 40
+	req := PatchTicketTypeRequest{Name: &name, Quota: &quota}
+
+	if params := req.setParams(); len(params) != 2 {
+		t.Errorf("expected 2 params, got %d", len(params))
+	}
+}
+
+func TestNewEventDetail_Available(t *testing.T) {
+	var event db.EventModel
+	event.InnerEvent.Capacity = 100
+	event.InnerEvent.SeatsSold = 60
+	event.InnerEvent.SeatsHeld = 15
+
+	if detail := newEventDetail(event, time.Now()); detail.Available != 25 || len(detail.TicketTypes) != 0 {
+		t.Errorf("expected 25 available seats and no ticket types, got %d and %v", detail.Available, detail.TicketTypes)
+	}
+}
+
+func TestCapacityCovers(t *testing.T) {
+	var event db.EventModel
+	event.InnerEvent.SeatsSold = 60
+	event.InnerEvent.SeatsHeld = 15
+
+	if !capacityCovers(&event, 75) {
+		t.Error("expected a capacity of 75 to cover 75 taken seats")
+	}
+	if capacityCovers(&event, 74) {
+		t.Error("expected a capacity of 74 not to cover 75 taken seats")
+	}
+}
@@ -0,0 +1,203 @@
+package events
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+	"github.com/shopspring/decimal"
+)
+
+// eventFields points at the fields of an event payload the domain rules
+// check. Nil fields were not sent and are skipped.
+type eventFields struct {
+	Name        *string
+	Description *string
+	Location    *string
+	Price       *decimal.Decimal
+	Capacity    *int
+	// Tags are normalized in place
+	Tags *[]string
+}
+
+// checkEvent applies the rules the binding tags cannot express. Fields that
+// already failed their binding tags are not checked again.
+func (ec *Controller) checkEvent(errs *validation.Errors, f eventFields) {
+	texts := []struct {
+		field string
+		value *string
+	}{{"name", f.Name}, {"description", f.Description}, {"location", f.Location}}
+	for _, text := range texts {
+		if text.value != nil && strings.TrimSpace(*text.value) == "" && !errs.Has(text.field) {
+			errs.Add(text.field, validation.CodeRequired, "must not be blank")
+		}
+	}
+	if f.Price != nil && f.Price.IsNegative() {
+		errs.Add("price", validation.CodeTooSmall, "must not be negative")
+	}
+	if f.Capacity != nil && *f.Capacity < 1 {
+		errs.Add("capacity", validation.CodeTooSmall, "must be at least 1")
+	}
+	checkTags(errs, f.Tags)
+}
+
+// newSchedule returns the schedule new events start from
+func (ec *Controller) newSchedule() schedule {
+	if ec.defaultTimezone == "" {
+		return schedule{Timezone: DefaultTimezone}
+	}
+	return schedule{Timezone: ec.defaultTimezone}
+}
+
+// validateCreateEvent checks a creation payload against the rules and the
+// categories of the tenant. CategoryID is set to the resolved category and
+// the schedule fields to the resolved schedule.
+func (ec *Controller) validateCreateEvent(req *CreateEventRequest, categories categoryIndex) validation.Errors {
+	errs := validation.Struct(req)
+	ec.checkEvent(&errs, eventFields{
+		Name:        &req.Name,
+		Description: &req.Description,
+		Location:    &req.Location,
+		Price:       &req.Price,
+		Capacity:    &req.Capacity,
+		Tags:        &req.Tags,
+	})
+	req.setSchedule(resolveSchedule(&errs, req.scheduleFields(), ec.newSchedule()))
+	req.CategoryID = resolveCategory(&errs, categories, req.CategoryID, req.Category)
+	return errs
+}
+
+// validateUpdateEvent checks a replacement payload like validateCreateEvent
+func (ec *Controller) validateUpdateEvent(req *UpdateEventRequest, categories categoryIndex) validation.Errors {
+	errs := validation.Struct(req)
+	ec.checkEvent(&errs, eventFields{
+		Name:        &req.Name,
+		Description: &req.Description,
+		Location:    &req.Location,
+		Price:       &req.Price,
+		Capacity:    &req.Capacity,
+		Tags:        &req.Tags,
+	})
+	req.setSchedule(resolveSchedule(&errs, req.scheduleFields(), ec.newSchedule()))
+	req.CategoryID = resolveCategory(&errs, categories, req.CategoryID, req.Category)
+	return errs
+}
+
+func (req *CreateEventRequest) scheduleFields() scheduleFields {
+	return scheduleFields{
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		Timezone:  &req.Timezone,
+		AllDay:    &req.AllDay,
+		StartDate: req.StartDate,
+		StartTime: req.StartTime,
+		EndDate:   req.EndDate,
+	}
+}
+
+func (req *CreateEventRequest) setSchedule(s schedule) {
+	req.StartsAt, req.EndsAt, req.Timezone, req.AllDay = &s.StartsAt, &s.EndsAt, s.Timezone, s.AllDay
+}
+
+// scheduled returns the schedule of a validated payload
+func (req *CreateEventRequest) scheduled() schedule {
+	return schedule{StartsAt: *req.StartsAt, EndsAt: *req.EndsAt, Timezone: req.Timezone, AllDay: req.AllDay}
+}
+
+func (req *UpdateEventRequest) scheduleFields() scheduleFields {
+	return scheduleFields{
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		Timezone:  &req.Timezone,
+		AllDay:    &req.AllDay,
+		StartDate: req.StartDate,
+		StartTime: req.StartTime,
+		EndDate:   req.EndDate,
+	}
+}
+
+func (req *UpdateEventRequest) setSchedule(s schedule) {
+	req.StartsAt, req.EndsAt, req.Timezone, req.AllDay = &s.StartsAt, &s.EndsAt, s.Timezone, s.AllDay
+}
+
+// scheduled returns the schedule of a validated payload
+func (req *UpdateEventRequest) scheduled() schedule {
+	return schedule{StartsAt: *req.StartsAt, EndsAt: *req.EndsAt, Timezone: req.Timezone, AllDay: req.AllDay}
+}
+
+// validatePatchEvent checks the provided fields of a partial update. The
+// schedule is checked against the stored event by patchedSchedule.
+// categories is only needed when the patch changes the category.
+func (ec *Controller) validatePatchEvent(req *PatchEventRequest, categories categoryIndex) validation.Errors {
+	errs := validation.Struct(req)
+	ec.checkEvent(&errs, eventFields{
+		Name:        req.Name,
+		Description: req.Description,
+		Location:    req.Location,
+		Price:       req.Price,
+		Capacity:    req.Capacity,
+		Tags:        req.Tags,
+	})
+	if req.changesCategory() {
+		id := resolveCategory(&errs, categories, deref(req.CategoryID), deref(req.Category))
+		req.CategoryID = &id
+	}
+	return errs
+}
+
+// changesCategory reports whether the patch files the event under another category
+func (req *PatchEventRequest) changesCategory() bool {
+	return req.CategoryID != nil || req.Category != nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func (req *PatchEventRequest) scheduleFields() scheduleFields {
+	return scheduleFields{
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		Timezone:  req.Timezone,
+		AllDay:    req.AllDay,
+		StartDate: req.StartDate,
+		StartTime: req.StartTime,
+		EndDate:   req.EndDate,
+	}
+}
+
+// patchedSchedule returns the schedule the event will have after the patch
+func (req *PatchEventRequest) patchedSchedule(current *db.EventModel) (schedule, validation.Errors) {
+	var errs validation.Errors
+	if !req.scheduleFields().sent() {
+		return eventSchedule(current), errs
+	}
+	return resolveSchedule(&errs, req.scheduleFields(), eventSchedule(current)), errs
+}
+
+// decodeJSON decodes the request body into obj without running the binding
+// tags, so their failures can be reported together with the domain rules.
+// Values of the wrong JSON type are reported as field errors. On failure the
+// response is already written and false is returned.
+func decodeJSON(c *gin.Context, obj any) bool {
+	if c.Request.Body == nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload", "request body is empty")
+		return false
+	}
+	if err := json.NewDecoder(c.Request.Body).Decode(obj); err != nil {
+		if errs, ok := validation.FromError(err, obj); ok {
+			problem.Validation(c, errs)
+			return false
+		}
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload", err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package events
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+func validCreateEventBody(overrides string) string {
+	body := `{
+		"name":"Pub quiz",
+		"description":"Quiz night",
+		"startsAt":"2026-01-01T18:00:00Z",
+		"endsAt":"2026-01-01T21:00:00Z",
+		"timezone":"Europe/Stockholm",
+		"price":"0",
+		"location":"Luleå",
+		"capacity":10,
+		"category":"Party"`
+	if overrides != "" {
+		body += "," + overrides
+	}
+	return body + "}"
+}
+
+func TestCreateEvent_DomainRules_Returns422(t *testing.T) {
+	ec := &Controller{categoryStore: testCategories}
+	r := setupRouterForCreate(ec)
+
+	tests := []struct {
+		name      string
+		body      string
+		wantField string
+		wantCode  string
+	}{
+		{"end before start", validCreateEventBody(`"endsAt":"2026-01-01T17:00:00Z"`), "endsAt", validation.CodeBeforeStart},
+		{"unknown timezone", validCreateEventBody(`"timezone":"Europe/Luleå"`), "timezone", validation.CodeInvalid},
+		{"zero capacity", validCreateEventBody(`"capacity":0`), "capacity", validation.CodeTooSmall},
+		{"negative capacity", validCreateEventBody(`"capacity":-5`), "capacity", validation.CodeTooSmall},
+		{"negative price", validCreateEventBody(`"price":"-1.50"`), "price", validation.CodeTooSmall},
+		{"unknown category", validCreateEventBody(`"category":"Parties"`), "category", validation.CodeUnknown},
+		{"unknown category ID", validCreateEventBody(`"categoryId":"cat-parties"`), "categoryId", validation.CodeUnknown},
+		{"blank name", validCreateEventBody(`"name":"   "`), "name", validation.CodeRequired},
+		{"wrong type", validCreateEventBody(`"capacity":"ten"`), "capacity", validation.CodeInvalidType},
+		{"latitude out of range", validCreateEventBody(`"latitude":91,"longitude":0`), "latitude", validation.CodeTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// JSON objects keep the last value of duplicate keys, so the overrides win
+			req := httptest.NewRequest("POST", "/events", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("expected status 422, got %d. body=%s", w.Code, w.Body.String())
+			}
+			var resp struct {
+				Errors validation.Errors `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Errors) != 1 || resp.Errors[0].Field != tt.wantField || resp.Errors[0].Code != tt.wantCode {
+				t.Errorf("expected a single %s error on %s, got %+v", tt.wantCode, tt.wantField, resp.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateCreateEvent_ReportsAllErrors(t *testing.T) {
+	ec := &Controller{}
+	startsAt := time.Date(2026, 1, 2, 19, 0, 0, 0, time.UTC)
+	endsAt := time.Date(2026, 1, 1, 21, 0, 0, 0, time.UTC)
+	req := CreateEventRequest{
+		Name:     "Pub quiz",
+		StartsAt: &startsAt,
+		EndsAt:   &endsAt,
+	}
+
+	errs := ec.validateCreateEvent(&req, categoryIndex(testCategories))
+	for _, field := range []string{"description", "location", "capacity", "endsAt", "categoryId"} {
+		if !errs.Has(field) {
+			t.Errorf("expected an error on %s, got %+v", field, errs)
+		}
+	}
+}
+
+func TestValidateCreateEvent_ResolvesCategory(t *testing.T) {
+	ec := &Controller{}
+	startsAt := time.Date(2026, 1, 1, 19, 0, 0, 0, time.UTC)
+	endsAt := startsAt.Add(2 * time.Hour)
+	req := CreateEventRequest{
+		Name:        "Pub quiz",
+		Description: "Quiz night",
+		StartsAt:    &startsAt,
+		EndsAt:      &endsAt,
+		Location:    "Luleå",
+		Capacity:    10,
+		Category:    " party ",
+	}
+
+	categories := categoryIndex(testCategories[:2])
+	if errs := ec.validateCreateEvent(&req, categories); len(errs) > 0 {
+		t.Fatalf("expected no errors, got %+v", errs)
+	}
+	if req.CategoryID != "cat-party" {
+		t.Errorf("expected the category to be resolved, got %q", req.CategoryID)
+	}
+	req.CategoryID, req.Category = "", "Workshop"
+	if errs := ec.validateCreateEvent(&req, categories); !errs.Has("category") {
+		t.Error("expected categories of other tenants to be rejected")
+	}
+}
+
+func TestPatchEventRequest_PatchedSchedule(t *testing.T) {
+	var current db.EventModel
+	current.InnerEvent.StartsAt = time.Date(2026, 3, 10, 18, 0, 0, 0, time.UTC)
+	current.InnerEvent.EndsAt = time.Date(2026, 3, 12, 18, 0, 0, 0, time.UTC)
+	current.InnerEvent.Timezone = "Europe/Stockholm"
+
+	laterStart := time.Date(2026, 3, 13, 18, 0, 0, 0, time.UTC)
+	if _, errs := (&PatchEventRequest{StartsAt: &laterStart}).patchedSchedule(&current); !errs.Has("endsAt") {
+		t.Error("expected a start after the stored end to be rejected")
+	}
+	earlierStart := time.Date(2026, 3, 11, 18, 0, 0, 0, time.UTC)
+	when, errs := (&PatchEventRequest{StartsAt: &earlierStart}).patchedSchedule(&current)
+	if len(errs) > 0 {
+		t.Fatalf("expected no errors, got %+v", errs)
+	}
+	if !when.StartsAt.Equal(earlierStart) || !when.EndsAt.Equal(current.EndsAt) || when.Timezone != "Europe/Stockholm" {
+		t.Errorf("expected the stored end and timezone to be kept, got %+v", when)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
 	"github.com/oskargbc/dws-event-service.git/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -39,7 +40,8 @@ func (hc *Controller) Live(c *gin.Context) {
 }
 
 // Ready validates downstream dependencies (database, message brokers, etc) and
-// reports readiness status.
+// reports readiness status. Failed checks are logged; the response only names
+// the dependency, as the probe is unauthenticated.
 func (hc *Controller) Ready(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
 	defer cancel()
@@ -51,17 +53,7 @@ func (hc *Controller) Ready(c *gin.Context) {
 	dbStatus := "skipped"
 	if dbService := services.GetDatabaseSeviceInstance(); dbService != nil {
 		if err := dbService.HealthCheck(ctx); err != nil {
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"status":  "fail",
-				"service": hc.service.Slug,
-				"version": hc.service.Version,
-				"checks": gin.H{
-					"database": gin.H{
-						"status":  "fail",
-						"details": err.Error(),
-					},
-				},
-			})
+			problem.Unavailable(c, "database_unavailable", "Database unavailable", err)
 			return
 		}
 		dbStatus = "ok"
@@ -73,17 +65,7 @@ func (hc *Controller) Ready(c *gin.Context) {
 		rabbitmqStatus := "skipped"
 		if rabbitmqService := services.GetRabbitMQServiceInstance(); rabbitmqService != nil {
 			if err := rabbitmqService.HealthCheck(ctx); err != nil {
-				c.JSON(http.StatusServiceUnavailable, gin.H{
-					"status":  "fail",
-					"service": hc.service.Slug,
-					"version": hc.service.Version,
-					"checks": gin.H{
-						"rabbitmq": gin.H{
-							"status":  "fail",
-							"details": err.Error(),
-						},
-					},
-				})
+				problem.Unavailable(c, "rabbitmq_unavailable", "RabbitMQ unavailable", err)
 				return
 			}
 			rabbitmqStatus = "ok"
@@ -0,0 +1,389 @@
+package holds
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/eventchange"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/waitlist"
+	"github.com/oskargbc/dws-event-service.git/internal/services"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+const (
+	// DefaultTTL is used when neither the request nor the config sets a TTL
+	DefaultTTL = 10 * time.Minute
+	// MaxTTL is used when the config does not limit the TTL
+	MaxTTL = 30 * time.Minute
+)
+
+// createHoldSQL reserves seats and records the hold in a single statement.
+// The event must be published in the current tenant; events with ticket types
+// need a ticket type whose sale window is open. The counters are incremented
+// unconditionally: the Event_seats_check and TicketType_seats_check
+// constraints reject any increment that would oversell, which aborts the
+// whole statement. Concurrent
+// holds on the same event serialise on the event row, so this can never
+// oversell. No row is returned if the event or ticket type did not qualify.
+// Like every statement changing the seats, it touches the event's updatedAt,
+// which the Last-Modified of event reads is based on.
+const createHoldSQL = `
+WITH event AS (
+    UPDATE "public"."Event" e
+    SET "seatsHeld" = e."seatsHeld" + $3, "updatedAt" = $4
+    WHERE e."id" = $1
+      AND e."tenantId" = $8
+      AND e."status" = 'PUBLISHED'
+      AND (
+        ($2 = '' AND NOT EXISTS (SELECT 1 FROM "public"."TicketType" t WHERE t."eventId" = e."id"))
+        OR EXISTS (
+          SELECT 1 FROM "public"."TicketType" t
+          WHERE t."id" = $2 AND t."eventId" = e."id"
+            AND (t."saleStartsAt" IS NULL OR t."saleStartsAt" <= $4)
+            AND (t."saleEndsAt" IS NULL OR t."saleEndsAt" > $4)
+        )
+      )
+    RETURNING e."id"
+), tier AS (
+    UPDATE "public"."TicketType" t
+    SET "held" = t."held" + $3
+    FROM event
+    WHERE t."id" = $2 AND t."eventId" = event."id"
+)
+INSERT INTO "public"."CapacityHold" ("id", "eventId", "ticketTypeId", "quantity", "status", "holderId", "expiresAt", "createdAt", "updatedAt")
+SELECT $5, event."id", NULLIF($2, ''), $3, 'ACTIVE'::"public"."HoldStatus", $6, $7, $4, $4
+FROM event
+RETURNING *`
+
+// confirmHoldSQL turns the held seats of an active, unexpired hold into sold seats
+const confirmHoldSQL = `
+WITH hold AS (
+    UPDATE "public"."CapacityHold"
+    SET "status" = 'CONFIRMED', "updatedAt" = $2
+    WHERE "id" = $1 AND "status" = 'ACTIVE' AND "expiresAt" > $2
+    RETURNING *
+), event AS (
+    UPDATE "public"."Event" e
+    SET "seatsHeld" = e."seatsHeld" - hold."quantity",
+        "seatsSold" = e."seatsSold" + hold."quantity",
+        "updatedAt" = $2
+    FROM hold
+    WHERE e."id" = hold."eventId"
+), tier AS (
+    UPDATE "public"."TicketType" t
+    SET "held" = t."held" - hold."quantity",
+        "sold" = t."sold" + hold."quantity"
+    FROM hold
+    WHERE t."id" = hold."ticketTypeId"
+)
+SELECT * FROM hold`
+
+// releaseHoldSQL gives the seats of an active hold back. Expired holds that
+// the sweeper has not reached yet may still be released.
+const releaseHoldSQL = `
+WITH hold AS (
+    UPDATE "public"."CapacityHold"
+    SET "status" = 'RELEASED', "updatedAt" = $2
+    WHERE "id" = $1 AND "status" = 'ACTIVE'
+    RETURNING *
+), event AS (
+    UPDATE "public"."Event" e
+    SET "seatsHeld" = e."seatsHeld" - hold."quantity", "updatedAt" = $2
+    FROM hold
+    WHERE e."id" = hold."eventId"
+), tier AS (
+    UPDATE "public"."TicketType" t
+    SET "held" = t."held" - hold."quantity"
+    FROM hold
+    WHERE t."id" = hold."ticketTypeId"
+)
+SELECT * FROM hold`
+
+// Controller handles capacity hold requests
+type Controller struct {
+	dbService  *services.DatabaseService
+	promoter   *waitlist.Promoter
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+	// changes announces the changed availability of events
+	changes *eventchange.Publisher
+}
+
+// NewController creates a new holds controller
+func NewController() *Controller {
+	holds := configs.GetEnvConfig().Holds
+	return &Controller{
+		dbService:  services.GetDatabaseSeviceInstance(),
+		promoter:   waitlist.NewPromoter(),
+		defaultTTL: holds.DefaultTTL,
+		maxTTL:     holds.MaxTTL,
+		changes:    eventchange.Default(),
+	}
+}
+
+// CreateHoldRequest represents the JSON payload for reserving seats
+// @Description  Capacity hold creation payload
+type CreateHoldRequest struct {
+	Quantity int `json:"quantity" binding:"required,min=1"`
+	// TicketTypeID is required for events that have ticket types
+	TicketTypeID string `json:"ticketTypeId"`
+	// TTLSeconds defaults to the configured default TTL
+	TTLSeconds int `json:"ttlSeconds" binding:"omitempty,min=1"`
+}
+
+// ttl returns the TTL for a hold requesting the given number of seconds
+func (hc *Controller) ttl(seconds int) (time.Duration, error) {
+	defaultTTL, maxTTL := hc.defaultTTL, hc.maxTTL
+	if defaultTTL <= 0 {
+		defaultTTL = DefaultTTL
+	}
+	if maxTTL <= 0 {
+		maxTTL = MaxTTL
+	}
+
+	if seconds == 0 {
+		return defaultTTL, nil
+	}
+	ttl := time.Duration(seconds) * time.Second
+	if ttl > maxTTL {
+		return 0, errors.New("ttlSeconds must not exceed " + maxTTL.String())
+	}
+	return ttl, nil
+}
+
+// isSeatsExhausted reports whether err is a rejected increment of the seat counters
+func isSeatsExhausted(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "Event_seats_check") || strings.Contains(message, "TicketType_seats_check")
+}
+
+// canAccessHold reports whether the authenticated user may see and change the hold
+func canAccessHold(c *gin.Context, hold *db.CapacityHoldModel) bool {
+	if middlewares.HasRole(c, middlewares.RoleAdmin) {
+		return true
+	}
+	userID, ok := middlewares.GetUserIDFromContext(c)
+	return ok && userID != "" && userID == hold.HolderID
+}
+
+// CreateHold godoc
+// @Summary      Reserve seats
+// @Description  Holds seats of a published event while a checkout is in progress. The hold expires after its TTL
+// @Description  unless it is confirmed. Events with ticket types need a ticketTypeId whose sale window is open.
+// @Tags         holds
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string             true  "Event ID"
+// @Param        hold  body      CreateHoldRequest  true  "Seats to hold"
+// @Success      201   {object}  map[string]interface{}
+// @Failure      400   {object}  map[string]interface{}
+// @Failure      401   {object}  map[string]interface{}
+// @Failure      404   {object}  map[string]interface{}
+// @Failure      409   {object}  map[string]interface{}
+// @Failure      500   {object}  map[string]interface{}
+// @Router       /events/{id}/holds [post]
+func (hc *Controller) CreateHold(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID := c.Param("id")
+
+	var req CreateHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload", err.Error())
+		return
+	}
+	ttl, err := hc.ttl(req.TTLSeconds)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	userID, ok := middlewares.GetUserIDFromContext(c)
+	if !ok || userID == "" {
+		problem.Respond(c, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	now := time.Now().UTC()
+	var holds []db.CapacityHoldModel
+	if err := hc.dbService.GetClient().Prisma.QueryRaw(
+		createHoldSQL, eventID, req.TicketTypeID, req.Quantity, now, uuid.NewString(), userID, now.Add(ttl), tenantID,
+	).Exec(ctx, &holds); err != nil {
+		if isSeatsExhausted(err) {
+			problem.Respond(c, http.StatusConflict, "not_enough_seats", "Not enough seats available",
+				"the requested quantity exceeds the remaining capacity")
+			return
+		}
+		problem.Internal(c, "Failed to create hold", err)
+		return
+	}
+	if len(holds) == 0 {
+		hc.rejectHold(c, eventID, req.TicketTypeID, now)
+		return
+	}
+	hc.changes.Announce(tenantID, eventID)
+
+	c.JSON(http.StatusCreated, holds[0])
+}
+
+// rejectHold explains why no hold could be created for the event
+func (hc *Controller) rejectHold(c *gin.Context, eventID, ticketTypeID string, now time.Time) {
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	event, err := hc.dbService.GetClient().Event.FindFirst(
+		db.Event.ID.Equals(eventID),
+		db.Event.TenantID.Equals(tenantID),
+	).With(
+		db.Event.TicketTypes.Fetch(),
+	).Exec(c.Request.Context())
+	if err != nil || event.Status == db.EventStatusDraft {
+		problem.Respond(c, http.StatusNotFound, "event_not_found", "Event not found", "")
+		return
+	}
+	if event.Status != db.EventStatusPublished {
+		problem.Respond(c, http.StatusConflict, "event_not_open", "Event is not open for reservations",
+			"event is "+string(event.Status))
+		return
+	}
+
+	ticketTypes := event.RelationsEvent.TicketTypes
+	if ticketTypeID == "" {
+		if len(ticketTypes) > 0 {
+			problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload",
+				"ticketTypeId is required for events with ticket types")
+			return
+		}
+		// The event changed while the hold was being created
+		problem.Respond(c, http.StatusConflict, "concurrent_update", "Hold could not be created",
+			"the event changed concurrently, please retry")
+		return
+	}
+	for _, ticketType := range ticketTypes {
+		if ticketType.ID == ticketTypeID {
+			problem.Respond(c, http.StatusConflict, "ticket_type_not_on_sale", "Ticket type is not on sale",
+				"the sale window of the ticket type is not open at "+now.Format(time.RFC3339))
+			return
+		}
+	}
+	problem.Respond(c, http.StatusNotFound, "ticket_type_not_found", "Ticket type not found", "")
+}
+
+// findHold loads the hold on an event of the current tenant and checks that
+// the caller may access it. On failure the response is already written and
+// false is returned.
+func (hc *Controller) findHold(c *gin.Context, holdID string) (*db.CapacityHoldModel, bool) {
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	hold, err := hc.dbService.GetClient().CapacityHold.FindFirst(
+		db.CapacityHold.ID.Equals(holdID),
+		db.CapacityHold.Event.Where(db.Event.TenantID.Equals(tenantID)),
+	).Exec(c.Request.Context())
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			problem.Respond(c, http.StatusNotFound, "hold_not_found", "Hold not found", "")
+			return nil, false
+		}
+		problem.Internal(c, "Failed to fetch hold", err)
+		return nil, false
+	}
+
+	// Other users' holds are reported as missing rather than forbidden
+	if !canAccessHold(c, hold) {
+		problem.Respond(c, http.StatusNotFound, "hold_not_found", "Hold not found", "")
+		return nil, false
+	}
+	return hold, true
+}
+
+// GetHold godoc
+// @Summary      Get a hold
+// @Description  Returns a capacity hold. Only the user that created it or an admin may see it.
+// @Tags         holds
+// @Produce      json
+// @Param        holdId  path      string  true  "Hold ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /holds/{holdId} [get]
+func (hc *Controller) GetHold(c *gin.Context) {
+	hold, ok := hc.findHold(c, c.Param("holdId"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+// ConfirmHold godoc
+// @Summary      Confirm a hold
+// @Description  Turns the held seats into sold seats once the checkout has been paid. Expired holds cannot be confirmed.
+// @Tags         holds
+// @Produce      json
+// @Param        holdId  path      string  true  "Hold ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /holds/{holdId}/confirm [post]
+func (hc *Controller) ConfirmHold(c *gin.Context) {
+	hc.finishHold(c, confirmHoldSQL)
+}
+
+// ReleaseHold godoc
+// @Summary      Release a hold
+// @Description  Gives the held seats back, e.g. when a checkout is abandoned. The freed seats go to the
+// @Description  next students on the event's waitlist.
+// @Tags         holds
+// @Produce      json
+// @Param        holdId  path      string  true  "Hold ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /holds/{holdId}/release [post]
+func (hc *Controller) ReleaseHold(c *gin.Context) {
+	hold, ok := hc.finishHold(c, releaseHoldSQL)
+	if !ok || hc.promoter == nil {
+		return
+	}
+
+	// The promotion outlives the request, so it must not use the request context
+	go hc.promoter.PromoteAfterRelease(context.Background(), hold.EventID)
+}
+
+// finishHold runs a statement that ends an active hold and responds with the
+// result. It returns the finished hold, or false if the response is an error.
+func (hc *Controller) finishHold(c *gin.Context, statement string) (*db.CapacityHoldModel, bool) {
+	ctx := c.Request.Context()
+
+	hold, ok := hc.findHold(c, c.Param("holdId"))
+	if !ok {
+		return nil, false
+	}
+
+	now := time.Now().UTC()
+	var holds []db.CapacityHoldModel
+	if err := hc.dbService.GetClient().Prisma.QueryRaw(statement, hold.ID, now).Exec(ctx, &holds); err != nil {
+		problem.Internal(c, "Failed to update hold", err)
+		return nil, false
+	}
+	if len(holds) == 0 {
+		details := "hold is " + string(hold.Status)
+		if hold.Status == db.HoldStatusActive {
+			details = "hold expired at " + hold.ExpiresAt.Format(time.RFC3339)
+		}
+		problem.Respond(c, http.StatusConflict, "hold_not_active", "Hold is no longer active", details)
+		return nil, false
+	}
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	hc.changes.Announce(tenantID, hold.EventID)
+
+	c.JSON(http.StatusOK, holds[0])
+	return &holds[0], true
+}
@@ -0,0 +1,122 @@
+package holds
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+func newContextWithUser(userID string, roles ...string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx := context.WithValue(context.Background(), middlewares.UserIDKey, userID)
+	if len(roles) > 0 {
+		ctx = context.WithValue(ctx, middlewares.UserRolesKey, roles)
+	}
+	c.Request = httptest.NewRequest("GET", "/holds/hold-1", nil).WithContext(ctx)
+	return c
+}
+
+func TestTTL(t *testing.T) {
+	hc := &Controller{}
+
+	if ttl, err := hc.ttl(0); err != nil || ttl != DefaultTTL {
+		t.Errorf("expected default TTL %s, got %s, %v", DefaultTTL, ttl, err)
+	}
+	if ttl, err := hc.ttl(120); err != nil || ttl != 2*time.Minute {
+		t.Errorf("expected TTL of 2m, got %s, %v", ttl, err)
+	}
+	if _, err := hc.ttl(int(MaxTTL.Seconds()) + 1); err == nil {
+		t.Error("expected an error for a TTL above the maximum")
+	}
+
+	configured := &Controller{defaultTTL: time.Minute, maxTTL: 5 * time.Minute}
+	if ttl, _ := configured.ttl(0); ttl != time.Minute {
+		t.Errorf("expected configured default TTL of 1m, got %s", ttl)
+	}
+	if _, err := configured.ttl(600); err == nil {
+		t.Error("expected an error for a TTL above the configured maximum")
+	}
+}
+
+func TestIsSeatsExhausted(t *testing.T) {
+	if !isSeatsExhausted(errors.New(`new row for relation "Event" violates check constraint "Event_seats_check"`)) {
+		t.Error("expected the event seats check to be recognised")
+	}
+	if !isSeatsExhausted(errors.New(`violates check constraint "TicketType_seats_check"`)) {
+		t.Error("expected the ticket type seats check to be recognised")
+	}
+	if isSeatsExhausted(errors.New("connection refused")) {
+		t.Error("expected other errors not to be recognised")
+	}
+}
+
+func TestCanAccessHold(t *testing.T) {
+	hold := &db.CapacityHoldModel{}
+	hold.InnerCapacityHold.HolderID = "kc-holder"
+
+	tests := []struct {
+		name   string
+		ctx    *gin.Context
+		expect bool
+	}{
+		{"holder", newContextWithUser("kc-holder"), true},
+		{"other user", newContextWithUser("kc-other"), false},
+		{"admin", newContextWithUser("kc-admin", middlewares.RoleAdmin), true},
+		{"anonymous", newContextWithUser(""), false},
+	}
+
+	for _, tt := range tests {
+		if got := canAccessHold(tt.ctx, hold); got != tt.expect {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expect, got)
+		}
+	}
+}
+
+func TestCreateHold_InvalidPayload_Returns400(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hc := &Controller{}
+	r := gin.New()
+	r.POST("/events/:id/holds", hc.CreateHold)
+
+	for _, body := range []string{
+		`{}`,
+		`{"quantity":0}`,
+		`{"quantity":2,"ttlSeconds":-1}`,
+		`{"quantity":2,"ttlSeconds":86400}`,
+	} {
+		req := httptest.NewRequest("POST", "/events/abc/holds", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d. body=%s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestCreateHold_WithoutUser_Returns401(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hc := &Controller{}
+	r := gin.New()
+	r.POST("/events/:id/holds", hc.CreateHold)
+
+	req := httptest.NewRequest("POST", "/events/abc/holds", bytes.NewBufferString(`{"quantity":2}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d. body=%s", w.Code, w.Body.String())
+	}
+}
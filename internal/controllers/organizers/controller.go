@@ -0,0 +1,404 @@
+package organizers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/eventchange"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/internal/services"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+const (
+	// DefaultPageSize is used when the client does not request a page size
+	DefaultPageSize = 20
+	// MaxPageSize is the largest page the server will return, regardless of the requested limit
+	MaxPageSize = 100
+)
+
+// Controller handles organizer-related HTTP requests
+type Controller struct {
+	dbService *services.DatabaseService
+	// changes announces edits to organizers, which events embed
+	changes *eventchange.Publisher
+}
+
+// NewController creates a new organizers controller
+func NewController() *Controller {
+	return &Controller{
+		dbService: services.GetDatabaseSeviceInstance(),
+		changes:   eventchange.Default(),
+	}
+}
+
+// ListOrganizersQuery represents the query parameters accepted by the organizers listing
+type ListOrganizersQuery struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1"`
+}
+
+// pageSize returns the requested page size clamped to the server limits
+func (q *ListOrganizersQuery) pageSize() int {
+	if q.Limit <= 0 {
+		return DefaultPageSize
+	}
+	if q.Limit > MaxPageSize {
+		return MaxPageSize
+	}
+	return q.Limit
+}
+
+// OrganizerResponse is an organizer as shown to other users. The contact
+// details and the linked Keycloak user are only included for the organizer's
+// linked user and admins.
+type OrganizerResponse struct {
+	ID         string    `json:"id"`
+	TenantID   string    `json:"tenantId"`
+	Name       string    `json:"name"`
+	Email      string    `json:"email,omitempty"`
+	Phone      string    `json:"phone,omitempty"`
+	KeycloakID *string   `json:"keycloakId,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// newOrganizerResponse projects the organizer for the authenticated user
+func newOrganizerResponse(c *gin.Context, organizer *db.OrganizerModel) OrganizerResponse {
+	response := OrganizerResponse{
+		ID:        organizer.ID,
+		TenantID:  organizer.TenantID,
+		Name:      organizer.Name,
+		CreatedAt: organizer.CreatedAt,
+		UpdatedAt: organizer.UpdatedAt,
+	}
+	if canManageOrganizer(c, organizer) {
+		response.Email = organizer.Email
+		response.Phone = organizer.Phone
+		response.KeycloakID = organizer.InnerOrganizer.KeycloakID
+	}
+	return response
+}
+
+// ListOrganizersResponse is a page of organizers
+type ListOrganizersResponse struct {
+	Organizers []OrganizerResponse `json:"organizers"`
+	NextCursor *string             `json:"nextCursor"`
+}
+
+// newListOrganizersResponse builds a page from the fetched organizers.
+// organizers may hold one row more than pageSize, which signals that another page exists.
+func newListOrganizersResponse(c *gin.Context, organizers []db.OrganizerModel, pageSize int) ListOrganizersResponse {
+	response := ListOrganizersResponse{Organizers: []OrganizerResponse{}}
+	if len(organizers) > pageSize {
+		organizers = organizers[:pageSize]
+		nextCursor := organizers[pageSize-1].ID
+		response.NextCursor = &nextCursor
+	}
+	for i := range organizers {
+		response.Organizers = append(response.Organizers, newOrganizerResponse(c, &organizers[i]))
+	}
+	return response
+}
+
+// canManageOrganizer reports whether the authenticated user may modify the
+// organizer: admins, and the Keycloak user linked to the organizer
+func canManageOrganizer(c *gin.Context, organizer *db.OrganizerModel) bool {
+	if middlewares.HasRole(c, middlewares.RoleAdmin) {
+		return true
+	}
+
+	userID, ok := middlewares.GetUserIDFromContext(c)
+	if !ok || userID == "" {
+		return false
+	}
+	keycloakID, ok := organizer.KeycloakID()
+	return ok && keycloakID == userID
+}
+
+// respondWriteError turns a failed create or update into a response. Unique
+// email and Keycloak ID violations are conflicts, not server errors.
+func respondWriteError(c *gin.Context, err error, message string) {
+	if conflict, ok := db.IsErrUniqueConstraint(err); ok {
+		details := "an organizer with this email already exists"
+		for _, field := range conflict.Fields {
+			if field == "keycloakId" {
+				details = "the Keycloak user is already linked to another organizer"
+			}
+		}
+		problem.Respond(c, http.StatusConflict, "organizer_exists", "Organizer already exists", details)
+		return
+	}
+	if db.IsErrNotFound(err) {
+		problem.Respond(c, http.StatusNotFound, "organizer_not_found", "Organizer not found", "")
+		return
+	}
+	problem.Internal(c, message, err)
+}
+
+// findOrganizer loads an organizer of the current tenant. On failure the
+// response is already written and false is returned.
+func (oc *Controller) findOrganizer(c *gin.Context, organizerID string) (*db.OrganizerModel, bool) {
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	organizer, err := oc.dbService.GetClient().Organizer.FindFirst(
+		db.Organizer.ID.Equals(organizerID),
+		db.Organizer.TenantID.Equals(tenantID),
+	).Exec(c.Request.Context())
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			problem.Respond(c, http.StatusNotFound, "organizer_not_found", "Organizer not found", "")
+			return nil, false
+		}
+		problem.Internal(c, "Failed to fetch organizer", err)
+		return nil, false
+	}
+	return organizer, true
+}
+
+// authorizeOrganizerWrite loads the organizer and checks that the caller may
+// modify it. On failure the response is already written and false is returned.
+func (oc *Controller) authorizeOrganizerWrite(c *gin.Context, organizerID string) (*db.OrganizerModel, bool) {
+	organizer, ok := oc.findOrganizer(c, organizerID)
+	if !ok {
+		return nil, false
+	}
+	if !canManageOrganizer(c, organizer) {
+		problem.Respond(c, http.StatusForbidden, "not_organizer_owner", "Only the organizer's linked user or an admin may modify this organizer", "")
+		return nil, false
+	}
+	return organizer, true
+}
+
+// GetOrganizers godoc
+// @Summary      List organizers
+// @Description  Returns a page of organizers ordered by name. Pass the returned nextCursor as cursor to fetch the next page.
+// @Description  Email, phone and keycloakId are only included for the organizer's linked user and admins.
+// @Tags         organizers
+// @Produce      json
+// @Param        cursor  query     string  false  "Cursor returned by the previous page"
+// @Param        limit   query     int     false  "Page size (default 20, max 100)"
+// @Success      200  {object}  ListOrganizersResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /organizers [get]
+func (oc *Controller) GetOrganizers(c *gin.Context) {
+	var query ListOrganizersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Invalid query parameters", err.Error())
+		return
+	}
+
+	// Fetch one extra row to find out whether another page exists
+	pageSize := query.pageSize()
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	findMany := oc.dbService.GetClient().Organizer.FindMany(
+		db.Organizer.TenantID.Equals(tenantID),
+	).OrderBy(
+		db.Organizer.Name.Order(db.SortOrderAsc),
+		db.Organizer.ID.Order(db.SortOrderAsc),
+	).Take(pageSize + 1)
+	if query.Cursor != "" {
+		findMany = findMany.Cursor(db.Organizer.ID.Cursor(query.Cursor)).Skip(1)
+	}
+
+	organizers, err := findMany.Exec(c.Request.Context())
+	if err != nil {
+		problem.Internal(c, "Failed to fetch organizers", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newListOrganizersResponse(c, organizers, pageSize))
+}
+
+// GetOrganizerByID godoc
+// @Summary      Get organizer by ID
+// @Description  Returns a single organizer by its ID. Email, phone and keycloakId are only included for the organizer's linked user and admins.
+// @Tags         organizers
+// @Produce      json
+// @Param        id   path      string  true  "Organizer ID"
+// @Success      200  {object}  OrganizerResponse
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /organizers/{id} [get]
+func (oc *Controller) GetOrganizerByID(c *gin.Context) {
+	organizer, ok := oc.findOrganizer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, newOrganizerResponse(c, organizer))
+}
+
+// CreateOrganizerRequest represents the JSON payload for creating an organizer
+// @Description  Organizer creation payload
+type CreateOrganizerRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+	Phone string `json:"phone" binding:"required"`
+	// KeycloakID links the organizer to the Keycloak user that manages its events
+	KeycloakID *string `json:"keycloakId" binding:"omitempty,min=1"`
+}
+
+// CreateOrganizer godoc
+// @Summary      Create an organizer
+// @Description  Creates a new organizer. Link it to a Keycloak user through keycloakId so that user can manage its events.
+// @Tags         organizers
+// @Accept       json
+// @Produce      json
+// @Param        organizer  body      CreateOrganizerRequest  true  "Organizer to create"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /organizers [post]
+func (oc *Controller) CreateOrganizer(c *gin.Context) {
+	var req CreateOrganizerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	organizer, err := oc.dbService.GetClient().Organizer.CreateOne(
+		db.Organizer.Name.Set(req.Name),
+		db.Organizer.Email.Set(req.Email),
+		db.Organizer.Phone.Set(req.Phone),
+		db.Organizer.Tenant.Link(db.Tenant.ID.Equals(tenantID)),
+		db.Organizer.KeycloakID.SetIfPresent(req.KeycloakID),
+	).Exec(c.Request.Context())
+	if err != nil {
+		respondWriteError(c, err, "Failed to create organizer")
+		return
+	}
+
+	c.JSON(http.StatusCreated, organizer)
+}
+
+// UpdateOrganizerRequest represents the JSON payload for replacing an organizer
+// @Description  Organizer replacement payload
+type UpdateOrganizerRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+	Phone string `json:"phone" binding:"required"`
+}
+
+// UpdateOrganizer godoc
+// @Summary      Update an organizer
+// @Description  Replaces the organizer's contact details. Only the organizer's linked user or an admin may update it.
+// @Tags         organizers
+// @Accept       json
+// @Produce      json
+// @Param        id         path      string                  true  "Organizer ID"
+// @Param        organizer  body      UpdateOrganizerRequest  true  "Organizer details"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /organizers/{id} [put]
+func (oc *Controller) UpdateOrganizer(c *gin.Context) {
+	var req UpdateOrganizerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	organizer, ok := oc.authorizeOrganizerWrite(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	updated, err := oc.dbService.GetClient().Organizer.FindUnique(
+		db.Organizer.ID.Equals(organizer.ID),
+	).Update(
+		db.Organizer.Name.Set(req.Name),
+		db.Organizer.Email.Set(req.Email),
+		db.Organizer.Phone.Set(req.Phone),
+		db.Organizer.UpdatedAt.Set(time.Now()),
+	).Exec(c.Request.Context())
+	if err != nil {
+		respondWriteError(c, err, "Failed to update organizer")
+		return
+	}
+	oc.changes.AnnounceTenant(updated.TenantID)
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// PatchOrganizerRequest represents the JSON payload for partially updating an organizer.
+// Omitted fields are left unchanged.
+// @Description  Organizer partial update payload
+type PatchOrganizerRequest struct {
+	Name  *string `json:"name" binding:"omitempty,min=1"`
+	Email *string `json:"email" binding:"omitempty,email"`
+	Phone *string `json:"phone" binding:"omitempty,min=1"`
+	// KeycloakID may only be changed by admins
+	KeycloakID *string `json:"keycloakId" binding:"omitempty,min=1"`
+}
+
+// setParams converts the provided fields into Prisma update parameters
+func (req *PatchOrganizerRequest) setParams() []db.OrganizerSetParam {
+	var params []db.OrganizerSetParam
+	if req.Name != nil {
+		params = append(params, db.Organizer.Name.Set(*req.Name))
+	}
+	if req.Email != nil {
+		params = append(params, db.Organizer.Email.Set(*req.Email))
+	}
+	if req.Phone != nil {
+		params = append(params, db.Organizer.Phone.Set(*req.Phone))
+	}
+	if req.KeycloakID != nil {
+		params = append(params, db.Organizer.KeycloakID.Set(*req.KeycloakID))
+	}
+	return params
+}
+
+// PatchOrganizer godoc
+// @Summary      Partially update an organizer
+// @Description  Updates only the provided fields. Only the organizer's linked user or an admin may update it;
+// @Description  only admins may change keycloakId.
+// @Tags         organizers
+// @Accept       json
+// @Produce      json
+// @Param        id         path      string                 true  "Organizer ID"
+// @Param        organizer  body      PatchOrganizerRequest  true  "Fields to update"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /organizers/{id} [patch]
+func (oc *Controller) PatchOrganizer(c *gin.Context) {
+	var req PatchOrganizerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	organizer, ok := oc.authorizeOrganizerWrite(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if req.KeycloakID != nil && !middlewares.HasRole(c, middlewares.RoleAdmin) {
+		problem.Respond(c, http.StatusForbidden, "admin_required", "Only admins may change the linked Keycloak user", "")
+		return
+	}
+
+	params := append(req.setParams(), db.Organizer.UpdatedAt.Set(time.Now()))
+	updated, err := oc.dbService.GetClient().Organizer.FindUnique(
+		db.Organizer.ID.Equals(organizer.ID),
+	).Update(params...).Exec(c.Request.Context())
+	if err != nil {
+		respondWriteError(c, err, "Failed to update organizer")
+		return
+	}
+	oc.changes.AnnounceTenant(updated.TenantID)
+
+	c.JSON(http.StatusOK, updated)
+}
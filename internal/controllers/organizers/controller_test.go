@@ -0,0 +1,153 @@
+package organizers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+func newContextWithUser(userID string, roles ...string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx := context.WithValue(context.Background(), middlewares.UserIDKey, userID)
+	if len(roles) > 0 {
+		ctx = context.WithValue(ctx, middlewares.UserRolesKey, roles)
+	}
+	c.Request = httptest.NewRequest("PATCH", "/organizers/org-1", nil).WithContext(ctx)
+	return c
+}
+
+func newOrganizer(id string) db.OrganizerModel {
+	var organizer db.OrganizerModel
+	organizer.InnerOrganizer.ID = id
+	return organizer
+}
+
+func TestPageSize(t *testing.T) {
+	tests := map[int]int{0: DefaultPageSize, 5: 5, MaxPageSize + 1: MaxPageSize}
+	for limit, want := range tests {
+		query := ListOrganizersQuery{Limit: limit}
+		if got := query.pageSize(); got != want {
+			t.Errorf("limit %d: expected page size %d, got %d", limit, want, got)
+		}
+	}
+}
+
+func TestNewListOrganizersResponse(t *testing.T) {
+	organizers := []db.OrganizerModel{newOrganizer("a"), newOrganizer("b"), newOrganizer("c")}
+
+	c := newContextWithUser("")
+
+	page := newListOrganizersResponse(c, organizers, 2)
+	if len(page.Organizers) != 2 || page.NextCursor == nil || *page.NextCursor != "b" {
+		t.Errorf("expected 2 organizers and cursor b, got %d and %v", len(page.Organizers), page.NextCursor)
+	}
+
+	last := newListOrganizersResponse(c, organizers[:1], 2)
+	if len(last.Organizers) != 1 || last.NextCursor != nil {
+		t.Errorf("expected the last page without a cursor, got %d and %v", len(last.Organizers), last.NextCursor)
+	}
+
+	if empty := newListOrganizersResponse(c, nil, 2); empty.Organizers == nil {
+		t.Error("expected an empty page to serialise as an empty array")
+	}
+}
+
+func TestNewOrganizerResponse(t *testing.T) {
+	linked := "kc-owner"
+	organizer := newOrganizer("org-1")
+	organizer.InnerOrganizer.Email = "jazz@example.com"
+	organizer.InnerOrganizer.Phone = "+46 70 123 45 67"
+	organizer.InnerOrganizer.KeycloakID = &linked
+
+	tests := []struct {
+		name        string
+		ctx         *gin.Context
+		showContact bool
+	}{
+		{"linked user", newContextWithUser("kc-owner"), true},
+		{"admin", newContextWithUser("kc-admin", middlewares.RoleAdmin), true},
+		{"other user", newContextWithUser("kc-other"), false},
+	}
+
+	for _, tt := range tests {
+		response := newOrganizerResponse(tt.ctx, &organizer)
+		if response.ID != "org-1" {
+			t.Errorf("%s: expected organizer org-1, got %q", tt.name, response.ID)
+		}
+		shown := response.Email != "" && response.Phone != "" && response.KeycloakID != nil
+		hidden := response.Email == "" && response.Phone == "" && response.KeycloakID == nil
+		if tt.showContact && !shown {
+			t.Errorf("%s: expected the contact details, got %+v", tt.name, response)
+		}
+		if !tt.showContact && !hidden {
+			t.Errorf("%s: expected the contact details to be hidden, got %+v", tt.name, response)
+		}
+	}
+}
+
+func TestCanManageOrganizer(t *testing.T) {
+	linked := "kc-owner"
+	organizer := newOrganizer("org-1")
+	organizer.InnerOrganizer.KeycloakID = &linked
+	unlinked := newOrganizer("org-2")
+
+	tests := []struct {
+		name      string
+		ctx       *gin.Context
+		organizer db.OrganizerModel
+		expect    bool
+	}{
+		{"linked user", newContextWithUser("kc-owner"), organizer, true},
+		{"other user", newContextWithUser("kc-other"), organizer, false},
+		{"admin", newContextWithUser("kc-admin", middlewares.RoleAdmin), unlinked, true},
+		{"unlinked organizer", newContextWithUser("kc-owner"), unlinked, false},
+		{"anonymous", newContextWithUser(""), organizer, false},
+	}
+
+	for _, tt := range tests {
+		if got := canManageOrganizer(tt.ctx, &tt.organizer); got != tt.expect {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expect, got)
+		}
+	}
+}
+
+func TestCreateOrganizer_InvalidPayload_Returns400(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	oc := &Controller{}
+	r := gin.New()
+	r.POST("/organizers", oc.CreateOrganizer)
+
+	for _, body := range []string{
+		`{`,
+		`{"email":"club@example.com","phone":"+46 70 000 00 00"}`,
+		`{"name":"Quiz Club","email":"not-an-email","phone":"+46 70 000 00 00"}`,
+		`{"name":"Quiz Club","email":"club@example.com"}`,
+		`{"name":"Quiz Club","email":"club@example.com","phone":"+46 70 000 00 00","keycloakId":""}`,
+	} {
+		req := httptest.NewRequest("POST", "/organizers", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d. body=%s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestPatchOrganizerRequest_SetParams(t *testing.T) {
+	name := "Quiz Club"
+	email := "club@example.com"
+	req := PatchOrganizerRequest{Name: &name, Email: &email}
+
+	if params := req.setParams(); len(params) != 2 {
+		t.Errorf("expected 2 params, got %d", len(params))
+	}
+}
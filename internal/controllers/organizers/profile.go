@@ -0,0 +1,242 @@
+package organizers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// profileHint tells first-time organisers how to get an organizer profile
+const profileHint = "create one with POST /api/v1/organizers/me or claim an existing one with POST /api/v1/organizers/{id}/claim"
+
+// findLinkedOrganizer returns the organizer linked to the Keycloak user in
+// the current tenant, or nil if the user has none
+func (oc *Controller) findLinkedOrganizer(c *gin.Context, userID string) (*db.OrganizerModel, error) {
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	organizer, err := oc.dbService.GetClient().Organizer.FindUnique(
+		db.Organizer.TenantIDKeycloakID(
+			db.Organizer.TenantID.Equals(tenantID),
+			db.Organizer.KeycloakID.Equals(userID),
+		),
+	).Exec(c.Request.Context())
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return organizer, nil
+}
+
+// requireUser returns the authenticated user's ID. On failure the response
+// is already written and false is returned.
+func requireUser(c *gin.Context) (string, bool) {
+	userID, ok := middlewares.GetUserIDFromContext(c)
+	if !ok || userID == "" {
+		problem.Respond(c, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized", "")
+		return "", false
+	}
+	return userID, true
+}
+
+// requireNoProfile checks that the user has no organizer profile yet. On
+// failure the response is already written and false is returned.
+func (oc *Controller) requireNoProfile(c *gin.Context, userID string) bool {
+	existing, err := oc.findLinkedOrganizer(c, userID)
+	if err != nil {
+		problem.Internal(c, "Failed to fetch organizer", err)
+		return false
+	}
+	if existing != nil {
+		problem.Respond(c, http.StatusConflict, "organizer_profile_exists", "Organizer profile already exists",
+			"you are already linked to organizer "+existing.ID)
+		return false
+	}
+	return true
+}
+
+// canClaim reports whether a user with the given verified email may claim
+// the organizer: it must not be linked yet and its email must match
+func canClaim(organizer *db.OrganizerModel, verifiedEmail string) bool {
+	if _, linked := organizer.KeycloakID(); linked {
+		return false
+	}
+	return verifiedEmail != "" && strings.EqualFold(organizer.Email, verifiedEmail)
+}
+
+// GetMyOrganizer godoc
+// @Summary      Get your organizer profile
+// @Description  Returns the organizer linked to the caller's Keycloak user in the current tenant
+// @Tags         organizers
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /organizers/me [get]
+func (oc *Controller) GetMyOrganizer(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	organizer, err := oc.findLinkedOrganizer(c, userID)
+	if err != nil {
+		problem.Internal(c, "Failed to fetch organizer", err)
+		return
+	}
+	if organizer == nil {
+		problem.Respond(c, http.StatusNotFound, "organizer_profile_missing", "No organizer profile", profileHint)
+		return
+	}
+
+	c.JSON(http.StatusOK, organizer)
+}
+
+// CreateMyOrganizerRequest represents the JSON payload for creating your own organizer profile
+// @Description  Organizer profile creation payload
+type CreateMyOrganizerRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Phone string `json:"phone" binding:"required"`
+	// Email must be the verified email address of the Keycloak user, which it defaults to
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+// CreateMyOrganizer godoc
+// @Summary      Create your organizer profile
+// @Description  Creates an organizer linked to the caller's Keycloak user. Each user can have one organizer profile per tenant.
+// @Description  The organizer gets the caller's verified Keycloak email address. If an organizer with it
+// @Description  already exists, claim it instead.
+// @Tags         organizers
+// @Accept       json
+// @Produce      json
+// @Param        organizer  body      CreateMyOrganizerRequest  true  "Organizer profile"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /organizers/me [post]
+func (oc *Controller) CreateMyOrganizer(c *gin.Context) {
+	var req CreateMyOrganizerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	// Organizers are claimed by their email, so nobody may take on an address
+	// they have not verified
+	email, ok := middlewares.GetUserEmailFromContext(c)
+	if !ok {
+		problem.Respond(c, http.StatusForbidden, "email_not_verified", "A verified email address is required to create an organizer",
+			"verify your email address in Keycloak and sign in again")
+		return
+	}
+	if req.Email != "" && !strings.EqualFold(req.Email, email) {
+		problem.Respond(c, http.StatusForbidden, "email_mismatch", "Organizer email does not match your verified email address",
+			"leave out email to use your verified email address")
+		return
+	}
+
+	if !oc.requireNoProfile(c, userID) {
+		return
+	}
+
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	organizer, err := oc.dbService.GetClient().Organizer.CreateOne(
+		db.Organizer.Name.Set(req.Name),
+		db.Organizer.Email.Set(email),
+		db.Organizer.Phone.Set(req.Phone),
+		db.Organizer.Tenant.Link(db.Tenant.ID.Equals(tenantID)),
+		db.Organizer.KeycloakID.Set(userID),
+	).Exec(c.Request.Context())
+	if err != nil {
+		respondWriteError(c, err, "Failed to create organizer")
+		return
+	}
+
+	c.JSON(http.StatusCreated, organizer)
+}
+
+// ClaimOrganizer godoc
+// @Summary      Claim an organizer profile
+// @Description  Links an existing organizer that is not linked to any user yet to the caller's Keycloak user.
+// @Description  The organizer's email must match the caller's verified Keycloak email address.
+// @Tags         organizers
+// @Produce      json
+// @Param        id   path      string  true  "Organizer ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /organizers/{id}/claim [post]
+func (oc *Controller) ClaimOrganizer(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	email, ok := middlewares.GetUserEmailFromContext(c)
+	if !ok {
+		problem.Respond(c, http.StatusForbidden, "email_not_verified", "A verified email address is required to claim an organizer",
+			"verify your email address in Keycloak and sign in again")
+		return
+	}
+
+	if !oc.requireNoProfile(c, userID) {
+		return
+	}
+	organizer, ok := oc.findOrganizer(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if _, linked := organizer.KeycloakID(); linked {
+		problem.Respond(c, http.StatusConflict, "organizer_already_linked", "Organizer is already linked to another user", "")
+		return
+	}
+	if !canClaim(organizer, email) {
+		problem.Respond(c, http.StatusForbidden, "email_mismatch", "Organizer email does not match your verified email address",
+			"ask an admin to link the organizer to your account")
+		return
+	}
+
+	// Only link the organizer if nobody claimed it in the meantime
+	result, err := oc.dbService.GetClient().Organizer.FindMany(
+		db.Organizer.ID.Equals(organizer.ID),
+		db.Organizer.TenantID.Equals(organizer.TenantID),
+		db.Organizer.KeycloakID.EqualsOptional(nil),
+	).Update(
+		db.Organizer.KeycloakID.Set(userID),
+		db.Organizer.UpdatedAt.Set(time.Now()),
+	).Exec(ctx)
+	if err != nil {
+		respondWriteError(c, err, "Failed to claim organizer")
+		return
+	}
+	if result.Count == 0 {
+		problem.Respond(c, http.StatusConflict, "organizer_already_linked", "Organizer is already linked to another user", "")
+		return
+	}
+	// The organizer's drafts are now visible to the user
+	oc.changes.AnnounceTenant(organizer.TenantID)
+
+	claimed, ok := oc.findOrganizer(c, organizer.ID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, claimed)
+}
@@ -0,0 +1,146 @@
+package organizers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+)
+
+// withUser makes every request of the test router look authenticated as userID
+func withUser(userID, email string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.WithValue(c.Request.Context(), middlewares.UserIDKey, userID)
+		if email != "" {
+			ctx = context.WithValue(ctx, middlewares.UserEmailKey, email)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+func TestCanClaim(t *testing.T) {
+	organizer := newOrganizer("org-1")
+	organizer.InnerOrganizer.Email = "Jazz@Example.com"
+
+	if !canClaim(&organizer, "jazz@example.com") {
+		t.Error("expected a matching verified email to be allowed to claim, ignoring case")
+	}
+	if canClaim(&organizer, "other@example.com") {
+		t.Error("expected a different email not to be allowed to claim")
+	}
+	if canClaim(&organizer, "") {
+		t.Error("expected an unverified email not to be allowed to claim")
+	}
+
+	linked := "kc-owner"
+	organizer.InnerOrganizer.KeycloakID = &linked
+	if canClaim(&organizer, "jazz@example.com") {
+		t.Error("expected a linked organizer not to be claimable")
+	}
+}
+
+func TestCreateMyOrganizer_InvalidPayload_Returns400(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	oc := &Controller{}
+	r := gin.New()
+	r.Use(withUser("kc-user-123", "quiz@example.com"))
+	r.POST("/organizers/me", oc.CreateMyOrganizer)
+
+	for _, body := range []string{
+		`{"phone":"+46 70 000 00 00"}`,
+		`{"name":"Quiz Club","phone":"+46 70 000 00 00","email":"not-an-email"}`,
+	} {
+		req := httptest.NewRequest("POST", "/organizers/me", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d. body=%s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestCreateMyOrganizer_UnverifiedEmail_Returns403(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	oc := &Controller{}
+
+	tests := []struct {
+		name  string
+		email string
+		body  string
+	}{
+		{"no verified email", "", `{"name":"Quiz Club","phone":"+46 70 000 00 00","email":"quiz@example.com"}`},
+		{"other email", "quiz@example.com", `{"name":"Quiz Club","phone":"+46 70 000 00 00","email":"board@example.com"}`},
+	}
+
+	for _, tt := range tests {
+		r := gin.New()
+		r.Use(withUser("kc-user-123", tt.email))
+		r.POST("/organizers/me", oc.CreateMyOrganizer)
+
+		req := httptest.NewRequest("POST", "/organizers/me", bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected status 403, got %d. body=%s", tt.name, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestProfile_WithoutUser_Returns401(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	oc := &Controller{}
+	r := gin.New()
+	r.GET("/organizers/me", oc.GetMyOrganizer)
+	r.POST("/organizers/me", oc.CreateMyOrganizer)
+	r.POST("/organizers/:id/claim", oc.ClaimOrganizer)
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"GET", "/organizers/me", ""},
+		{"POST", "/organizers/me", `{"name":"Quiz Club","phone":"+46 70 000 00 00"}`},
+		{"POST", "/organizers/org-1/claim", ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s: expected status 401, got %d. body=%s", tt.method, tt.path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestClaimOrganizer_WithoutVerifiedEmail_Returns403(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	oc := &Controller{}
+	r := gin.New()
+	r.Use(withUser("kc-user-123", ""))
+	r.POST("/organizers/:id/claim", oc.ClaimOrganizer)
+
+	req := httptest.NewRequest("POST", "/organizers/org-1/claim", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d. body=%s", w.Code, w.Body.String())
+	}
+}
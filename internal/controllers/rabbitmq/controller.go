@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
 	"github.com/oskargbc/dws-event-service.git/internal/pkg/rabbitmq"
 	"github.com/oskargbc/dws-event-service.git/internal/services"
 )
@@ -41,20 +42,13 @@ func NewController() *Controller {
 // @Router       /rabbitmq/test [get]
 func (rc *Controller) TestConnection(c *gin.Context) {
 	if rc.rabbitmqService == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status":  "error",
-			"message": "RabbitMQ service is not available or not enabled",
-		})
+		problem.Respond(c, http.StatusServiceUnavailable, "rabbitmq_unavailable", "RabbitMQ service is not available or not enabled", "")
 		return
 	}
 
 	ctx := c.Request.Context()
 	if err := rc.rabbitmqService.HealthCheck(ctx); err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status":  "error",
-			"message": "RabbitMQ health check failed",
-			"error":   err.Error(),
-		})
+		problem.Unavailable(c, "rabbitmq_unavailable", "RabbitMQ health check failed", err)
 		return
 	}
 
@@ -90,20 +84,13 @@ type PublishTestMessageRequest struct {
 // @Router       /rabbitmq/publish [post]
 func (rc *Controller) PublishTestMessage(c *gin.Context) {
 	if rc.rabbitmqService == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status":  "error",
-			"message": "RabbitMQ service is not available or not enabled",
-		})
+		problem.Respond(c, http.StatusServiceUnavailable, "rabbitmq_unavailable", "RabbitMQ service is not available or not enabled", "")
 		return
 	}
 
 	var req PublishTestMessageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "Invalid request payload",
-			"error":   err.Error(),
-		})
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload", err.Error())
 		return
 	}
 
@@ -119,11 +106,7 @@ func (rc *Controller) PublishTestMessage(c *gin.Context) {
 
 	// Publish the message
 	if err := rabbitmq.PublishEventMessage(req.Exchange, req.RoutingKey, eventMsg); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Failed to publish message",
-			"error":   err.Error(),
-		})
+		problem.Internal(c, "Failed to publish message", err)
 		return
 	}
 
@@ -147,10 +130,7 @@ func (rc *Controller) PublishTestMessage(c *gin.Context) {
 // @Router       /rabbitmq/setup [post]
 func (rc *Controller) SetupTestExchangeAndQueue(c *gin.Context) {
 	if rc.rabbitmqService == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status":  "error",
-			"message": "RabbitMQ service is not available or not enabled",
-		})
+		problem.Respond(c, http.StatusServiceUnavailable, "rabbitmq_unavailable", "RabbitMQ service is not available or not enabled", "")
 		return
 	}
 
@@ -166,11 +146,7 @@ func (rc *Controller) SetupTestExchangeAndQueue(c *gin.Context) {
 		routingKey,
 		true, // durable
 	); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Failed to setup exchange and queue",
-			"error":   err.Error(),
-		})
+		problem.Internal(c, "Failed to setup exchange and queue", err)
 		return
 	}
 
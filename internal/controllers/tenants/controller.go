@@ -0,0 +1,413 @@
+package tenants
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/internal/services"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+const (
+	// DefaultCacheTTL is used when branding.cache_ttl is not configured
+	DefaultCacheTTL = 5 * time.Minute
+	// DefaultLocale is used when branding.default_locale is not configured
+	DefaultLocale = "en"
+	// DefaultTimezone is used when branding.default_timezone is not configured
+	DefaultTimezone = "UTC"
+)
+
+// DefaultPalette is served for tenants that have not configured their branding
+var DefaultPalette = Palette{
+	Primary:    "#1a73e8",
+	Secondary:  "#5f6368",
+	Accent:     "#fbbc04",
+	Background: "#ffffff",
+	Text:       "#202124",
+}
+
+// Palette holds the colours of a tenant's frontend as hex codes
+type Palette struct {
+	Primary    string `json:"primary"`
+	Secondary  string `json:"secondary"`
+	Accent     string `json:"accent"`
+	Background string `json:"background"`
+	Text       string `json:"text"`
+}
+
+// Branding is the theme of a tenant's white-label frontend. UpdatedAt is
+// only set once the tenant has configured its branding.
+type Branding struct {
+	Tenant            string     `json:"tenant"`
+	Name              string     `json:"name"`
+	LogoURL           *string    `json:"logoUrl"`
+	Palette           Palette    `json:"palette"`
+	EnabledCategories []string   `json:"enabledCategories"`
+	DefaultLocale     string     `json:"defaultLocale"`
+	Timezone          string     `json:"timezone"`
+	UpdatedAt         *time.Time `json:"updatedAt,omitempty"`
+}
+
+// cachedBranding is a cache entry of the public branding endpoint
+type cachedBranding struct {
+	branding  Branding
+	expiresAt time.Time
+}
+
+// Controller handles tenant-related HTTP requests
+type Controller struct {
+	dbService       *services.DatabaseService
+	cacheTTL        time.Duration
+	defaultLocale   string
+	defaultTimezone string
+	// vary lists the request headers the tenant is resolved from
+	vary string
+
+	mu    sync.RWMutex
+	cache map[string]cachedBranding
+}
+
+// NewController creates a new tenants controller
+func NewController() *Controller {
+	cfg := configs.GetEnvConfig()
+	return newController(services.GetDatabaseSeviceInstance(), cfg.Branding, cfg.Tenancy.Header)
+}
+
+// newController applies the defaults to the branding config. Branding varies
+// on the tenant header and on the host, which select the tenant of the public
+// endpoint.
+func newController(dbService *services.DatabaseService, cfg configs.Branding, tenantHeader string) *Controller {
+	if tenantHeader == "" {
+		tenantHeader = middlewares.DefaultTenantHeader
+	}
+	tc := &Controller{
+		dbService:       dbService,
+		cacheTTL:        cfg.CacheTTL,
+		defaultLocale:   cfg.DefaultLocale,
+		defaultTimezone: cfg.DefaultTimezone,
+		vary:            tenantHeader + ", Host",
+		cache:           map[string]cachedBranding{},
+	}
+	if tc.cacheTTL <= 0 {
+		tc.cacheTTL = DefaultCacheTTL
+	}
+	if tc.defaultLocale == "" {
+		tc.defaultLocale = DefaultLocale
+	}
+	if tc.defaultTimezone == "" {
+		tc.defaultTimezone = DefaultTimezone
+	}
+	return tc
+}
+
+// defaultBranding is served for tenants without stored branding
+func (tc *Controller) defaultBranding(tenant *db.TenantModel) Branding {
+	return Branding{
+		Tenant:            tenant.Slug,
+		Name:              tenant.Name,
+		Palette:           DefaultPalette,
+		EnabledCategories: []string{},
+		DefaultLocale:     tc.defaultLocale,
+		Timezone:          tc.defaultTimezone,
+	}
+}
+
+// newBranding converts stored branding into its response
+func newBranding(tenant *db.TenantModel, stored *db.TenantBrandingModel) Branding {
+	branding := Branding{
+		Tenant: tenant.Slug,
+		Name:   stored.Name,
+		Palette: Palette{
+			Primary:    stored.PrimaryColor,
+			Secondary:  stored.SecondaryColor,
+			Accent:     stored.AccentColor,
+			Background: stored.BackgroundColor,
+			Text:       stored.TextColor,
+		},
+		EnabledCategories: append([]string{}, stored.EnabledCategories...),
+		DefaultLocale:     stored.DefaultLocale,
+		Timezone:          stored.Timezone,
+		UpdatedAt:         &stored.UpdatedAt,
+	}
+	if logoURL, ok := stored.LogoURL(); ok {
+		branding.LogoURL = &logoURL
+	}
+	return branding
+}
+
+// cached returns the cached branding of the tenant, if it has not expired
+func (tc *Controller) cached(tenantID string, now time.Time) (Branding, bool) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	entry, ok := tc.cache[tenantID]
+	if !ok || !now.Before(entry.expiresAt) {
+		return Branding{}, false
+	}
+	return entry.branding, true
+}
+
+// store caches the tenant's branding for the cache TTL
+func (tc *Controller) store(tenantID string, branding Branding, now time.Time) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.cache[tenantID] = cachedBranding{branding: branding, expiresAt: now.Add(tc.cacheTTL)}
+}
+
+// requireTenant returns the request's tenant. On failure the response is
+// already written and false is returned.
+func requireTenant(c *gin.Context) (*db.TenantModel, bool) {
+	tenant, ok := middlewares.GetTenantFromContext(c)
+	if !ok {
+		problem.Respond(c, http.StatusBadRequest, "tenant_required", "Tenant required", "")
+		return nil, false
+	}
+	return tenant, true
+}
+
+// loadBranding returns the tenant's stored branding, or its defaults
+func (tc *Controller) loadBranding(c *gin.Context, tenant *db.TenantModel) (Branding, error) {
+	stored, err := tc.dbService.GetClient().TenantBranding.FindUnique(
+		db.TenantBranding.TenantID.Equals(tenant.ID),
+	).Exec(c.Request.Context())
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			return tc.defaultBranding(tenant), nil
+		}
+		return Branding{}, err
+	}
+	return newBranding(tenant, stored), nil
+}
+
+// GetBranding godoc
+// @Summary      Get the tenant's branding
+// @Description  Returns the theme of the tenant's white-label frontend: name, logo, colour palette, enabled categories,
+// @Description  default locale and time zone. Public; the tenant is resolved from the tenant header (X-Tenant by default) or the host.
+// @Description  Tenants that have not configured their branding get the defaults.
+// @Tags         tenants
+// @Produce      json
+// @Param        X-Tenant  header    string  false  "Tenant slug"
+// @Success      200  {object}  Branding
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /_meta/branding [get]
+func (tc *Controller) GetBranding(c *gin.Context) {
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	branding, ok := tc.cached(tenant.ID, now)
+	if !ok {
+		var err error
+		branding, err = tc.loadBranding(c, tenant)
+		if err != nil {
+			problem.Internal(c, "Failed to fetch branding", err)
+			return
+		}
+		tc.store(tenant.ID, branding, now)
+	}
+
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(tc.cacheTTL.Seconds())))
+	c.Header("Vary", tc.vary)
+	c.JSON(http.StatusOK, branding)
+}
+
+// PaletteRequest represents the colour palette of a branding payload
+type PaletteRequest struct {
+	Primary    string `json:"primary" binding:"required,hexcolor"`
+	Secondary  string `json:"secondary" binding:"required,hexcolor"`
+	Accent     string `json:"accent" binding:"required,hexcolor"`
+	Background string `json:"background" binding:"required,hexcolor"`
+	Text       string `json:"text" binding:"required,hexcolor"`
+}
+
+// UpdateBrandingRequest represents the JSON payload for replacing the tenant's branding
+// @Description  Tenant branding payload
+type UpdateBrandingRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	// LogoURL is omitted or empty for tenants without a logo
+	LogoURL string         `json:"logoUrl" binding:"omitempty,http_url"`
+	Palette PaletteRequest `json:"palette"`
+	// EnabledCategories is empty to enable every category
+	EnabledCategories []string `json:"enabledCategories" binding:"omitempty,dive,required"`
+	DefaultLocale     string   `json:"defaultLocale" binding:"required,bcp47_language_tag"`
+	Timezone          string   `json:"timezone" binding:"required,timezone"`
+}
+
+// branding returns the branding the request describes
+func (req *UpdateBrandingRequest) branding(tenant *db.TenantModel) Branding {
+	branding := Branding{
+		Tenant:            tenant.Slug,
+		Name:              req.Name,
+		Palette:           Palette(req.Palette),
+		EnabledCategories: append([]string{}, req.EnabledCategories...),
+		DefaultLocale:     req.DefaultLocale,
+		Timezone:          req.Timezone,
+	}
+	if req.LogoURL != "" {
+		branding.LogoURL = &req.LogoURL
+	}
+	return branding
+}
+
+// PatchPaletteRequest represents the colours to change in a branding patch
+type PatchPaletteRequest struct {
+	Primary    *string `json:"primary" binding:"omitempty,hexcolor"`
+	Secondary  *string `json:"secondary" binding:"omitempty,hexcolor"`
+	Accent     *string `json:"accent" binding:"omitempty,hexcolor"`
+	Background *string `json:"background" binding:"omitempty,hexcolor"`
+	Text       *string `json:"text" binding:"omitempty,hexcolor"`
+}
+
+// PatchBrandingRequest represents the JSON payload for changing part of the tenant's branding
+// @Description  Partial tenant branding payload
+type PatchBrandingRequest struct {
+	Name *string `json:"name" binding:"omitempty,min=1,max=100"`
+	// LogoURL is set to "" to remove the logo
+	LogoURL           *string              `json:"logoUrl" binding:"omitempty,eq=|http_url"`
+	Palette           *PatchPaletteRequest `json:"palette"`
+	EnabledCategories *[]string            `json:"enabledCategories" binding:"omitempty,dive,required"`
+	DefaultLocale     *string              `json:"defaultLocale" binding:"omitempty,bcp47_language_tag"`
+	Timezone          *string              `json:"timezone" binding:"omitempty,timezone"`
+}
+
+// apply returns the branding with the patch applied
+func (req *PatchBrandingRequest) apply(branding Branding) Branding {
+	set := func(target *string, value *string) {
+		if value != nil {
+			*target = *value
+		}
+	}
+	set(&branding.Name, req.Name)
+	set(&branding.DefaultLocale, req.DefaultLocale)
+	set(&branding.Timezone, req.Timezone)
+	if req.LogoURL != nil {
+		branding.LogoURL = nil
+		if *req.LogoURL != "" {
+			logoURL := *req.LogoURL
+			branding.LogoURL = &logoURL
+		}
+	}
+	if req.Palette != nil {
+		set(&branding.Palette.Primary, req.Palette.Primary)
+		set(&branding.Palette.Secondary, req.Palette.Secondary)
+		set(&branding.Palette.Accent, req.Palette.Accent)
+		set(&branding.Palette.Background, req.Palette.Background)
+		set(&branding.Palette.Text, req.Palette.Text)
+	}
+	if req.EnabledCategories != nil {
+		branding.EnabledCategories = append([]string{}, (*req.EnabledCategories)...)
+	}
+	return branding
+}
+
+// save stores the tenant's branding, refreshes this instance's cache and
+// responds with the stored branding
+func (tc *Controller) save(c *gin.Context, tenant *db.TenantModel, branding Branding) {
+	optional := []db.TenantBrandingSetParam{
+		db.TenantBranding.LogoURL.SetOptional(branding.LogoURL),
+		db.TenantBranding.EnabledCategories.Set(branding.EnabledCategories),
+	}
+	update := append([]db.TenantBrandingSetParam{
+		db.TenantBranding.Name.Set(branding.Name),
+		db.TenantBranding.PrimaryColor.Set(branding.Palette.Primary),
+		db.TenantBranding.SecondaryColor.Set(branding.Palette.Secondary),
+		db.TenantBranding.AccentColor.Set(branding.Palette.Accent),
+		db.TenantBranding.BackgroundColor.Set(branding.Palette.Background),
+		db.TenantBranding.TextColor.Set(branding.Palette.Text),
+		db.TenantBranding.DefaultLocale.Set(branding.DefaultLocale),
+		db.TenantBranding.Timezone.Set(branding.Timezone),
+		db.TenantBranding.UpdatedAt.Set(time.Now()),
+	}, optional...)
+
+	stored, err := tc.dbService.GetClient().TenantBranding.UpsertOne(
+		db.TenantBranding.TenantID.Equals(tenant.ID),
+	).Create(
+		db.TenantBranding.Name.Set(branding.Name),
+		db.TenantBranding.PrimaryColor.Set(branding.Palette.Primary),
+		db.TenantBranding.SecondaryColor.Set(branding.Palette.Secondary),
+		db.TenantBranding.AccentColor.Set(branding.Palette.Accent),
+		db.TenantBranding.BackgroundColor.Set(branding.Palette.Background),
+		db.TenantBranding.TextColor.Set(branding.Palette.Text),
+		db.TenantBranding.DefaultLocale.Set(branding.DefaultLocale),
+		db.TenantBranding.Timezone.Set(branding.Timezone),
+		db.TenantBranding.Tenant.Link(db.Tenant.ID.Equals(tenant.ID)),
+		optional...,
+	).Update(update...).Exec(c.Request.Context())
+	if err != nil {
+		problem.Internal(c, "Failed to update branding", err)
+		return
+	}
+
+	saved := newBranding(tenant, stored)
+	tc.store(tenant.ID, saved, time.Now())
+	c.JSON(http.StatusOK, saved)
+}
+
+// UpdateBranding godoc
+// @Summary      Replace the tenant's branding
+// @Description  Replaces the theme of the current tenant's white-label frontend. Admins only.
+// @Tags         tenants
+// @Accept       json
+// @Produce      json
+// @Param        branding  body      UpdateBrandingRequest  true  "Branding"
+// @Success      200  {object}  Branding
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /tenant/branding [put]
+func (tc *Controller) UpdateBranding(c *gin.Context) {
+	var req UpdateBrandingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	tc.save(c, tenant, req.branding(tenant))
+}
+
+// PatchBranding godoc
+// @Summary      Change the tenant's branding
+// @Description  Changes only the given parts of the current tenant's branding. Tenants without branding start from
+// @Description  the defaults. Admins only.
+// @Tags         tenants
+// @Accept       json
+// @Produce      json
+// @Param        branding  body      PatchBrandingRequest  true  "Branding fields to change"
+// @Success      200  {object}  Branding
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /tenant/branding [patch]
+func (tc *Controller) PatchBranding(c *gin.Context) {
+	var req PatchBrandingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	current, err := tc.loadBranding(c, tenant)
+	if err != nil {
+		problem.Internal(c, "Failed to fetch branding", err)
+		return
+	}
+
+	tc.save(c, tenant, req.apply(current))
+}
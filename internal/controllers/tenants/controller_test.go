@@ -0,0 +1,142 @@
+package tenants
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+func newTenant(slug, name string) *db.TenantModel {
+	var tenant db.TenantModel
+	tenant.InnerTenant.ID = "t-" + slug
+	tenant.InnerTenant.Slug = slug
+	tenant.InnerTenant.Name = name
+	return &tenant
+}
+
+func TestNewController_Defaults(t *testing.T) {
+	tc := newController(nil, configs.Branding{}, "")
+	if tc.cacheTTL != DefaultCacheTTL || tc.defaultLocale != DefaultLocale || tc.defaultTimezone != DefaultTimezone {
+		t.Errorf("expected the defaults, got %s, %s and %s", tc.cacheTTL, tc.defaultLocale, tc.defaultTimezone)
+	}
+	if tc.vary != "X-Tenant, Host" {
+		t.Errorf("expected to vary on the default tenant header and the host, got %q", tc.vary)
+	}
+
+	branding := tc.defaultBranding(newTenant("ltu", "Luleå University of Technology"))
+	if branding.Name != "Luleå University of Technology" || branding.Palette != DefaultPalette || branding.UpdatedAt != nil {
+		t.Errorf("expected the default branding named after the tenant, got %+v", branding)
+	}
+	if branding.EnabledCategories == nil {
+		t.Error("expected enabled categories to serialise as an empty array")
+	}
+}
+
+func TestNewController_CustomTenantHeader(t *testing.T) {
+	tc := newController(nil, configs.Branding{}, "X-University")
+	if tc.vary != "X-University, Host" {
+		t.Errorf("expected to vary on the configured tenant header and the host, got %q", tc.vary)
+	}
+}
+
+func TestCache_Expires(t *testing.T) {
+	tc := newController(nil, configs.Branding{CacheTTL: time.Minute}, "")
+	now := time.Now()
+	tc.store("t-ltu", Branding{Name: "LTU"}, now)
+
+	if branding, ok := tc.cached("t-ltu", now.Add(30*time.Second)); !ok || branding.Name != "LTU" {
+		t.Errorf("expected a cache hit within the TTL, got %v and %+v", ok, branding)
+	}
+	if _, ok := tc.cached("t-ltu", now.Add(time.Minute)); ok {
+		t.Error("expected the entry to expire after the TTL")
+	}
+	if _, ok := tc.cached("t-umu", now); ok {
+		t.Error("expected a miss for other tenants")
+	}
+}
+
+func TestPatchBrandingRequest_Apply(t *testing.T) {
+	logoURL := "https://cdn.example.com/ltu.svg"
+	current := Branding{Name: "LTU", LogoURL: &logoURL, Palette: DefaultPalette, DefaultLocale: "sv-SE", Timezone: "Europe/Stockholm"}
+
+	name := "LTU Student Events"
+	primary := "#ff6600"
+	noLogo := ""
+	categories := []string{"Music"}
+	req := PatchBrandingRequest{
+		Name:              &name,
+		LogoURL:           &noLogo,
+		Palette:           &PatchPaletteRequest{Primary: &primary},
+		EnabledCategories: &categories,
+	}
+
+	patched := req.apply(current)
+	if patched.Name != name || patched.LogoURL != nil || patched.Palette.Primary != primary {
+		t.Errorf("expected the patched fields to change, got %+v", patched)
+	}
+	if patched.Palette.Secondary != DefaultPalette.Secondary || patched.DefaultLocale != "sv-SE" || len(patched.EnabledCategories) != 1 {
+		t.Errorf("expected the other fields to stay, got %+v", patched)
+	}
+	if current.LogoURL == nil || current.Name != "LTU" {
+		t.Error("expected the current branding to be left alone")
+	}
+}
+
+func TestUpdateBranding_InvalidPayload_Returns400(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tc := &Controller{}
+	r := gin.New()
+	r.PUT("/tenant/branding", tc.UpdateBranding)
+	r.PATCH("/tenant/branding", tc.PatchBranding)
+
+	palette := `"palette":{"primary":"#1a73e8","secondary":"#5f6368","accent":"#fbbc04","background":"#fff","text":"#202124"}`
+	tests := []struct {
+		method string
+		body   string
+	}{
+		{"PUT", `{`},
+		{"PUT", `{"name":"LTU",` + palette + `,"defaultLocale":"sv-SE"}`},
+		{"PUT", `{"name":"LTU",` + palette + `,"defaultLocale":"sv-SE","timezone":"Europe/Lulea"}`},
+		{"PUT", `{"name":"LTU",` + palette + `,"defaultLocale":"not a locale","timezone":"Europe/Stockholm"}`},
+		{"PUT", `{"name":"LTU","palette":{"primary":"blue"},"defaultLocale":"sv-SE","timezone":"Europe/Stockholm"}`},
+		{"PUT", `{"name":"LTU",` + palette + `,"logoUrl":"ftp://example.com/logo.png","defaultLocale":"sv-SE","timezone":"Europe/Stockholm"}`},
+		{"PATCH", `{"palette":{"accent":"#12345"}}`},
+		{"PATCH", `{"enabledCategories":["Music",""]}`},
+		{"PATCH", `{"timezone":"Mars/Olympus_Mons"}`},
+		{"PATCH", `{"logoUrl":"not a url"}`},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/tenant/branding", bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: expected status 400, got %d. body=%s", tt.method, tt.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestGetBranding_WithoutTenant_Returns400(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tc := newController(nil, configs.Branding{}, "")
+	r := gin.New()
+	r.GET("/_meta/branding", tc.GetBranding)
+
+	req := httptest.NewRequest("GET", "/_meta/branding", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d. body=%s", w.Code, w.Body.String())
+	}
+}
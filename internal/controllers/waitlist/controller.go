@@ -0,0 +1,378 @@
+package waitlist
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/logger"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	waitlistPromoter "github.com/oskargbc/dws-event-service.git/internal/pkg/waitlist"
+	"github.com/oskargbc/dws-event-service.git/internal/services"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+
+	"github.com/sirupsen/logrus"
+)
+
+// aheadSQL counts the waiting entries that joined before the given entry
+const aheadSQL = `
+SELECT COUNT(*)::int AS "ahead"
+FROM "public"."WaitlistEntry"
+WHERE "eventId" = $1 AND "status" = 'WAITING' AND ("joinedAt", "id") < ($2, $3)`
+
+// Controller handles waitlist requests
+type Controller struct {
+	dbService *services.DatabaseService
+	promoter  *waitlistPromoter.Promoter
+	logger    *logrus.Logger
+}
+
+// NewController creates a new waitlist controller
+func NewController() *Controller {
+	return &Controller{
+		dbService: services.GetDatabaseSeviceInstance(),
+		promoter:  waitlistPromoter.NewPromoter(),
+		logger:    logger.NewLogrusLogger(),
+	}
+}
+
+// JoinWaitlistRequest represents the JSON payload for joining a waitlist
+// @Description  Waitlist join payload
+type JoinWaitlistRequest struct {
+	// Quantity defaults to 1
+	Quantity int `json:"quantity" binding:"omitempty,min=1,max=10"`
+	// TicketTypeID is required for events that have ticket types
+	TicketTypeID string `json:"ticketTypeId"`
+}
+
+// EntryPosition is a waitlist entry together with its place in the queue.
+// Position and Ahead are 0 once the entry has been promoted.
+type EntryPosition struct {
+	db.WaitlistEntryModel
+	Position int `json:"position"`
+	Ahead    int `json:"ahead"`
+}
+
+// seatsLeft returns how many seats can still be held for the event or, if
+// given, its ticket type
+func seatsLeft(event *db.EventModel, ticketType *db.TicketTypeModel) int {
+	left := event.Capacity - event.SeatsSold - event.SeatsHeld
+	if ticketType != nil {
+		if tier := ticketType.Quota - ticketType.Sold - ticketType.Held; tier < left {
+			left = tier
+		}
+	}
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// saleOpen reports whether the ticket type's sale window is open at now
+func saleOpen(ticketType *db.TicketTypeModel, now time.Time) bool {
+	if start, ok := ticketType.SaleStartsAt(); ok && now.Before(start) {
+		return false
+	}
+	if end, ok := ticketType.SaleEndsAt(); ok && !now.Before(end) {
+		return false
+	}
+	return true
+}
+
+// requireUser returns the authenticated user's ID. On failure the response
+// is already written and false is returned.
+func requireUser(c *gin.Context) (string, bool) {
+	userID, ok := middlewares.GetUserIDFromContext(c)
+	if !ok || userID == "" {
+		problem.Respond(c, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized", "")
+		return "", false
+	}
+	return userID, true
+}
+
+// findEntry loads the caller's entry on the waitlist of an event of the
+// current tenant. On failure the response is already written and false is
+// returned.
+func (wc *Controller) findEntry(c *gin.Context, eventID, userID string) (*db.WaitlistEntryModel, bool) {
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	entry, err := wc.dbService.GetClient().WaitlistEntry.FindFirst(
+		db.WaitlistEntry.EventID.Equals(eventID),
+		db.WaitlistEntry.UserID.Equals(userID),
+		db.WaitlistEntry.Event.Where(db.Event.TenantID.Equals(tenantID)),
+	).With(
+		db.WaitlistEntry.Hold.Fetch(),
+	).Exec(c.Request.Context())
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			problem.Respond(c, http.StatusNotFound, "waitlist_entry_not_found", "Not on the waitlist", "")
+			return nil, false
+		}
+		problem.Internal(c, "Failed to fetch waitlist entry", err)
+		return nil, false
+	}
+	return entry, true
+}
+
+// position computes the entry's place in the queue
+func (wc *Controller) position(c *gin.Context, entry *db.WaitlistEntryModel) (*EntryPosition, error) {
+	result := &EntryPosition{WaitlistEntryModel: *entry}
+	if entry.Status != db.WaitlistStatusWaiting {
+		return result, nil
+	}
+
+	var rows []struct {
+		Ahead int `json:"ahead"`
+	}
+	if err := wc.dbService.GetClient().Prisma.QueryRaw(
+		aheadSQL, entry.EventID, entry.JoinedAt, entry.ID,
+	).Exec(c.Request.Context(), &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 {
+		result.Ahead = rows[0].Ahead
+	}
+	result.Position = result.Ahead + 1
+	return result, nil
+}
+
+// JoinWaitlist godoc
+// @Summary      Join an event's waitlist
+// @Description  Queues the caller for seats of a published event that does not have enough seats left. When seats
+// @Description  are released, waiting students are promoted in the order they joined: a hold for their seats is
+// @Description  created in their name and a waitlist.promoted message is published. The waitlist is also promoted
+// @Description  right after joining, so the returned entry may already be promoted.
+// @Tags         waitlist
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string               true  "Event ID"
+// @Param        entry  body      JoinWaitlistRequest  true  "Seats to wait for"
+// @Success      201    {object}  EntryPosition
+// @Failure      400    {object}  map[string]interface{}
+// @Failure      401    {object}  map[string]interface{}
+// @Failure      404    {object}  map[string]interface{}
+// @Failure      409    {object}  map[string]interface{}
+// @Failure      500    {object}  map[string]interface{}
+// @Router       /events/{id}/waitlist [post]
+func (wc *Controller) JoinWaitlist(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID := c.Param("id")
+
+	var req JoinWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload", err.Error())
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := middlewares.GetTenantIDFromContext(c)
+	event, err := wc.dbService.GetClient().Event.FindFirst(
+		db.Event.ID.Equals(eventID),
+		db.Event.TenantID.Equals(tenantID),
+	).With(
+		db.Event.TicketTypes.Fetch(),
+	).Exec(ctx)
+	if err != nil || event.Status == db.EventStatusDraft {
+		if err != nil && !db.IsErrNotFound(err) {
+			problem.Internal(c, "Failed to fetch event", err)
+			return
+		}
+		problem.Respond(c, http.StatusNotFound, "event_not_found", "Event not found", "")
+		return
+	}
+	if event.Status != db.EventStatusPublished {
+		problem.Respond(c, http.StatusConflict, "event_not_open", "Event is not open for reservations",
+			"event is "+string(event.Status))
+		return
+	}
+
+	now := time.Now().UTC()
+	var ticketType *db.TicketTypeModel
+	ticketTypes := event.RelationsEvent.TicketTypes
+	if req.TicketTypeID == "" && len(ticketTypes) > 0 {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid request payload",
+			"ticketTypeId is required for events with ticket types")
+		return
+	}
+	if req.TicketTypeID != "" {
+		for i := range ticketTypes {
+			if ticketTypes[i].ID == req.TicketTypeID {
+				ticketType = &ticketTypes[i]
+			}
+		}
+		if ticketType == nil {
+			problem.Respond(c, http.StatusNotFound, "ticket_type_not_found", "Ticket type not found", "")
+			return
+		}
+		if !saleOpen(ticketType, now) {
+			problem.Respond(c, http.StatusConflict, "ticket_type_not_on_sale", "Ticket type is not on sale",
+				"the sale window of the ticket type is not open at "+now.Format(time.RFC3339))
+			return
+		}
+	}
+
+	if seatsLeft(event, ticketType) >= req.Quantity {
+		problem.Respond(c, http.StatusConflict, "seats_available", "Seats are still available",
+			"reserve them with POST /api/v1/events/"+eventID+"/holds")
+		return
+	}
+
+	// A promoted student whose hold has ended may queue again; anyone else is already queued
+	existing, err := wc.dbService.GetClient().WaitlistEntry.FindUnique(
+		db.WaitlistEntry.EventIDUserID(
+			db.WaitlistEntry.EventID.Equals(eventID),
+			db.WaitlistEntry.UserID.Equals(userID),
+		),
+	).With(
+		db.WaitlistEntry.Hold.Fetch(),
+	).Exec(ctx)
+	if err != nil && !db.IsErrNotFound(err) {
+		problem.Internal(c, "Failed to fetch waitlist entry", err)
+		return
+	}
+	if err == nil {
+		if existing.Status == db.WaitlistStatusWaiting {
+			problem.Respond(c, http.StatusConflict, "already_on_waitlist", "Already on the waitlist",
+				"leave the waitlist first to change the entry")
+			return
+		}
+		if hold, ok := existing.Hold(); ok && hold.Status != db.HoldStatusReleased && hold.Status != db.HoldStatusExpired {
+			problem.Respond(c, http.StatusConflict, "already_promoted", "Already promoted",
+				"seats are held for you with hold "+hold.ID)
+			return
+		}
+		if _, err := wc.dbService.GetClient().WaitlistEntry.FindUnique(
+			db.WaitlistEntry.ID.Equals(existing.ID),
+		).Delete().Exec(ctx); err != nil && !db.IsErrNotFound(err) {
+			problem.Internal(c, "Failed to join waitlist", err)
+			return
+		}
+	}
+
+	var optional []db.WaitlistEntrySetParam
+	optional = append(optional, db.WaitlistEntry.Quantity.Set(req.Quantity))
+	if ticketType != nil {
+		optional = append(optional, db.WaitlistEntry.TicketType.Link(db.TicketType.ID.Equals(ticketType.ID)))
+	}
+	entry, err := wc.dbService.GetClient().WaitlistEntry.CreateOne(
+		db.WaitlistEntry.UserID.Set(userID),
+		db.WaitlistEntry.Event.Link(db.Event.ID.Equals(eventID)),
+		optional...,
+	).Exec(ctx)
+	if err != nil {
+		if _, ok := db.IsErrUniqueConstraint(err); ok {
+			problem.Respond(c, http.StatusConflict, "already_on_waitlist", "Already on the waitlist",
+				"leave the waitlist first to change the entry")
+			return
+		}
+		problem.Internal(c, "Failed to join waitlist", err)
+		return
+	}
+
+	// Seats may have been released since they were counted above, with
+	// nobody waiting to take them
+	if entry, err = wc.promote(c, entry); err != nil {
+		problem.Internal(c, "Failed to fetch waitlist entry", err)
+		return
+	}
+
+	result, err := wc.position(c, entry)
+	if err != nil {
+		problem.Internal(c, "Failed to compute waitlist position", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// promote promotes the waitlist of the entry's event and returns the entry as
+// it is afterwards. Failed promotions are logged, as the entry was created.
+func (wc *Controller) promote(c *gin.Context, entry *db.WaitlistEntryModel) (*db.WaitlistEntryModel, error) {
+	promotions, err := wc.promoter.Promote(c.Request.Context(), entry.EventID)
+	if err != nil {
+		wc.logger.Errorf("Failed to promote waitlist of event %s: %v", entry.EventID, err)
+	}
+	for _, promotion := range promotions {
+		if promotion.EntryID == entry.ID {
+			return wc.dbService.GetClient().WaitlistEntry.FindUnique(
+				db.WaitlistEntry.ID.Equals(entry.ID),
+			).Exec(c.Request.Context())
+		}
+	}
+	return entry, nil
+}
+
+// GetWaitlistPosition godoc
+// @Summary      Get your waitlist position
+// @Description  Returns the caller's waitlist entry with its 1-based position and the number of entries ahead of it.
+// @Description  Promoted entries carry the holdId of the seats held for the caller.
+// @Tags         waitlist
+// @Produce      json
+// @Param        id   path      string  true  "Event ID"
+// @Success      200  {object}  EntryPosition
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /events/{id}/waitlist/position [get]
+func (wc *Controller) GetWaitlistPosition(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	entry, ok := wc.findEntry(c, c.Param("id"), userID)
+	if !ok {
+		return
+	}
+
+	result, err := wc.position(c, entry)
+	if err != nil {
+		problem.Internal(c, "Failed to compute waitlist position", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// LeaveWaitlist godoc
+// @Summary      Leave an event's waitlist
+// @Description  Removes the caller's waitlist entry. Seats already held after a promotion are kept until the hold
+// @Description  is confirmed, released or expires.
+// @Tags         waitlist
+// @Param        id   path  string  true  "Event ID"
+// @Success      204
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /events/{id}/waitlist [delete]
+func (wc *Controller) LeaveWaitlist(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	entry, ok := wc.findEntry(c, c.Param("id"), userID)
+	if !ok {
+		return
+	}
+
+	if _, err := wc.dbService.GetClient().WaitlistEntry.FindUnique(
+		db.WaitlistEntry.ID.Equals(entry.ID),
+	).Delete().Exec(c.Request.Context()); err != nil {
+		if db.IsErrNotFound(err) {
+			problem.Respond(c, http.StatusNotFound, "waitlist_entry_not_found", "Not on the waitlist", "")
+			return
+		}
+		problem.Internal(c, "Failed to leave waitlist", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
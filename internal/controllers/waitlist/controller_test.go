@@ -0,0 +1,108 @@
+package waitlist
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+func TestSeatsLeft(t *testing.T) {
+	var event db.EventModel
+	event.InnerEvent.Capacity = 100
+	event.InnerEvent.SeatsSold = 90
+	event.InnerEvent.SeatsHeld = 8
+
+	if left := seatsLeft(&event, nil); left != 2 {
+		t.Errorf("expected 2 seats left for the event, got %d", left)
+	}
+
+	var ticketType db.TicketTypeModel
+	ticketType.InnerTicketType.Quota = 20
+	ticketType.InnerTicketType.Sold = 19
+	if left := seatsLeft(&event, &ticketType); left != 1 {
+		t.Errorf("expected the ticket type to limit the seats left to 1, got %d", left)
+	}
+
+	event.InnerEvent.SeatsHeld = 15
+	if left := seatsLeft(&event, nil); left != 0 {
+		t.Errorf("expected no seats left after a capacity reduction, got %d", left)
+	}
+}
+
+func TestSaleOpen(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+
+	var open, notYet, ended db.TicketTypeModel
+	notYet.InnerTicketType.SaleStartsAt = &after
+	ended.InnerTicketType.SaleEndsAt = &before
+
+	if !saleOpen(&open, now) {
+		t.Error("expected a ticket type without a sale window to be on sale")
+	}
+	if saleOpen(&notYet, now) {
+		t.Error("expected a ticket type whose sale has not started not to be on sale")
+	}
+	if saleOpen(&ended, now) {
+		t.Error("expected a ticket type whose sale has ended not to be on sale")
+	}
+}
+
+func TestJoinWaitlist_InvalidPayload_Returns400(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	wc := &Controller{}
+	r := gin.New()
+	r.POST("/events/:id/waitlist", wc.JoinWaitlist)
+
+	for _, body := range []string{
+		`{"quantity":-1}`,
+		`{"quantity":11}`,
+		`{"quantity":"two"}`,
+	} {
+		req := httptest.NewRequest("POST", "/events/abc/waitlist", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d. body=%s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestWaitlist_WithoutUser_Returns401(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	wc := &Controller{}
+	r := gin.New()
+	r.POST("/events/:id/waitlist", wc.JoinWaitlist)
+	r.DELETE("/events/:id/waitlist", wc.LeaveWaitlist)
+	r.GET("/events/:id/waitlist/position", wc.GetWaitlistPosition)
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"POST", "/events/abc/waitlist", `{"quantity":2}`},
+		{"DELETE", "/events/abc/waitlist", ""},
+		{"GET", "/events/abc/waitlist/position", ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s: expected status 401, got %d. body=%s", tt.method, tt.path, w.Code, w.Body.String())
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
 )
 
 // UserContextKey is the key used to store user information in the context
@@ -14,6 +15,18 @@ type UserContextKey string
 const (
 	UserIDKey    UserContextKey = "user_id"
 	UserRolesKey UserContextKey = "user_roles"
+	// UserEmailKey holds the user's email address, only if Keycloak has verified it
+	UserEmailKey UserContextKey = "user_email"
+	// UserTenantKey holds the tenant slug from the token's "tenant" claim, if any
+	UserTenantKey UserContextKey = "user_tenant"
+	// UserClientKey holds the Keycloak client the token was issued to (its "azp" claim)
+	UserClientKey UserContextKey = "user_client"
+)
+
+// Keycloak realm roles used for authorization decisions
+const (
+	RoleOrganiser = "Organiser"
+	RoleAdmin     = "Admin"
 )
 
 // AuthMiddleware - LEGACY: Supabase wurde entfernt
@@ -24,28 +37,19 @@ func AuthMiddleware() gin.HandlerFunc {
 		tokenStr := c.GetHeader("Authorization")
 
 		if tokenStr == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    http.StatusUnauthorized,
-				"message": "Authorization header required",
-			})
+			problem.Respond(c, http.StatusUnauthorized, "missing_token", "Authorization header required", "")
 			return
 		}
 
 		parts := strings.Split(tokenStr, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    http.StatusUnauthorized,
-				"message": "Invalid authorization format",
-			})
+			problem.Respond(c, http.StatusUnauthorized, "invalid_authorization_header", "Invalid authorization format", "")
 			return
 		}
 
 		token := parts[1]
 		if token == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    http.StatusUnauthorized,
-				"message": "Empty token",
-			})
+			problem.Respond(c, http.StatusUnauthorized, "missing_token", "Empty token", "")
 			return
 		}
 
@@ -67,4 +71,33 @@ func GetUserIDFromContext(c *gin.Context) (string, bool) {
 func GetUserRolesFromContext(c *gin.Context) ([]string, bool) {
 	roles, exists := c.Request.Context().Value(UserRolesKey).([]string)
 	return roles, exists
-}
\ No newline at end of file
+}
+
+// GetUserEmailFromContext extracts the user's verified email address from the request context
+func GetUserEmailFromContext(c *gin.Context) (string, bool) {
+	email, exists := c.Request.Context().Value(UserEmailKey).(string)
+	return email, exists && email != ""
+}
+
+// GetUserTenantFromContext extracts the slug of the tenant the user's token was issued for
+func GetUserTenantFromContext(c *gin.Context) (string, bool) {
+	slug, exists := c.Request.Context().Value(UserTenantKey).(string)
+	return slug, exists && slug != ""
+}
+
+// GetUserClientFromContext extracts the ID of the Keycloak client the user's token was issued to
+func GetUserClientFromContext(c *gin.Context) (string, bool) {
+	clientID, exists := c.Request.Context().Value(UserClientKey).(string)
+	return clientID, exists && clientID != ""
+}
+
+// HasRole reports whether the authenticated user holds the given realm role
+func HasRole(c *gin.Context, role string) bool {
+	roles, _ := GetUserRolesFromContext(c)
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -114,4 +115,22 @@ func TestGetUserIDFromContext(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, resp.Code)
 	assert.Contains(t, resp.Body.String(), "userID")
-}
\ No newline at end of file
+}
+// =============================================================================
+// TEST: GetUserEmailFromContext
+// =============================================================================
+
+func TestGetUserEmailFromContext(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/user", nil)
+
+	_, exists := GetUserEmailFromContext(c)
+	assert.False(t, exists)
+
+	ctx := context.WithValue(c.Request.Context(), UserEmailKey, "student@example.com")
+	c.Request = c.Request.WithContext(ctx)
+
+	email, exists := GetUserEmailFromContext(c)
+	assert.True(t, exists)
+	assert.Equal(t, "student@example.com", email)
+}
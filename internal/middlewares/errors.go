@@ -6,6 +6,7 @@ import (
 	"runtime/debug"
 
 	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
 )
 
 // ErrorHandle is a middleware to handle panic
@@ -20,14 +21,9 @@ func ErrorHandle() gin.HandlerFunc {
 				// print stack trace
 				debug.PrintStack()
 
-				// return error response
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"code":    http.StatusInternalServerError,
-					"message": "Internal Server Error",
-				})
-
-				// abort request
-				c.Abort()
+				// return error response and abort request
+				problem.Respond(c, http.StatusInternalServerError, problem.CodeInternal, "Internal Server Error",
+					"quote trace id "+problem.TraceID(c)+" when reporting this error")
 			}
 		}()
 
@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
 	"github.com/oskargbc/dws-event-service.git/internal/pkg/utils"
 	"net/http"
 	"strings"
@@ -14,15 +15,13 @@ func Jwt() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		tokenStr := ctx.GetHeader("Authorization")
 		if tokenStr == "" {
-			ctx.Abort()
-			ctx.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": "Not Authorized"})
+			problem.Respond(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "Not Authorized", "")
 			return
 		}
 		parts := strings.Split(tokenStr, " ")
 
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			ctx.Abort()
-			ctx.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": "Not Authorized"})
+			problem.Respond(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "Not Authorized", "")
 			return
 		}
 
@@ -30,8 +29,7 @@ func Jwt() gin.HandlerFunc {
 		claims, err := utils.JwtVerify(token)
 
 		if err != nil || claims == nil {
-			ctx.Abort()
-			ctx.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": "Not Authorized"})
+			problem.Respond(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "Not Authorized", "")
 			return
 		}
 
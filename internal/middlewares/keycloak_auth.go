@@ -18,6 +18,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/oskargbc/dws-event-service.git/configs"
 	"github.com/oskargbc/dws-event-service.git/internal/pkg/logger"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
 
 	"github.com/gin-gonic/gin"
 )
@@ -31,8 +32,11 @@ type RealmAccess struct {
 // Additional fields (like realm_access / resource_access) can be added later if needed.
 type KeycloakClaims struct {
 	jwt.RegisteredClaims
-	AZP         string      `json:"azp,omitempty"`          // Authorized party - the client ID that issued the token
-	RealmAccess RealmAccess `json:"realm_access,omitempty"` // Realm roles assigned to the user
+	AZP           string      `json:"azp,omitempty"`            // Authorized party - the client ID that issued the token
+	RealmAccess   RealmAccess `json:"realm_access,omitempty"`   // Realm roles assigned to the user
+	Email         string      `json:"email,omitempty"`          // Email address from the user's Keycloak profile
+	EmailVerified bool        `json:"email_verified,omitempty"` // Whether Keycloak has verified the email address
+	Tenant        string      `json:"tenant,omitempty"`         // Tenant slug, added by a user attribute mapper
 }
 
 // jwks represents a JSON Web Key Set as returned by Keycloak's /certs endpoint.
@@ -185,30 +189,21 @@ func KeycloakAuthMiddleware() gin.HandlerFunc {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			log.Debug("Keycloak auth: Authorization header missing")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    http.StatusUnauthorized,
-				"message": "Authorization header required",
-			})
+			problem.Respond(c, http.StatusUnauthorized, "missing_token", "Authorization header required", "")
 			return
 		}
 
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
 			log.Debugf("Keycloak auth: Invalid authorization format, parts: %v", parts)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    http.StatusUnauthorized,
-				"message": "Invalid authorization format",
-			})
+			problem.Respond(c, http.StatusUnauthorized, "invalid_authorization_header", "Invalid authorization format", "")
 			return
 		}
 
 		rawToken := strings.TrimSpace(parts[1])
 		if rawToken == "" {
 			log.Debug("Keycloak auth: Empty bearer token")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    http.StatusUnauthorized,
-				"message": "Empty bearer token",
-			})
+			problem.Respond(c, http.StatusUnauthorized, "missing_token", "Empty bearer token", "")
 			return
 		}
 
@@ -228,29 +223,19 @@ func KeycloakAuthMiddleware() gin.HandlerFunc {
 		})
 		if err != nil {
 			log.Errorf("Keycloak auth: Failed to parse token: %v", err)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    http.StatusUnauthorized,
-				"message": "Invalid token",
-				"details": err.Error(),
-			})
+			problem.Respond(c, http.StatusUnauthorized, "invalid_token", "Invalid token", err.Error())
 			return
 		}
 		if !token.Valid {
 			log.Debug("Keycloak auth: Token is not valid")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    http.StatusUnauthorized,
-				"message": "Invalid token",
-			})
+			problem.Respond(c, http.StatusUnauthorized, "invalid_token", "Invalid token", "")
 			return
 		}
 
 		claims, ok := token.Claims.(*KeycloakClaims)
 		if !ok {
 			log.Debug("Keycloak auth: Failed to extract claims")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    http.StatusUnauthorized,
-				"message": "Invalid token claims",
-			})
+			problem.Respond(c, http.StatusUnauthorized, "invalid_token", "Invalid token claims", "")
 			return
 		}
 
@@ -261,28 +246,19 @@ func KeycloakAuthMiddleware() gin.HandlerFunc {
 		now := time.Now()
 		if claims.ExpiresAt != nil && !claims.ExpiresAt.After(now) {
 			log.Debugf("Keycloak auth: Token expired at %v, now: %v", claims.ExpiresAt, now)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    http.StatusUnauthorized,
-				"message": "Token is expired",
-			})
+			problem.Respond(c, http.StatusUnauthorized, "token_expired", "Token is expired", "")
 			return
 		}
 		if claims.NotBefore != nil && claims.NotBefore.After(now) {
 			log.Debugf("Keycloak auth: Token not valid until %v, now: %v", claims.NotBefore, now)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    http.StatusUnauthorized,
-				"message": "Token is not yet valid",
-			})
+			problem.Respond(c, http.StatusUnauthorized, "token_not_yet_valid", "Token is not yet valid", "")
 			return
 		}
 
 		if issuer != "" && claims.Issuer != "" && claims.Issuer != issuer {
 			log.Debugf("Keycloak auth: Issuer mismatch - expected: %s, got: %s", issuer, claims.Issuer)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    http.StatusUnauthorized,
-				"message": "Invalid token issuer",
-				"details": fmt.Sprintf("expected: %s, got: %s", issuer, claims.Issuer),
-			})
+			problem.Respond(c, http.StatusUnauthorized, "invalid_token_issuer", "Invalid token issuer",
+				fmt.Sprintf("expected: %s, got: %s", issuer, claims.Issuer))
 			return
 		}
 
@@ -317,11 +293,8 @@ func KeycloakAuthMiddleware() gin.HandlerFunc {
 			if !audienceMatch {
 				log.Debugf("Keycloak auth: Audience validation failed - expected: %s, got audiences: %v",
 					audience, claims.Audience)
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-					"code":    http.StatusUnauthorized,
-					"message": "Invalid token audience",
-					"details": fmt.Sprintf("expected: %s, got: %v", audience, claims.Audience),
-				})
+				problem.Respond(c, http.StatusUnauthorized, "invalid_token_audience", "Invalid token audience",
+					fmt.Sprintf("expected: %s, got: %v", audience, claims.Audience))
 				return
 			}
 		}
@@ -329,20 +302,29 @@ func KeycloakAuthMiddleware() gin.HandlerFunc {
 		subject := claims.Subject
 		if subject == "" {
 			log.Debug("Keycloak auth: Token subject (sub) is missing")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    http.StatusUnauthorized,
-				"message": "Token subject (sub) is missing",
-			})
+			problem.Respond(c, http.StatusUnauthorized, "invalid_token", "Token subject (sub) is missing", "")
 			return
 		}
 
 		log.Debugf("Keycloak auth: Token validated successfully for subject: %s", subject)
 
-		// Store user ID and roles in context so handlers can retrieve them.
+		// Store user ID, roles and verified email in context so handlers can retrieve them.
 		ctx := context.WithValue(c.Request.Context(), UserIDKey, subject)
 		if len(claims.RealmAccess.Roles) > 0 {
 			ctx = context.WithValue(ctx, UserRolesKey, claims.RealmAccess.Roles)
 		}
+		// Only verified addresses may be used to claim organizer profiles
+		if claims.Email != "" && claims.EmailVerified {
+			ctx = context.WithValue(ctx, UserEmailKey, claims.Email)
+		}
+		// Users of one university or city must not act on another tenant's data
+		if claims.Tenant != "" {
+			ctx = context.WithValue(ctx, UserTenantKey, claims.Tenant)
+		}
+		// Lets handlers apply rules of particular clients, e.g. the organiser dashboard
+		if claims.AZP != "" {
+			ctx = context.WithValue(ctx, UserClientKey, claims.AZP)
+		}
 		c.Request = c.Request.WithContext(ctx)
 
 		c.Next()
@@ -358,10 +340,7 @@ func RequireRole(requiredRole string) gin.HandlerFunc {
 		roles, ok := c.Request.Context().Value(UserRolesKey).([]string)
 		if !ok || len(roles) == 0 {
 			log.Debug("RequireRole: no roles found in context")
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-				"code":    http.StatusForbidden,
-				"message": "Forbidden: missing required role",
-			})
+			problem.Respond(c, http.StatusForbidden, "missing_role", "Forbidden: missing required role", "")
 			return
 		}
 
@@ -374,9 +353,6 @@ func RequireRole(requiredRole string) gin.HandlerFunc {
 		}
 
 		log.Debugf("RequireRole: user roles %v do not include required role %s", roles, requiredRole)
-		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-			"code":    http.StatusForbidden,
-			"message": "Forbidden: missing required role",
-		})
+		problem.Respond(c, http.StatusForbidden, "missing_role", "Forbidden: missing required role", "")
 	}
 }
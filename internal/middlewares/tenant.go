@@ -0,0 +1,125 @@
+package middlewares
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/logger"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// TenantKey holds the *db.TenantModel the request was resolved to
+const TenantKey UserContextKey = "tenant"
+
+// DefaultTenantHeader carries the tenant slug when tenancy.header is not configured
+const DefaultTenantHeader = "X-Tenant"
+
+// TenantStore looks up tenants for ResolveTenant. Both methods return an error
+// matched by db.IsErrNotFound when no tenant matches.
+type TenantStore interface {
+	FindTenantBySlug(ctx context.Context, slug string) (*db.TenantModel, error)
+	FindTenantByDomain(ctx context.Context, host string) (*db.TenantModel, error)
+}
+
+// ResolveTenant determines the tenant of the request and stores it in the
+// context under TenantKey. The tenant is taken from the tenant header, else
+// from the Host header, else from the token's tenant claim, else from the
+// configured default tenant. Tokens issued for another tenant are rejected
+// with 403, as are tokens without a tenant claim used with a tenant other than
+// the default, unless their client is one of tenancy.cross_tenant_clients. On
+// authenticated routes it must run after KeycloakAuthMiddleware.
+func ResolveTenant(store TenantStore, tenancy configs.Tenancy) gin.HandlerFunc {
+	header := tenancy.Header
+	if header == "" {
+		header = DefaultTenantHeader
+	}
+	crossTenantClients := map[string]bool{}
+	for _, clientID := range tenancy.CrossTenantClients {
+		crossTenantClients[clientID] = true
+	}
+
+	return func(c *gin.Context) {
+		log := logger.NewLogrusLogger()
+		ctx := c.Request.Context()
+		claim, _ := GetUserTenantFromContext(c)
+
+		var (
+			tenant *db.TenantModel
+			err    error
+		)
+		if slug := strings.TrimSpace(c.GetHeader(header)); slug != "" {
+			tenant, err = store.FindTenantBySlug(ctx, slug)
+		} else {
+			err = db.ErrNotFound
+			if host := requestHost(c.Request); host != "" {
+				tenant, err = store.FindTenantByDomain(ctx, host)
+			}
+			if db.IsErrNotFound(err) {
+				slug := claim
+				if slug == "" {
+					slug = tenancy.DefaultTenant
+				}
+				if slug == "" {
+					problem.Respond(c, http.StatusBadRequest, "tenant_required", "Tenant required", "set the "+header+" header")
+					return
+				}
+				tenant, err = store.FindTenantBySlug(ctx, slug)
+			}
+		}
+		if err != nil {
+			if db.IsErrNotFound(err) {
+				problem.Respond(c, http.StatusNotFound, "tenant_not_found", "Tenant not found", "")
+				return
+			}
+			problem.Internal(c, "Failed to resolve tenant", err)
+			return
+		}
+
+		if claim != "" && claim != tenant.Slug {
+			log.Debugf("ResolveTenant: token tenant %s does not match requested tenant %s", claim, tenant.Slug)
+			problem.Respond(c, http.StatusForbidden, "tenant_mismatch", "Forbidden: token is not valid for this tenant", "")
+			return
+		}
+		userID, _ := GetUserIDFromContext(c)
+		clientID, _ := GetUserClientFromContext(c)
+		if claim == "" && userID != "" && tenant.Slug != tenancy.DefaultTenant && !crossTenantClients[clientID] {
+			log.Debugf("ResolveTenant: token of client %q without tenant claim used for tenant %s", clientID, tenant.Slug)
+			problem.Respond(c, http.StatusForbidden, "tenant_mismatch", "Forbidden: token is not valid for this tenant",
+				"the token carries no tenant claim")
+			return
+		}
+
+		c.Request = c.Request.WithContext(context.WithValue(ctx, TenantKey, tenant))
+		c.Next()
+	}
+}
+
+// requestHost returns the lower-cased hostname of the request without its port
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+// GetTenantFromContext extracts the tenant resolved by ResolveTenant from the request context
+func GetTenantFromContext(c *gin.Context) (*db.TenantModel, bool) {
+	tenant, exists := c.Request.Context().Value(TenantKey).(*db.TenantModel)
+	return tenant, exists && tenant != nil
+}
+
+// GetTenantIDFromContext extracts the ID of the request's tenant. Without a
+// tenant it returns "", which matches no records.
+func GetTenantIDFromContext(c *gin.Context) (string, bool) {
+	tenant, ok := GetTenantFromContext(c)
+	if !ok {
+		return "", false
+	}
+	return tenant.ID, true
+}
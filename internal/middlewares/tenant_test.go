@@ -0,0 +1,168 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTenantStore serves tenants from memory
+type fakeTenantStore []db.TenantModel
+
+func (s fakeTenantStore) FindTenantBySlug(ctx context.Context, slug string) (*db.TenantModel, error) {
+	for i := range s {
+		if s[i].Slug == slug {
+			return &s[i], nil
+		}
+	}
+	return nil, db.ErrNotFound
+}
+
+func (s fakeTenantStore) FindTenantByDomain(ctx context.Context, host string) (*db.TenantModel, error) {
+	for i := range s {
+		for _, domain := range s[i].Domains {
+			if domain == host {
+				return &s[i], nil
+			}
+		}
+	}
+	return nil, db.ErrNotFound
+}
+
+func newTenant(id, slug string, domains ...string) db.TenantModel {
+	var tenant db.TenantModel
+	tenant.InnerTenant.ID = id
+	tenant.InnerTenant.Slug = slug
+	tenant.InnerTenant.Domains = domains
+	return tenant
+}
+
+// =============================================================================
+// TEST: ResolveTenant
+// =============================================================================
+
+func TestResolveTenant(t *testing.T) {
+	store := fakeTenantStore{
+		newTenant("t-default", "default"),
+		newTenant("t-ltu", "ltu", "events.ltu.se"),
+		newTenant("t-umu", "umu", "events.umu.se"),
+	}
+
+	tests := []struct {
+		name       string
+		tenancy    configs.Tenancy
+		host       string
+		header     string
+		claim      string
+		wantStatus int
+		wantTenant string
+	}{
+		{"header", configs.Tenancy{DefaultTenant: "default"}, "localhost:6906", "umu", "", http.StatusOK, "t-umu"},
+		{"host", configs.Tenancy{DefaultTenant: "default"}, "events.ltu.se", "", "", http.StatusOK, "t-ltu"},
+		{"host with port", configs.Tenancy{}, "EVENTS.LTU.SE:443", "", "", http.StatusOK, "t-ltu"},
+		{"claim", configs.Tenancy{DefaultTenant: "default"}, "localhost", "", "umu", http.StatusOK, "t-umu"},
+		{"default", configs.Tenancy{DefaultTenant: "default"}, "localhost", "", "", http.StatusOK, "t-default"},
+		{"no default", configs.Tenancy{}, "localhost", "", "", http.StatusBadRequest, ""},
+		{"unknown header", configs.Tenancy{DefaultTenant: "default"}, "localhost", "kth", "", http.StatusNotFound, ""},
+		{"claim for other tenant", configs.Tenancy{}, "events.ltu.se", "", "umu", http.StatusForbidden, ""},
+		{"header for other tenant", configs.Tenancy{}, "localhost", "ltu", "umu", http.StatusForbidden, ""},
+		{"custom header", configs.Tenancy{Header: "X-University"}, "localhost", "ltu", "ltu", http.StatusOK, "t-ltu"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.claim != "" {
+					ctx := context.WithValue(c.Request.Context(), UserTenantKey, tt.claim)
+					c.Request = c.Request.WithContext(ctx)
+				}
+				c.Next()
+			})
+			router.Use(ResolveTenant(store, tt.tenancy))
+			router.GET("/events", func(c *gin.Context) {
+				tenantID, _ := GetTenantIDFromContext(c)
+				c.String(http.StatusOK, tenantID)
+			})
+
+			req := httptest.NewRequest("GET", "/events", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				headerName := tt.tenancy.Header
+				if headerName == "" {
+					headerName = DefaultTenantHeader
+				}
+				req.Header.Set(headerName, tt.header)
+			}
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantStatus, resp.Code, resp.Body.String())
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.wantTenant, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestResolveTenant_TokenWithoutClaim(t *testing.T) {
+	store := fakeTenantStore{
+		newTenant("t-default", "default"),
+		newTenant("t-umu", "umu", "events.umu.se"),
+	}
+	tenancy := configs.Tenancy{DefaultTenant: "default", CrossTenantClients: []string{"admin-cli"}}
+
+	tests := []struct {
+		name       string
+		client     string
+		host       string
+		header     string
+		wantStatus int
+	}{
+		{"foreign header", "dashboard", "localhost", "umu", http.StatusForbidden},
+		{"foreign host", "dashboard", "events.umu.se", "", http.StatusForbidden},
+		{"default tenant", "dashboard", "localhost", "default", http.StatusOK},
+		{"cross-tenant client", "admin-cli", "localhost", "umu", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				ctx := context.WithValue(c.Request.Context(), UserIDKey, "admin")
+				ctx = context.WithValue(ctx, UserClientKey, tt.client)
+				c.Request = c.Request.WithContext(ctx)
+				c.Next()
+			})
+			router.Use(ResolveTenant(store, tenancy))
+			router.GET("/events", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest("GET", "/events", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set(DefaultTenantHeader, tt.header)
+			}
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantStatus, resp.Code, resp.Body.String())
+		})
+	}
+}
+
+func TestGetTenantIDFromContext_WithoutTenant(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/events", nil)
+
+	tenantID, exists := GetTenantIDFromContext(c)
+	assert.False(t, exists)
+	assert.Empty(t, tenantID)
+}
@@ -0,0 +1,16 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+)
+
+// TraceID assigns every request a trace id, taken from the traceparent or
+// X-Request-ID header when the client or gateway sent one. The id is returned
+// in the X-Request-ID response header and in every problem response.
+func TraceID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(problem.TraceIDHeader, problem.TraceID(c))
+		c.Next()
+	}
+}
@@ -0,0 +1,178 @@
+// Package cache provides an in-memory cache whose entries expire after a TTL,
+// whose size is bounded by evicting the least recently used entries and which
+// shares one load between concurrent misses of the same key
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errLoadPanicked is returned to the callers waiting for a load that panicked
+var errLoadPanicked = errors.New("cache: load panicked")
+
+// Cache maps string keys to values of type V. It is safe for concurrent use.
+type Cache[V any] struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu sync.Mutex
+	// order holds the entries, most recently used first
+	order   *list.List
+	entries map[string]*list.Element
+	calls   map[string]*call[V]
+	// generation counts the deletions, so loads that started before one do
+	// not store what they read
+	generation uint64
+}
+
+type entry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// call is a load in progress; done is closed once value and err are set
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// New creates a cache keeping entries for ttl and at most maxEntries of them;
+// maxEntries <= 0 leaves the size unbounded
+func New[V any](ttl time.Duration, maxEntries int) *Cache[V] {
+	return &Cache[V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		order:      list.New(),
+		entries:    map[string]*list.Element{},
+		calls:      map[string]*call[V]{},
+	}
+}
+
+// Get returns the value cached for key, if it has not expired
+func (c *Cache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+// Set caches value for key
+func (c *Cache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value)
+}
+
+// Do returns the value cached for key, or loads and caches it. Concurrent
+// calls for the same missing key wait for the first one's load instead of
+// loading it again. Errors are returned to every waiting caller and are not
+// cached.
+func (c *Cache[V]) Do(key string, load func() (V, error)) (V, error) {
+	c.mu.Lock()
+	if value, ok := c.get(key); ok {
+		c.mu.Unlock()
+		return value, nil
+	}
+	if cl, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		<-cl.done
+		return cl.value, cl.err
+	}
+	cl := &call[V]{done: make(chan struct{}), err: errLoadPanicked}
+	c.calls[key] = cl
+	generation := c.generation
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		if c.calls[key] == cl {
+			delete(c.calls, key)
+		}
+		if cl.err == nil && c.generation == generation {
+			c.set(key, cl.value)
+		}
+		c.mu.Unlock()
+		close(cl.done)
+	}()
+	cl.value, cl.err = load()
+	return cl.value, cl.err
+}
+
+// Delete removes the entry of key. Loads of it that are in progress are not
+// cached, and later calls to Do load it again.
+func (c *Cache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	delete(c.calls, key)
+}
+
+// DeletePrefix removes the entries of every key starting with prefix, like
+// Delete
+func (c *Cache[V]) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for key, element := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(element)
+		}
+	}
+	for key := range c.calls {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.calls, key)
+		}
+	}
+}
+
+// Len returns the number of cached entries, including expired ones not yet
+// evicted
+func (c *Cache[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *Cache[V]) get(key string) (V, bool) {
+	var zero V
+	element, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	e := element.Value.(*entry[V])
+	if !c.now().Before(e.expiresAt) {
+		c.remove(element)
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	return e.value, true
+}
+
+func (c *Cache[V]) set(key string, value V) {
+	expiresAt := c.now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		e := element.Value.(*entry[V])
+		e.value, e.expiresAt = value, expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&entry[V]{key: key, value: value, expiresAt: expiresAt})
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *Cache[V]) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*entry[V]).key)
+}
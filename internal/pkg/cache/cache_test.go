@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Expiry(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	c := New[string](time.Minute, 0)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", "1")
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+
+	now = now.Add(time.Minute)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := New[int](time.Minute, 2)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Set("c", 3)
+
+	_, ok := c.Get("b")
+	assert.False(t, ok, "b was used least recently")
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestCache_DeletePrefix(t *testing.T) {
+	c := New[int](time.Minute, 0)
+	c.Set("t1/a", 1)
+	c.Set("t1/b", 2)
+	c.Set("t2/a", 3)
+
+	c.DeletePrefix("t1/")
+	assert.Equal(t, 1, c.Len())
+	_, ok := c.Get("t2/a")
+	assert.True(t, ok)
+}
+
+func TestCache_DoSharesConcurrentLoads(t *testing.T) {
+	c := New[int](time.Minute, 0)
+	var loads atomic.Int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = c.Do("a", func() (int, error) {
+				loads.Add(1)
+				<-release
+				return 42, nil
+			})
+		}()
+	}
+	// Let the callers queue up behind the first load
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loads.Load())
+	for _, result := range results {
+		assert.Equal(t, 42, result)
+	}
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 42, value)
+}
+
+func TestCache_DoDoesNotCacheErrors(t *testing.T) {
+	c := New[int](time.Minute, 0)
+	_, err := c.Do("a", func() (int, error) { return 0, errors.New("unavailable") })
+	require.Error(t, err)
+
+	value, err := c.Do("a", func() (int, error) { return 1, nil })
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+}
+
+func TestCache_DeleteDuringLoad(t *testing.T) {
+	c := New[int](time.Minute, 0)
+	loading := make(chan struct{})
+	release := make(chan struct{})
+
+	done := make(chan int)
+	go func() {
+		value, _ := c.Do("a", func() (int, error) {
+			close(loading)
+			<-release
+			return 1, nil
+		})
+		done <- value
+	}()
+	<-loading
+	c.Delete("a")
+
+	// A load started after the deletion does not wait for the stale one
+	value, err := c.Do("a", func() (int, error) { return 2, nil })
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	close(release)
+	assert.Equal(t, 1, <-done)
+	value, _ = c.Get("a")
+	assert.Equal(t, 2, value, "the stale load must not replace the fresh value")
+}
@@ -0,0 +1,158 @@
+// Package eventchange announces writes to events, so that what this and every
+// other replica caches of them can be dropped
+package eventchange
+
+import (
+	"sync"
+
+	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/logger"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/rabbitmq"
+	"github.com/oskargbc/dws-event-service.git/internal/services"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// UpdatedRoutingKey is the routing key of the message published for
+	// every write to an event
+	UpdatedRoutingKey = "event.updated"
+	// DeletedRoutingKey is the routing key of messages announcing that an
+	// event was deleted; they are consumed like event.updated
+	DeletedRoutingKey = "event.deleted"
+)
+
+// Change is the data of event.updated and event.deleted messages. Without an
+// event ID any event of the tenant may have changed, e.g. when one of its
+// organizers or categories was edited; without a tenant ID any tenant's.
+type Change struct {
+	EventID  string `json:"eventId,omitempty"`
+	TenantID string `json:"tenantId,omitempty"`
+}
+
+// Publisher hands changes to the listeners of this process and publishes
+// them to the other replicas
+type Publisher struct {
+	rabbitmqService *services.RabbitMQService
+	exchange        string
+	source          string
+	logger          *logrus.Logger
+
+	mu         sync.RWMutex
+	listeners  []func(Change)
+	subscribed bool
+}
+
+var (
+	defaultPublisher *Publisher
+	defaultOnce      sync.Once
+)
+
+// Default returns the publisher of this process. It publishes over RabbitMQ
+// if enabled.
+func Default() *Publisher {
+	defaultOnce.Do(func() {
+		envConfig := configs.GetEnvConfig()
+		var rabbitmqService *services.RabbitMQService
+		if envConfig.RabbitMQ.Enabled {
+			rabbitmqService = services.GetRabbitMQServiceInstance()
+		}
+		defaultPublisher = New(rabbitmqService, envConfig.RabbitMQ.Exchange, envConfig.Service.Slug)
+	})
+	return defaultPublisher
+}
+
+// New creates a publisher. Without a RabbitMQ service changes only reach the
+// listeners of this process.
+func New(rabbitmqService *services.RabbitMQService, exchange, source string) *Publisher {
+	if exchange == "" {
+		exchange = rabbitmq.DefaultExchange
+	}
+	return &Publisher{
+		rabbitmqService: rabbitmqService,
+		exchange:        exchange,
+		source:          source,
+		logger:          logger.NewLogrusLogger(),
+	}
+}
+
+// OnChange registers a function that is called for every change announced by
+// this process or, once subscribed, by any other replica
+func (p *Publisher) OnChange(fn func(Change)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.listeners = append(p.listeners, fn)
+}
+
+// Announce reports writes to the events of the tenant. Publishing failures
+// are logged, as the writes have already been committed. A nil publisher
+// announces nothing.
+func (p *Publisher) Announce(tenantID string, eventIDs ...string) {
+	if p == nil {
+		return
+	}
+	for _, eventID := range eventIDs {
+		p.announce(Change{EventID: eventID, TenantID: tenantID})
+	}
+}
+
+// AnnounceTenant reports a write that may show in any event of the tenant
+func (p *Publisher) AnnounceTenant(tenantID string) {
+	if p == nil {
+		return
+	}
+	p.announce(Change{TenantID: tenantID})
+}
+
+func (p *Publisher) announce(change Change) {
+	p.notify(change)
+	if p.rabbitmqService == nil {
+		return
+	}
+	msg := rabbitmq.NewEventMessage(UpdatedRoutingKey, change.EventID, p.source, change)
+	if err := p.rabbitmqService.PublishJSON(p.exchange, UpdatedRoutingKey, msg); err != nil {
+		p.logger.Errorf("Failed to publish %s for event %q of tenant %q: %v",
+			UpdatedRoutingKey, change.EventID, change.TenantID, err)
+	}
+}
+
+func (p *Publisher) notify(change Change) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, fn := range p.listeners {
+		fn(change)
+	}
+}
+
+// handle passes an event.updated or event.deleted message on to the
+// listeners. This replica's own messages arrive as well, which is harmless.
+func (p *Publisher) handle(msg *rabbitmq.EventMessage) error {
+	var change Change
+	if data, ok := msg.Data.(map[string]interface{}); ok {
+		change.EventID, _ = data["eventId"].(string)
+		change.TenantID, _ = data["tenantId"].(string)
+	}
+	if msg.EventID != "" {
+		change.EventID = msg.EventID
+	}
+	p.notify(change)
+	return nil
+}
+
+// Subscribe consumes the event.updated and event.deleted messages of every
+// replica. Later calls do nothing, as does a publisher without RabbitMQ.
+func (p *Publisher) Subscribe() error {
+	if p.rabbitmqService == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.subscribed {
+		return nil
+	}
+	if err := rabbitmq.SubscribeEvents(p.exchange, []string{UpdatedRoutingKey, DeletedRoutingKey}, "", p.handle); err != nil {
+		return err
+	}
+	p.subscribed = true
+	return nil
+}
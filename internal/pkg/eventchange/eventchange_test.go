@@ -0,0 +1,52 @@
+package eventchange
+
+import (
+	"testing"
+
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/rabbitmq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnounce(t *testing.T) {
+	p := New(nil, "", "event-service")
+	var changes []Change
+	p.OnChange(func(change Change) { changes = append(changes, change) })
+
+	p.Announce("t1", "evt-1", "evt-2")
+	p.AnnounceTenant("t2")
+
+	assert.Equal(t, []Change{
+		{EventID: "evt-1", TenantID: "t1"},
+		{EventID: "evt-2", TenantID: "t1"},
+		{TenantID: "t2"},
+	}, changes)
+
+	// A nil publisher announces nothing, without failing
+	var none *Publisher
+	none.Announce("t1", "evt-1")
+	none.AnnounceTenant("t1")
+}
+
+func TestHandle(t *testing.T) {
+	p := New(nil, "", "event-service")
+	var changes []Change
+	p.OnChange(func(change Change) { changes = append(changes, change) })
+
+	require.NoError(t, p.handle(rabbitmq.NewEventMessage(UpdatedRoutingKey, "evt-1", "event-service",
+		map[string]interface{}{"eventId": "evt-1", "tenantId": "t1"})))
+	// Messages of other services may not name the tenant
+	require.NoError(t, p.handle(rabbitmq.NewEventMessage(DeletedRoutingKey, "evt-2", "ticket-service", nil)))
+	require.NoError(t, p.handle(rabbitmq.NewEventMessage(UpdatedRoutingKey, "", "event-service",
+		map[string]interface{}{"tenantId": "t2"})))
+
+	assert.Equal(t, []Change{
+		{EventID: "evt-1", TenantID: "t1"},
+		{EventID: "evt-2"},
+		{TenantID: "t2"},
+	}, changes)
+}
+
+func TestSubscribe_WithoutRabbitMQ(t *testing.T) {
+	assert.NoError(t, New(nil, "", "event-service").Subscribe())
+}
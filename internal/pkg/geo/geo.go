@@ -0,0 +1,99 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// EarthRadiusKm is the mean Earth radius used for distance calculations
+const EarthRadiusKm = 6371.0
+
+// Point is a WGS84 coordinate in decimal degrees
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Validate checks that the point lies within valid latitude/longitude ranges
+func (p Point) Validate() error {
+	if p.Lat < -90 || p.Lat > 90 {
+		return errors.New("latitude must be between -90 and 90")
+	}
+	if p.Lon < -180 || p.Lon > 180 {
+		return errors.New("longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+// ParsePoint parses a "lat,lon" string such as "65.6170,22.1365"
+func ParsePoint(s string) (Point, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return Point{}, errors.New("point must be in the form lat,lon")
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return Point{}, errors.New("latitude must be a number")
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return Point{}, errors.New("longitude must be a number")
+	}
+
+	p := Point{Lat: lat, Lon: lon}
+	return p, p.Validate()
+}
+
+// DistanceKm returns the great-circle distance between two points using the haversine formula
+func DistanceKm(a, b Point) float64 {
+	lat1 := toRadians(a.Lat)
+	lat2 := toRadians(b.Lat)
+	dLat := lat2 - lat1
+	dLon := toRadians(b.Lon - a.Lon)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BoundingBox is a latitude/longitude rectangle that contains a search circle
+type BoundingBox struct {
+	MinLat, MaxLat float64
+	MinLon, MaxLon float64
+	// WrapsLongitude is set when the box crosses the antimeridian or a pole,
+	// in which case the longitude bounds must not be used as a filter.
+	WrapsLongitude bool
+}
+
+// BoundingBoxAround returns a box that contains every point within radiusKm of
+// center. It is meant as a cheap, index-friendly pre-filter before the exact
+// distance check.
+func BoundingBoxAround(center Point, radiusKm float64) BoundingBox {
+	dLat := radiusKm / EarthRadiusKm * 180 / math.Pi
+	box := BoundingBox{
+		MinLat: center.Lat - dLat,
+		MaxLat: center.Lat + dLat,
+	}
+
+	if box.MinLat <= -90 || box.MaxLat >= 90 {
+		box.MinLat = math.Max(box.MinLat, -90)
+		box.MaxLat = math.Min(box.MaxLat, 90)
+		box.WrapsLongitude = true
+		return box
+	}
+
+	dLon := dLat / math.Cos(toRadians(center.Lat))
+	box.MinLon = center.Lon - dLon
+	box.MaxLon = center.Lon + dLon
+	if box.MinLon < -180 || box.MaxLon > 180 {
+		box.WrapsLongitude = true
+	}
+	return box
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
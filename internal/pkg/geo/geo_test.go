@@ -0,0 +1,47 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	lulea     = Point{Lat: 65.5848, Lon: 22.1547}
+	stockholm = Point{Lat: 59.3293, Lon: 18.0686}
+)
+
+func TestParsePoint(t *testing.T) {
+	p, err := ParsePoint("65.5848, 22.1547")
+	assert.NoError(t, err)
+	assert.Equal(t, lulea, p)
+
+	for _, input := range []string{"", "65.5", "a,b", "91,0", "0,181", "1,2,3"} {
+		_, err := ParsePoint(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	assert.InDelta(t, 0, DistanceKm(lulea, lulea), 1e-9)
+	// Luleå - Stockholm is roughly 725 km as the crow flies
+	assert.InDelta(t, 725, DistanceKm(lulea, stockholm), 5)
+	assert.InDelta(t, DistanceKm(lulea, stockholm), DistanceKm(stockholm, lulea), 1e-9)
+}
+
+func TestBoundingBoxAround(t *testing.T) {
+	box := BoundingBoxAround(lulea, 5)
+
+	assert.False(t, box.WrapsLongitude)
+	assert.Less(t, box.MinLat, lulea.Lat)
+	assert.Greater(t, box.MaxLat, lulea.Lat)
+
+	// A point exactly radius km north must lie inside the box
+	north := Point{Lat: lulea.Lat + 5/EarthRadiusKm*180/math.Pi, Lon: lulea.Lon}
+	assert.InDelta(t, 5, DistanceKm(lulea, north), 0.01)
+	assert.LessOrEqual(t, north.Lat, box.MaxLat+1e-9)
+
+	assert.True(t, BoundingBoxAround(Point{Lat: 0, Lon: 179.99}, 10).WrapsLongitude)
+	assert.True(t, BoundingBoxAround(Point{Lat: 89.99, Lon: 0}, 10).WrapsLongitude)
+}
@@ -0,0 +1,64 @@
+// Package httpcache evaluates the conditional GET requests of RFC 9110 so that
+// unchanged responses can be answered with 304 Not Modified
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Digest returns a short hash of a response body, suitable as the opaque part
+// of an entity tag
+func Digest(parts ...[]byte) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write(part)
+		// Separates the parts, so ("ab", "c") and ("a", "bc") differ
+		h.Write([]byte{0})
+	}
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:12])
+}
+
+// NotModified reports whether the client's copy of a response with the given
+// entity tag and modification time is still current. If-None-Match takes
+// precedence; If-Modified-Since is only used without it, and only when
+// lastModified is known. Only GET and HEAD requests are conditional.
+func NotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etag != "" && matchesWeak(ifNoneMatch, etag)
+	}
+	ifModifiedSince := r.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	// HTTP dates have a resolution of one second
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// matchesWeak reports whether an If-None-Match header lists the entity tag or
+// is "*", using the weak comparison: W/"x" and "x" match
+func matchesWeak(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatTime formats t for the Last-Modified header
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(http.TimeFormat)
+}
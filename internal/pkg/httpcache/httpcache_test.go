@@ -0,0 +1,55 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigest(t *testing.T) {
+	assert.Equal(t, Digest([]byte("abc")), Digest([]byte("abc")))
+	assert.NotEqual(t, Digest([]byte("ab"), []byte("c")), Digest([]byte("a"), []byte("bc")))
+	assert.Len(t, Digest([]byte("abc")), 16)
+}
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2026, 10, 16, 12, 0, 0, 500, time.UTC)
+	etag := `"7-abc"`
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		expect  bool
+	}{
+		{"no validators", "GET", nil, false},
+		{"matching etag", "GET", map[string]string{"If-None-Match": `"6-xyz", "7-abc"`}, true},
+		{"weak etag", "GET", map[string]string{"If-None-Match": `W/"7-abc"`}, true},
+		{"any etag", "GET", map[string]string{"If-None-Match": "*"}, true},
+		{"other etag", "GET", map[string]string{"If-None-Match": `"6-xyz"`}, false},
+		{"etag wins over date", "GET", map[string]string{
+			"If-None-Match":     `"6-xyz"`,
+			"If-Modified-Since": FormatTime(modified),
+		}, false},
+		{"same second", "GET", map[string]string{"If-Modified-Since": FormatTime(modified)}, true},
+		{"later", "GET", map[string]string{"If-Modified-Since": FormatTime(modified.Add(time.Hour))}, true},
+		{"earlier", "GET", map[string]string{"If-Modified-Since": FormatTime(modified.Add(-time.Second))}, false},
+		{"invalid date", "GET", map[string]string{"If-Modified-Since": "yesterday"}, false},
+		{"head", "HEAD", map[string]string{"If-None-Match": etag}, true},
+		{"not a read", "POST", map[string]string{"If-None-Match": etag}, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "/events", nil)
+		for name, value := range tt.headers {
+			r.Header.Set(name, value)
+		}
+		assert.Equal(t, tt.expect, NotModified(r, etag, modified), tt.name)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+	r.Header.Set("If-Modified-Since", FormatTime(modified))
+	assert.False(t, NotModified(r, etag, time.Time{}), "unknown modification time")
+}
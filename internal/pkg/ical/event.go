@@ -0,0 +1,122 @@
+package ical
+
+import (
+	"sort"
+	"time"
+
+	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// DomainFromConfig returns the configured UID domain, falling back to the service slug
+func DomainFromConfig() string {
+	envConfig := configs.GetEnvConfig()
+	if envConfig.Calendar.UIDDomain != "" {
+		return envConfig.Calendar.UIDDomain
+	}
+	return envConfig.Service.Slug
+}
+
+// UID returns the UID an event is exported with. It only depends on the
+// event's ID, so calendar apps replace the old copy of an event when it changes.
+func UID(eventID, domain string) string {
+	return eventID + "@" + domain
+}
+
+// statusOf maps an event's lifecycle state onto a VEVENT status
+func statusOf(status db.EventStatus) Status {
+	switch status {
+	case db.EventStatusCancelled:
+		return StatusCancelled
+	case db.EventStatusPostponed, db.EventStatusDraft:
+		return StatusTentative
+	default:
+		return StatusConfirmed
+	}
+}
+
+// FromEvent converts an event into VEVENTs. A single event yields one VEVENT.
+// A recurring event yields the series, with exception dates and cancelled
+// occurrences as EXDATEs, followed by one VEVENT per changed occurrence that
+// replaces it through its RECURRENCE-ID. The organizer and category are
+// included if the event was loaded with them.
+func FromEvent(event *db.EventModel, overrides []db.EventOccurrenceOverrideModel, domain string) []Event {
+	series := Event{
+		UID:          UID(event.ID, domain),
+		Stamp:        event.UpdatedAt,
+		Created:      event.CreatedAt,
+		LastModified: event.UpdatedAt,
+		Start:        event.StartsAt,
+		End:          event.EndsAt,
+		AllDay:       event.AllDay,
+		Summary:      event.Name,
+		Description:  event.Description,
+		Location:     event.Location,
+		Status:       statusOf(event.Status),
+	}
+	if loc, err := time.LoadLocation(event.Timezone); err == nil {
+		series.Timezone = loc
+	}
+	if lat, ok := event.Latitude(); ok {
+		if lon, ok := event.Longitude(); ok {
+			series.Latitude, series.Longitude = &lat, &lon
+		}
+	}
+	if category := event.RelationsEvent.Category; category != nil {
+		series.Categories = []string{category.Name}
+	}
+	if organizer := event.RelationsEvent.Organizer; organizer != nil {
+		series.Organizer = &Organizer{Name: organizer.Name, Email: organizer.Email}
+	}
+
+	rule, ok := event.RecurrenceRule()
+	if !ok {
+		return []Event{series}
+	}
+	series.RRule = rule
+	series.ExDates = append([]time.Time{}, event.RecurrenceExceptions...)
+
+	sorted := append([]db.EventOccurrenceOverrideModel{}, overrides...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].OriginalStart.Before(sorted[j].OriginalStart)
+	})
+
+	duration := event.EndsAt.Sub(event.StartsAt)
+	var occurrences []Event
+	for _, override := range sorted {
+		if override.Cancelled {
+			series.ExDates = append(series.ExDates, override.OriginalStart)
+			continue
+		}
+
+		occurrence := series
+		occurrence.RRule = ""
+		occurrence.ExDates = nil
+		recurrenceID := override.OriginalStart
+		occurrence.RecurrenceID = &recurrenceID
+		occurrence.Start = recurrenceID
+		occurrence.End = recurrenceID.Add(duration)
+		if v, ok := override.StartsAt(); ok {
+			occurrence.Start = v
+		}
+		if v, ok := override.EndsAt(); ok {
+			occurrence.End = v
+		}
+		if v, ok := override.Name(); ok {
+			occurrence.Summary = v
+		}
+		if v, ok := override.Description(); ok {
+			occurrence.Description = v
+		}
+		if v, ok := override.Location(); ok {
+			occurrence.Location = v
+		}
+		if override.UpdatedAt.After(occurrence.LastModified) {
+			occurrence.Stamp = override.UpdatedAt
+			occurrence.LastModified = override.UpdatedAt
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+
+	return append([]Event{series}, occurrences...)
+}
@@ -0,0 +1,282 @@
+// Package ical writes iCalendar (RFC 5545) documents.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// ProductID identifies this service as the producer of exported calendars
+	ProductID = "-//DWS//Event Service//EN"
+
+	// ContentType is the media type of iCalendar responses
+	ContentType = "text/calendar; charset=utf-8"
+)
+
+// maxLineOctets is the longest content line RFC 5545 allows before folding
+const maxLineOctets = 75
+
+// Status is the STATUS of a VEVENT
+type Status string
+
+const (
+	StatusConfirmed Status = "CONFIRMED"
+	StatusTentative Status = "TENTATIVE"
+	StatusCancelled Status = "CANCELLED"
+)
+
+// Organizer is the ORGANIZER of a VEVENT
+type Organizer struct {
+	Name  string
+	Email string
+}
+
+// Event is a VEVENT. Times are written in UTC, except that all-day events are
+// written as dates and series in another Timezone as local times of it; End
+// is left out when it is not after Start. An event with a RecurrenceID
+// replaces that single occurrence of the series with the same UID.
+type Event struct {
+	UID          string
+	Stamp        time.Time
+	Created      time.Time
+	LastModified time.Time
+	Start        time.Time
+	End          time.Time
+	// Timezone the event takes place in; series are written in it so calendar
+	// apps repeat them at the same local time across DST changes
+	Timezone     *time.Location
+	AllDay       bool
+	Summary      string
+	Description  string
+	Location     string
+	Latitude     *float64
+	Longitude    *float64
+	Categories   []string
+	Status       Status
+	Organizer    *Organizer
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID *time.Time
+}
+
+// Calendar is a VCALENDAR
+type Calendar struct {
+	Name string
+	// RefreshInterval tells subscribed calendar apps how often to reload the
+	// calendar. It is left out when zero.
+	RefreshInterval time.Duration
+	Events          []Event
+}
+
+// Encode writes the calendar to w
+func (cal *Calendar) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	e := &encoder{w: bw}
+
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", ProductID)
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		e.line("X-WR-CALNAME", Escape(cal.Name))
+	}
+	if cal.RefreshInterval > 0 {
+		interval := Duration(cal.RefreshInterval)
+		e.line("REFRESH-INTERVAL;VALUE=DURATION", interval)
+		e.line("X-PUBLISHED-TTL", interval)
+	}
+	for i := range cal.Events {
+		e.event(&cal.Events[i])
+	}
+	e.line("END", "VCALENDAR")
+
+	if e.err != nil {
+		return e.err
+	}
+	return bw.Flush()
+}
+
+// String returns the encoded calendar
+func (cal *Calendar) String() string {
+	var b strings.Builder
+	_ = cal.Encode(&b)
+	return b.String()
+}
+
+// FormatTime formats t as an iCalendar UTC date-time
+func FormatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// Duration formats d as an iCalendar duration, e.g. PT1H30M
+func Duration(d time.Duration) string {
+	if d <= 0 {
+		return "PT0S"
+	}
+	var b strings.Builder
+	b.WriteString("P")
+	if days := d / (24 * time.Hour); days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+		d -= days * 24 * time.Hour
+	}
+	if d > 0 {
+		b.WriteString("T")
+		if h := d / time.Hour; h > 0 {
+			fmt.Fprintf(&b, "%dH", h)
+			d -= h * time.Hour
+		}
+		if m := d / time.Minute; m > 0 {
+			fmt.Fprintf(&b, "%dM", m)
+			d -= m * time.Minute
+		}
+		if s := d / time.Second; s > 0 {
+			fmt.Fprintf(&b, "%dS", s)
+		}
+	}
+	return b.String()
+}
+
+// Escape escapes a TEXT value
+func Escape(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+// escapeParam quotes a parameter value if needed. Double quotes are not
+// allowed inside parameter values and are dropped.
+func escapeParam(s string) string {
+	s = strings.ReplaceAll(s, `"`, "")
+	if strings.ContainsAny(s, ";:,") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// Fold splits a content line into lines of at most 75 octets, as RFC 5545
+// requires. Continuation lines start with a space; multi-byte characters are
+// never split.
+func Fold(line string) string {
+	if len(line) <= maxLineOctets {
+		return line
+	}
+
+	var b strings.Builder
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines lose one octet to the leading space
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+// times returns the name with its parameters and the value of a property
+// holding times of the event
+func (ev *Event) times(name string, times ...time.Time) (string, string) {
+	loc := ev.Timezone
+	if loc == nil {
+		loc = time.UTC
+	}
+	layout := ""
+	switch {
+	case ev.AllDay:
+		name, layout = name+";VALUE=DATE", "20060102"
+	case loc != time.UTC && (ev.RRule != "" || ev.RecurrenceID != nil):
+		name, layout = name+";TZID="+loc.String(), "20060102T150405"
+	}
+
+	values := make([]string, len(times))
+	for i, t := range times {
+		if layout == "" {
+			values[i] = FormatTime(t)
+		} else {
+			values[i] = t.In(loc).Format(layout)
+		}
+	}
+	return name, strings.Join(values, ",")
+}
+
+// encoder writes content lines and remembers the first write error
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.WriteString(Fold(name+":"+value) + "\r\n")
+}
+
+func (e *encoder) event(ev *Event) {
+	e.line("BEGIN", "VEVENT")
+	e.line("UID", ev.UID)
+	e.line("DTSTAMP", FormatTime(ev.Stamp))
+	if !ev.Created.IsZero() {
+		e.line("CREATED", FormatTime(ev.Created))
+	}
+	if !ev.LastModified.IsZero() {
+		e.line("LAST-MODIFIED", FormatTime(ev.LastModified))
+	}
+	if ev.RecurrenceID != nil {
+		e.line(ev.times("RECURRENCE-ID", *ev.RecurrenceID))
+	}
+	e.line(ev.times("DTSTART", ev.Start))
+	if ev.End.After(ev.Start) {
+		e.line(ev.times("DTEND", ev.End))
+	}
+	if ev.RRule != "" {
+		e.line("RRULE", ev.RRule)
+	}
+	if len(ev.ExDates) > 0 {
+		e.line(ev.times("EXDATE", ev.ExDates...))
+	}
+	e.line("SUMMARY", Escape(ev.Summary))
+	if ev.Description != "" {
+		e.line("DESCRIPTION", Escape(ev.Description))
+	}
+	if ev.Location != "" {
+		e.line("LOCATION", Escape(ev.Location))
+	}
+	if ev.Latitude != nil && ev.Longitude != nil {
+		e.line("GEO", fmt.Sprintf("%.6f;%.6f", *ev.Latitude, *ev.Longitude))
+	}
+	if len(ev.Categories) > 0 {
+		categories := make([]string, len(ev.Categories))
+		for i, category := range ev.Categories {
+			categories[i] = Escape(category)
+		}
+		e.line("CATEGORIES", strings.Join(categories, ","))
+	}
+	if ev.Status != "" {
+		e.line("STATUS", string(ev.Status))
+	}
+	if ev.Organizer != nil && ev.Organizer.Email != "" {
+		name := ""
+		if ev.Organizer.Name != "" {
+			name = ";CN=" + escapeParam(ev.Organizer.Name)
+		}
+		e.line("ORGANIZER"+name, "mailto:"+ev.Organizer.Email)
+	}
+	e.line("END", "VEVENT")
+}
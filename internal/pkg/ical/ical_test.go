@@ -0,0 +1,181 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+func TestEscape(t *testing.T) {
+	got := Escape("Pub quiz; bring pens, paper\nand a\\friend")
+	want := `Pub quiz\; bring pens\, paper\nand a\\friend`
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestFold(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("å", 60)
+	folded := Fold(line)
+
+	for _, part := range strings.Split(folded, "\r\n") {
+		if len(part) > maxLineOctets {
+			t.Errorf("expected lines of at most %d octets, got %d: %q", maxLineOctets, len(part), part)
+		}
+	}
+	if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != line {
+		t.Errorf("expected unfolding to restore the line, got %q", unfolded)
+	}
+	if short := "SUMMARY:Pub quiz"; Fold(short) != short {
+		t.Errorf("expected short lines to be left alone, got %q", Fold(short))
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		time.Hour:                     "PT1H",
+		90 * time.Minute:              "PT1H30M",
+		26*time.Hour + 15*time.Second: "P1DT2H15S",
+		48 * time.Hour:                "P2D",
+		0:                             "PT0S",
+	}
+	for d, want := range tests {
+		if got := Duration(d); got != want {
+			t.Errorf("%s: expected %s, got %s", d, want, got)
+		}
+	}
+}
+
+func TestCalendarEncode(t *testing.T) {
+	start := time.Date(2026, 6, 1, 18, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	lat, lon := 65.6167, 22.1367
+	calendar := Calendar{
+		Name:            "Pub quizzes",
+		RefreshInterval: time.Hour,
+		Events: []Event{{
+			UID:        "evt-001@event-service",
+			Stamp:      start,
+			Start:      start,
+			End:        start.Add(2 * time.Hour),
+			Summary:    "Pub quiz, round 1",
+			Location:   "Student union",
+			Latitude:   &lat,
+			Longitude:  &lon,
+			Categories: []string{"Social"},
+			Status:     StatusConfirmed,
+			Organizer:  &Organizer{Name: "Quiz Club", Email: "quiz@example.com"},
+		}},
+	}
+
+	encoded := calendar.String()
+	for _, line := range []string{
+		"BEGIN:VCALENDAR",
+		"PRODID:" + ProductID,
+		"X-WR-CALNAME:Pub quizzes",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT1H",
+		"UID:evt-001@event-service",
+		"DTSTART:20260601T160000Z",
+		"DTEND:20260601T180000Z",
+		`SUMMARY:Pub quiz\, round 1`,
+		"GEO:65.616700;22.136700",
+		"ORGANIZER;CN=Quiz Club:mailto:quiz@example.com",
+		"END:VCALENDAR",
+	} {
+		if !strings.Contains(encoded, line+"\r\n") {
+			t.Errorf("expected line %q in\n%s", line, encoded)
+		}
+	}
+}
+
+func TestFromEvent_Series(t *testing.T) {
+	start := time.Date(2026, 6, 1, 18, 0, 0, 0, time.UTC)
+	rule := "FREQ=WEEKLY;COUNT=5"
+
+	var event db.EventModel
+	event.InnerEvent.ID = "evt-001"
+	event.InnerEvent.Name = "Pub quiz"
+	event.InnerEvent.StartsAt = start
+	event.InnerEvent.EndsAt = start.Add(2 * time.Hour)
+	event.InnerEvent.Status = db.EventStatusPublished
+	event.InnerEvent.RecurrenceRule = &rule
+	event.InnerEvent.RecurrenceExceptions = []time.Time{start.AddDate(0, 0, 7)}
+
+	var cancelled, moved db.EventOccurrenceOverrideModel
+	cancelled.InnerEventOccurrenceOverride.OriginalStart = start.AddDate(0, 0, 14)
+	cancelled.InnerEventOccurrenceOverride.Cancelled = true
+	moved.InnerEventOccurrenceOverride.OriginalStart = start.AddDate(0, 0, 21)
+	location := "Main hall"
+	moved.InnerEventOccurrenceOverride.Location = &location
+
+	events := FromEvent(&event, []db.EventOccurrenceOverrideModel{moved, cancelled}, "event-service")
+	if len(events) != 2 {
+		t.Fatalf("expected the series and one changed occurrence, got %d events", len(events))
+	}
+
+	series, occurrence := events[0], events[1]
+	if series.UID != "evt-001@event-service" || occurrence.UID != series.UID {
+		t.Errorf("expected both VEVENTs to share a stable UID, got %q and %q", series.UID, occurrence.UID)
+	}
+	if series.RRule != rule || len(series.ExDates) != 2 {
+		t.Errorf("expected the rule with the exception and the cancelled occurrence as EXDATEs, got %q and %v", series.RRule, series.ExDates)
+	}
+	if occurrence.RecurrenceID == nil || !occurrence.RecurrenceID.Equal(start.AddDate(0, 0, 21)) {
+		t.Errorf("expected the changed occurrence to carry its original start, got %v", occurrence.RecurrenceID)
+	}
+	if occurrence.Location != location || !occurrence.End.Equal(start.AddDate(0, 0, 21).Add(2*time.Hour)) {
+		t.Errorf("expected the override to apply on top of the series, got %+v", occurrence)
+	}
+}
+
+func TestFromEvent_CancelledEvent(t *testing.T) {
+	var event db.EventModel
+	event.InnerEvent.ID = "evt-002"
+	event.InnerEvent.Status = db.EventStatusCancelled
+
+	events := FromEvent(&event, nil, "event-service")
+	if len(events) != 1 || events[0].Status != StatusCancelled || events[0].RRule != "" {
+		t.Errorf("expected a single cancelled VEVENT, got %+v", events)
+	}
+}
+
+func TestCalendarEncode_LocalTimes(t *testing.T) {
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 3, 23, 19, 0, 0, 0, stockholm)
+	calendar := Calendar{Events: []Event{
+		{
+			UID:      "evt-001@event-service",
+			Start:    start,
+			End:      start.Add(2 * time.Hour),
+			Timezone: stockholm,
+			RRule:    "FREQ=WEEKLY;COUNT=5",
+			ExDates:  []time.Time{start.AddDate(0, 0, 7)},
+			Summary:  "Pub quiz",
+		},
+		{
+			UID:      "evt-002@event-service",
+			Start:    time.Date(2026, 6, 19, 0, 0, 0, 0, stockholm),
+			End:      time.Date(2026, 6, 21, 0, 0, 0, 0, stockholm),
+			Timezone: stockholm,
+			AllDay:   true,
+			Summary:  "Midsummer",
+		},
+	}}
+
+	encoded := calendar.String()
+	for _, line := range []string{
+		"DTSTART;TZID=Europe/Stockholm:20260323T190000",
+		"DTEND;TZID=Europe/Stockholm:20260323T210000",
+		"EXDATE;TZID=Europe/Stockholm:20260330T190000",
+		"DTSTART;VALUE=DATE:20260619",
+		"DTEND;VALUE=DATE:20260621",
+	} {
+		if !strings.Contains(encoded, line+"\r\n") {
+			t.Errorf("expected line %q in\n%s", line, encoded)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Package imaging validates uploaded images and creates resized copies of
+// them using only the standard library codecs (JPEG, PNG and GIF).
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// MaxPixels limits the decoded size of an image, so small files cannot expand
+// into huge bitmaps
+const MaxPixels = 40_000_000
+
+// JPEGQuality is used for JPEG thumbnails
+const JPEGQuality = 85
+
+// Extensions maps the supported MIME types to the file extension they are stored with
+var Extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+var (
+	// ErrUnsupportedType is returned for content that is not a supported image
+	ErrUnsupportedType = errors.New("unsupported image type")
+	// ErrTooManyPixels is returned for images with more than MaxPixels pixels
+	ErrTooManyPixels = fmt.Errorf("image has more than %d pixels", MaxPixels)
+)
+
+// Image is a decoded upload
+type Image struct {
+	image.Image
+	// ContentType is the MIME type detected from the content, not the one the client sent
+	ContentType string
+}
+
+// DetectType returns the MIME type of the content, or ErrUnsupportedType
+func DetectType(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if _, ok := Extensions[contentType]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+	return contentType, nil
+}
+
+// Decode detects the type of the content and decodes it
+func Decode(data []byte) (*Image, error) {
+	contentType, err := DetectType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	var img image.Image
+	switch contentType {
+	case "image/jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+	return &Image{Image: img, ContentType: contentType}, nil
+}
+
+// Thumbnail scales the image down to the given width, keeping its aspect
+// ratio, and encodes it like the original: JPEGs as JPEG, everything else as
+// PNG to keep transparency. Images narrower than width keep their size.
+func (img *Image) Thumbnail(width int) ([]byte, string, error) {
+	resized := Resize(img.Image, width)
+
+	var buf bytes.Buffer
+	if img.ContentType == "image/jpeg" {
+		if err := jpeg.Encode(&buf, resized, &jpeg.Options{Quality: JPEGQuality}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+	if err := png.Encode(&buf, resized); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+// Resize scales src down to the given width, keeping its aspect ratio. Every
+// destination pixel is the average of the source pixels it covers, which
+// avoids the aliasing of nearest-neighbour scaling. Images narrower than
+// width are returned unchanged.
+func Resize(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if width <= 0 || srcW <= width {
+		return src
+	}
+	height := srcH * width / srcW
+	if height < 1 {
+		height = 1
+	}
+
+	// Work on straight RGBA so pixels can be read directly
+	rgba := image.NewNRGBA(image.Rect(0, 0, srcW, srcH))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcH/height, (y+1)*srcH/height
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcW/width, (x+1)*srcW/width
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					// Weight colours by alpha so transparent pixels do not darken edges
+					alpha := uint64(p[3])
+					r += uint64(p[0]) * alpha
+					g += uint64(p[1]) * alpha
+					b += uint64(p[2]) * alpha
+					a += alpha
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			if a > 0 {
+				dst.Pix[i] = uint8(r / a)
+				dst.Pix[i+1] = uint8(g / a)
+				dst.Pix[i+2] = uint8(b / a)
+			}
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,76 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: 200, G: 40, B: 40, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestResize(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 1000, 500))
+
+	if got := Resize(src, 320).Bounds(); got.Dx() != 320 || got.Dy() != 160 {
+		t.Errorf("expected 320x160, got %dx%d", got.Dx(), got.Dy())
+	}
+	if got := Resize(src, 2000); got != image.Image(src) {
+		t.Error("expected narrower images to be returned unchanged")
+	}
+}
+
+func TestResize_KeepsColourOfTransparentEdges(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	src.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	src.Set(1, 0, color.NRGBA{})
+
+	got := Resize(src, 2).(*image.NRGBA).NRGBAAt(0, 0)
+	if got.R != 255 || got.A != 127 {
+		t.Errorf("expected a half transparent red pixel, got %+v", got)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	img, err := Decode(encodePNG(t, 640, 480))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if img.ContentType != "image/png" || img.Bounds().Dx() != 640 {
+		t.Errorf("unexpected image %s %v", img.ContentType, img.Bounds())
+	}
+
+	thumbnail, contentType, err := img.Thumbnail(320)
+	if err != nil {
+		t.Fatalf("Thumbnail: %v", err)
+	}
+	config, err := png.DecodeConfig(bytes.NewReader(thumbnail))
+	if err != nil || contentType != "image/png" || config.Width != 320 || config.Height != 240 {
+		t.Errorf("expected a 320x240 PNG, got %s %dx%d and %v", contentType, config.Width, config.Height, err)
+	}
+}
+
+func TestDecode_Unsupported(t *testing.T) {
+	if _, err := Decode([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>")); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("expected ErrUnsupportedType, got %v", err)
+	}
+	// A PNG signature followed by garbage
+	if _, err := Decode([]byte("\x89PNG\r\n\x1a\ngarbage")); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("expected ErrUnsupportedType for a broken PNG, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+// Package problem writes error responses as RFC 7807 problem details
+// (application/problem+json). Every problem carries a stable machine-readable
+// code and the trace id of the request, so a client report can be matched
+// with the service logs.
+package problem
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/logger"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+	"github.com/sirupsen/logrus"
+)
+
+// ContentType is the media type of problem responses
+const ContentType = "application/problem+json"
+
+// TypePrefix is prepended to the code to build the problem type URI
+const TypePrefix = "urn:dws-event-service:problem:"
+
+// TraceIDHeader carries the trace id of a request and its response
+const TraceIDHeader = "X-Request-ID"
+
+// Codes shared by all endpoints. Endpoints add more specific codes, e.g.
+// "event_not_found"; codes are part of the API and must not change.
+const (
+	CodeInvalidRequest   = "invalid_request"
+	CodeInvalidQuery     = "invalid_query"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeNotFound         = "not_found"
+	CodeInternal         = "internal_error"
+)
+
+// traceIDKey stores the trace id in the gin context
+const traceIDKey = "trace_id"
+
+// Problem is an RFC 7807 problem details object with the extension members
+// code, traceId and, for validation failures, errors
+type Problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Code     string            `json:"code"`
+	TraceID  string            `json:"traceId"`
+	Errors   validation.Errors `json:"errors,omitempty"`
+}
+
+// Write completes the problem with its type, the request path and the trace
+// id, writes it and aborts the request
+func Write(c *gin.Context, p Problem) {
+	p.Type = TypePrefix + p.Code
+	p.TraceID = TraceID(c)
+	if c.Request != nil && c.Request.URL != nil {
+		p.Instance = c.Request.URL.Path
+	}
+
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+// Respond writes a problem. detail explains this occurrence to the client and
+// may be empty; it must not contain internal error messages.
+func Respond(c *gin.Context, status int, code, title, detail string) {
+	Write(c, Problem{Status: status, Code: code, Title: title, Detail: detail})
+}
+
+// Internal logs err together with the trace id and writes a 500 problem
+// without the error, which may expose queries or connection details
+func Internal(c *gin.Context, title string, err error) {
+	traceID := TraceID(c)
+	log().WithField("traceId", traceID).Errorf("%s %s: %s: %v", c.Request.Method, c.Request.URL.Path, title, err)
+	Write(c, Problem{
+		Status: http.StatusInternalServerError,
+		Code:   CodeInternal,
+		Title:  title,
+		Detail: "an unexpected error occurred; quote trace id " + traceID + " when reporting it",
+	})
+}
+
+// Unavailable logs err together with the trace id and writes a 503 problem
+// without the error, for dependencies that cannot be reached
+func Unavailable(c *gin.Context, code, title string, err error) {
+	traceID := TraceID(c)
+	log().WithField("traceId", traceID).Warnf("%s %s: %s: %v", c.Request.Method, c.Request.URL.Path, title, err)
+	Write(c, Problem{
+		Status: http.StatusServiceUnavailable,
+		Code:   code,
+		Title:  title,
+		Detail: "the service is temporarily unavailable; quote trace id " + traceID + " when reporting it",
+	})
+}
+
+// Validation writes a 422 problem listing the invalid fields
+func Validation(c *gin.Context, errs validation.Errors) {
+	Write(c, Problem{
+		Status: http.StatusUnprocessableEntity,
+		Code:   CodeValidationFailed,
+		Title:  "Validation failed",
+		Detail: errs.Error(),
+		Errors: errs,
+	})
+}
+
+// validTraceID limits client-supplied trace ids to what is safe to log and echo
+var validTraceID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// TraceID returns the trace id of the request. It is taken from the W3C
+// traceparent header or X-Request-ID, or generated, and kept for the rest of
+// the request.
+func TraceID(c *gin.Context) string {
+	if traceID := c.GetString(traceIDKey); traceID != "" {
+		return traceID
+	}
+
+	var traceID string
+	if c.Request != nil {
+		// traceparent: version-traceid-parentid-flags
+		parts := strings.Split(c.GetHeader("traceparent"), "-")
+		if len(parts) == 4 && len(parts[1]) == 32 && validTraceID.MatchString(parts[1]) && strings.Trim(parts[1], "0") != "" {
+			traceID = parts[1]
+		} else if requestID := c.GetHeader(TraceIDHeader); validTraceID.MatchString(requestID) {
+			traceID = requestID
+		}
+	}
+	if traceID == "" {
+		traceID = strings.ReplaceAll(uuid.NewString(), "-", "")
+	}
+	c.Set(traceIDKey, traceID)
+	return traceID
+}
+
+var (
+	logOnce sync.Once
+	logs    *logrus.Logger
+)
+
+func log() *logrus.Logger {
+	logOnce.Do(func() {
+		logs = logger.NewLogrusLogger()
+	})
+	return logs
+}
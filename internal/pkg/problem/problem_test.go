@@ -0,0 +1,142 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/validation"
+)
+
+func newContext(header map[string]string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/events/42", nil)
+	for name, value := range header {
+		c.Request.Header.Set(name, value)
+	}
+	return c, w
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, ContentType) {
+		t.Fatalf("expected content type %s, got %q", ContentType, got)
+	}
+	var p Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("failed to decode problem: %v", err)
+	}
+	return p
+}
+
+func TestRespond(t *testing.T) {
+	c, w := newContext(map[string]string{TraceIDHeader: "req-1"})
+
+	Respond(c, http.StatusNotFound, "event_not_found", "Event not found", "")
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", w.Code)
+	}
+	if !c.IsAborted() {
+		t.Error("expected the request to be aborted")
+	}
+	p := decode(t, w)
+	want := Problem{
+		Type:     TypePrefix + "event_not_found",
+		Title:    "Event not found",
+		Status:   http.StatusNotFound,
+		Instance: "/api/v1/events/42",
+		Code:     "event_not_found",
+		TraceID:  "req-1",
+	}
+	if p.Type != want.Type || p.Title != want.Title || p.Status != want.Status ||
+		p.Instance != want.Instance || p.Code != want.Code || p.TraceID != want.TraceID || p.Detail != "" {
+		t.Errorf("expected %+v, got %+v", want, p)
+	}
+}
+
+func TestInternal_HidesError(t *testing.T) {
+	c, w := newContext(nil)
+
+	Internal(c, "Failed to fetch event", errors.New("pq: connection refused to 10.0.0.5"))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "10.0.0.5") {
+		t.Errorf("expected the error to stay in the logs, got %s", w.Body.String())
+	}
+	p := decode(t, w)
+	if p.Code != CodeInternal {
+		t.Errorf("expected code %s, got %s", CodeInternal, p.Code)
+	}
+	if p.TraceID == "" || !strings.Contains(p.Detail, p.TraceID) {
+		t.Errorf("expected the detail to quote the trace id, got %+v", p)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	c, w := newContext(nil)
+	var errs validation.Errors
+	errs.Add("endDate", validation.CodeBeforeStart, "must not be before startDate")
+
+	Validation(c, errs)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d", w.Code)
+	}
+	p := decode(t, w)
+	if p.Code != CodeValidationFailed || len(p.Errors) != 1 || p.Errors[0].Field != "endDate" {
+		t.Errorf("expected the field errors, got %+v", p)
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   string
+	}{
+		{
+			name:   "traceparent",
+			header: map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", TraceIDHeader: "req-1"},
+			want:   "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:   "request id",
+			header: map[string]string{TraceIDHeader: "req-1"},
+			want:   "req-1",
+		},
+		{
+			name:   "all-zero traceparent falls back to the request id",
+			header: map[string]string{"traceparent": "00-00000000000000000000000000000000-00f067aa0ba902b7-01", TraceIDHeader: "req-1"},
+			want:   "req-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newContext(tt.header)
+			if got := TraceID(c); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTraceID_GeneratesForInvalidHeader(t *testing.T) {
+	c, _ := newContext(map[string]string{TraceIDHeader: "bad id\nwith newline"})
+
+	traceID := TraceID(c)
+	if len(traceID) != 32 || !validTraceID.MatchString(traceID) {
+		t.Fatalf("expected a generated trace id, got %q", traceID)
+	}
+	if again := TraceID(c); again != traceID {
+		t.Errorf("expected the trace id to be kept for the request, got %q and %q", traceID, again)
+	}
+}
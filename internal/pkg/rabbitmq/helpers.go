@@ -9,6 +9,10 @@ import (
 	"github.com/rabbitmq/amqp091-go"
 )
 
+// DefaultExchange is the topic exchange domain messages are published to when
+// rabbitmq.exchange is not configured
+const DefaultExchange = "events"
+
 // PublishEvent publishes an event to RabbitMQ
 func PublishEvent(exchange, routingKey string, event interface{}) error {
 	rabbitmqService := services.GetRabbitMQServiceInstance()
@@ -123,3 +127,45 @@ func StartEventConsumer(queueName, consumerTag string, handler MessageHandler) e
 		delivery.Ack(false)
 	})
 }
+
+// SubscribeEvents consumes the messages published to the topic exchange with
+// any of the routing keys. The queue is exclusive to this instance and deleted
+// when it disconnects, so every replica receives every message.
+func SubscribeEvents(exchange string, routingKeys []string, consumerTag string, handler MessageHandler) error {
+	rabbitmqService := services.GetRabbitMQServiceInstance()
+	if rabbitmqService == nil {
+		return fmt.Errorf("RabbitMQ service is not available")
+	}
+
+	if err := rabbitmqService.DeclareExchange(
+		exchange,
+		"topic",
+		true,  // durable
+		false, // autoDelete
+		false, // internal
+		false, // noWait
+		nil,   // args
+	); err != nil {
+		return fmt.Errorf("failed to declare exchange: %w", err)
+	}
+
+	queue, err := rabbitmqService.DeclareQueue(
+		"",    // name (empty = auto-generate)
+		false, // durable
+		true,  // autoDelete
+		true,  // exclusive
+		false, // noWait
+		nil,   // args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue: %w", err)
+	}
+
+	for _, routingKey := range routingKeys {
+		if err := rabbitmqService.QueueBind(queue.Name, routingKey, exchange, false, nil); err != nil {
+			return fmt.Errorf("failed to bind queue to %s: %w", routingKey, err)
+		}
+	}
+
+	return StartEventConsumer(queue.Name, consumerTag, handler)
+}
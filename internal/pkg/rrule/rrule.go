@@ -0,0 +1,464 @@
+// Package rrule implements the subset of iCalendar (RFC 5545) recurrence rules
+// used for recurring events: FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL,
+// COUNT, UNTIL, BYDAY, BYMONTHDAY and BYMONTH. WKST is accepted but weeks
+// always start on Monday.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the FREQ part of a recurrence rule
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxPeriods bounds the number of periods walked during expansion so that a
+// pathological rule can never loop forever
+const maxPeriods = 100000
+
+// untilLayouts are the accepted UNTIL formats
+var untilLayouts = []string{"20060102T150405Z", "20060102T150405", "20060102"}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum is a BYDAY entry such as "FR", "1MO" or "-1SU". N is 0 for every
+// matching weekday in the period.
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+func (w WeekdayNum) String() string {
+	code := strings.ToUpper(w.Weekday.String()[:2])
+	if w.N == 0 {
+		return code
+	}
+	return strconv.Itoa(w.N) + code
+}
+
+// Rule is a parsed recurrence rule
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+}
+
+// Parse parses an RRULE value such as "FREQ=WEEKLY;BYDAY=TU,TH;COUNT=10".
+// A leading "RRULE:" is ignored.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, errors.New("rrule is empty")
+	}
+
+	r := &Rule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("rrule: invalid part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			switch f := Frequency(strings.ToUpper(value)); f {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Freq = f
+			default:
+				return nil, fmt.Errorf("rrule: unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, errors.New("rrule: INTERVAL must be a positive integer")
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, errors.New("rrule: COUNT must be a positive integer")
+			}
+			r.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			r.Until = until
+		case "BYDAY":
+			for _, v := range strings.Split(value, ",") {
+				wd, err := parseWeekdayNum(v)
+				if err != nil {
+					return nil, err
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(v)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("rrule: invalid BYMONTHDAY %q", v)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "BYMONTH":
+			for _, v := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 1 || n > 12 {
+					return nil, fmt.Errorf("rrule: invalid BYMONTH %q", v)
+				}
+				r.ByMonth = append(r.ByMonth, time.Month(n))
+			}
+		case "WKST":
+			if _, ok := weekdays[strings.ToUpper(value)]; !ok {
+				return nil, fmt.Errorf("rrule: invalid WKST %q", value)
+			}
+		default:
+			return nil, fmt.Errorf("rrule: unsupported part %q", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, errors.New("rrule: FREQ is required")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, errors.New("rrule: COUNT and UNTIL must not both be set")
+	}
+	for _, wd := range r.ByDay {
+		if wd.N != 0 && r.Freq != Monthly && r.Freq != Yearly {
+			return nil, errors.New("rrule: numbered BYDAY is only allowed with FREQ=MONTHLY or FREQ=YEARLY")
+		}
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq == Weekly {
+		return nil, errors.New("rrule: BYMONTHDAY is not allowed with FREQ=WEEKLY")
+	}
+
+	return r, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range untilLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// A date-only UNTIL includes the whole day
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("rrule: invalid UNTIL %q", value)
+}
+
+func parseWeekdayNum(value string) (WeekdayNum, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) < 2 {
+		return WeekdayNum{}, fmt.Errorf("rrule: invalid BYDAY %q", value)
+	}
+
+	wd, ok := weekdays[value[len(value)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("rrule: invalid BYDAY %q", value)
+	}
+
+	n := 0
+	if prefix := value[:len(value)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return WeekdayNum{}, fmt.Errorf("rrule: invalid BYDAY %q", value)
+		}
+	}
+
+	return WeekdayNum{Weekday: wd, N: n}, nil
+}
+
+// String returns the rule in canonical RRULE form (without the "RRULE:" prefix)
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = wd.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]string, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = strconv.Itoa(int(m))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// IsFinite reports whether the rule ends through COUNT or UNTIL
+func (r *Rule) IsFinite() bool {
+	return r.Count > 0 || !r.Until.IsZero()
+}
+
+// Between returns the occurrences starting in [from, to], in order. dtstart
+// anchors the series: it defines the first period, the time of day and the
+// location in which days are counted.
+func (r *Rule) Between(dtstart, from, to time.Time) []time.Time {
+	var out []time.Time
+	r.iterate(dtstart, to, func(t time.Time) bool {
+		if t.After(to) {
+			return false
+		}
+		if !t.Before(from) {
+			out = append(out, t)
+		}
+		return true
+	})
+	return out
+}
+
+// Last returns the final occurrence of a finite rule. ok is false for rules
+// without COUNT or UNTIL, or if the rule produces no occurrence at all.
+func (r *Rule) Last(dtstart time.Time) (last time.Time, ok bool) {
+	if !r.IsFinite() {
+		return time.Time{}, false
+	}
+	r.iterate(dtstart, time.Time{}, func(t time.Time) bool {
+		last, ok = t, true
+		return true
+	})
+	return last, ok
+}
+
+// First returns the first occurrence starting no later than before. ok is
+// false if the rule produces none by then, e.g. for BYMONTH=2;BYMONTHDAY=30.
+// Periods after before are not walked.
+func (r *Rule) First(dtstart, before time.Time) (first time.Time, ok bool) {
+	r.iterate(dtstart, before, func(t time.Time) bool {
+		if !t.After(before) {
+			first, ok = t, true
+		}
+		return false
+	})
+	return first, ok
+}
+
+// iterate calls yield for every occurrence in order until yield returns false
+// or the rule ends. Periods starting after horizon are not walked, unless it
+// is zero.
+func (r *Rule) iterate(dtstart, horizon time.Time, yield func(time.Time) bool) {
+	count := 0
+	for period := 0; period < maxPeriods; period++ {
+		if !horizon.IsZero() && r.periodStart(dtstart, period).After(horizon) {
+			return
+		}
+		candidates := r.candidates(dtstart, period)
+		for _, t := range candidates {
+			if t.Before(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return
+			}
+			count++
+			if r.Count > 0 && count > r.Count {
+				return
+			}
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// periodStart returns the midnight that begins the given period, at or
+// before each of its candidates
+func (r *Rule) periodStart(dtstart time.Time, period int) time.Time {
+	loc := dtstart.Location()
+	y, m, d := dtstart.Date()
+	step := period * r.Interval
+	switch r.Freq {
+	case Weekly:
+		offset := (int(dtstart.Weekday()) + 6) % 7 // days since Monday
+		return time.Date(y, m, d-offset+7*step, 0, 0, 0, 0, loc)
+	case Monthly:
+		return time.Date(y, m+time.Month(step), 1, 0, 0, 0, 0, loc)
+	case Yearly:
+		return time.Date(y+step, time.January, 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(y, m, d+step, 0, 0, 0, 0, loc)
+	}
+}
+
+// candidates returns the sorted occurrences of the given period, which is the
+// n-th step of FREQ*INTERVAL after the period containing dtstart
+func (r *Rule) candidates(dtstart time.Time, period int) []time.Time {
+	loc := dtstart.Location()
+	hour, min, sec := dtstart.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, min, sec, dtstart.Nanosecond(), loc)
+	}
+	step := period * r.Interval
+
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		days = []time.Time{at(dtstart.Year(), dtstart.Month(), dtstart.Day()+step)}
+	case Weekly:
+		offset := (int(dtstart.Weekday()) + 6) % 7 // days since Monday
+		monday := at(dtstart.Year(), dtstart.Month(), dtstart.Day()-offset+7*step)
+		weekdaySet := r.ByDay
+		if len(weekdaySet) == 0 {
+			weekdaySet = []WeekdayNum{{Weekday: dtstart.Weekday()}}
+		}
+		for _, wd := range weekdaySet {
+			days = append(days, monday.AddDate(0, 0, (int(wd.Weekday)+6)%7))
+		}
+	case Monthly:
+		first := at(dtstart.Year(), dtstart.Month()+time.Month(step), 1)
+		days = r.monthDays(first, dtstart.Day())
+	case Yearly:
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{dtstart.Month()}
+		}
+		for _, m := range months {
+			days = append(days, r.monthDays(at(dtstart.Year()+step, m, 1), dtstart.Day())...)
+		}
+	}
+
+	var out []time.Time
+	for _, d := range days {
+		if r.matchesFilters(d) {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+// monthDays expands BYMONTHDAY and BYDAY within the month starting at first.
+// Without either, the day of month of dtstart is used; months that are too
+// short for it are skipped.
+func (r *Rule) monthDays(first time.Time, defaultDay int) []time.Time {
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+	var days []time.Time
+
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, d := range r.ByMonthDay {
+			if d < 0 {
+				d = daysInMonth + d + 1
+			}
+			if d >= 1 && d <= daysInMonth {
+				days = append(days, first.AddDate(0, 0, d-1))
+			}
+		}
+	case len(r.ByDay) > 0:
+		for _, wd := range r.ByDay {
+			var matches []time.Time
+			for d := 0; d < daysInMonth; d++ {
+				if day := first.AddDate(0, 0, d); day.Weekday() == wd.Weekday {
+					matches = append(matches, day)
+				}
+			}
+			switch {
+			case wd.N == 0:
+				days = append(days, matches...)
+			case wd.N > 0 && wd.N <= len(matches):
+				days = append(days, matches[wd.N-1])
+			case wd.N < 0 && -wd.N <= len(matches):
+				days = append(days, matches[len(matches)+wd.N])
+			}
+		}
+	default:
+		if defaultDay <= daysInMonth {
+			days = append(days, first.AddDate(0, 0, defaultDay-1))
+		}
+	}
+
+	return days
+}
+
+// matchesFilters applies the BY* parts that limit rather than expand the set
+func (r *Rule) matchesFilters(t time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsMonth(r.ByMonth, t.Month()) {
+		return false
+	}
+	if r.Freq == Daily && len(r.ByDay) > 0 && !containsWeekday(r.ByDay, t.Weekday()) {
+		return false
+	}
+	if r.Freq == Daily && len(r.ByMonthDay) > 0 {
+		daysInMonth := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+		if !containsMonthDay(r.ByMonthDay, t.Day(), daysInMonth) {
+			return false
+		}
+	}
+	if (r.Freq == Monthly || r.Freq == Yearly) && len(r.ByMonthDay) > 0 && len(r.ByDay) > 0 &&
+		!containsWeekday(r.ByDay, t.Weekday()) {
+		return false
+	}
+	return true
+}
+
+func containsMonth(months []time.Month, m time.Month) bool {
+	for _, month := range months {
+		if month == m {
+			return true
+		}
+	}
+	return false
+}
+
+func containsWeekday(days []WeekdayNum, wd time.Weekday) bool {
+	for _, d := range days {
+		if d.Weekday == wd {
+			return true
+		}
+	}
+	return false
+}
+
+func containsMonthDay(days []int, day, daysInMonth int) bool {
+	for _, d := range days {
+		if d == day || (d < 0 && daysInMonth+d+1 == day) {
+			return true
+		}
+	}
+	return false
+}
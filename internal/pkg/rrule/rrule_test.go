@@ -0,0 +1,160 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(y int, m time.Month, d, h int) time.Time {
+	return time.Date(y, m, d, h, 0, 0, 0, time.UTC)
+}
+
+func TestParse_RoundTrip(t *testing.T) {
+	tests := []string{
+		"FREQ=DAILY",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH",
+		"FREQ=MONTHLY;COUNT=6;BYDAY=-1FR",
+		"FREQ=YEARLY;UNTIL=20301231T000000Z;BYMONTHDAY=1;BYMONTH=1,7",
+	}
+
+	for _, input := range tests {
+		r, err := Parse(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, input, r.String())
+	}
+
+	r, err := Parse("RRULE:freq=weekly;wkst=MO")
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY", r.String())
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=3;UNTIL=20300101",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYMONTHDAY=3",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ=DAILY;UNTIL=tomorrow",
+	}
+
+	for _, input := range tests {
+		_, err := Parse(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestBetween_WeeklyPubQuiz(t *testing.T) {
+	// Every Wednesday at 19:00, starting Wednesday 2026-09-02
+	r, err := Parse("FREQ=WEEKLY;BYDAY=WE")
+	require.NoError(t, err)
+
+	got := r.Between(date(2026, 9, 2, 19), date(2026, 9, 10, 0), date(2026, 9, 30, 23))
+	assert.Equal(t, []time.Time{
+		date(2026, 9, 16, 19),
+		date(2026, 9, 23, 19),
+		date(2026, 9, 30, 19),
+	}, got)
+}
+
+func TestBetween_WeeklyMultipleDaysWithInterval(t *testing.T) {
+	r, err := Parse("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH")
+	require.NoError(t, err)
+
+	// Starts on a Thursday, so the Monday of the first week is skipped
+	got := r.Between(date(2026, 1, 1, 18), date(2026, 1, 1, 0), date(2026, 1, 31, 0))
+	assert.Equal(t, []time.Time{
+		date(2026, 1, 1, 18),
+		date(2026, 1, 12, 18),
+		date(2026, 1, 15, 18),
+		date(2026, 1, 26, 18),
+		date(2026, 1, 29, 18),
+	}, got)
+}
+
+func TestBetween_MonthlyLastFriday(t *testing.T) {
+	r, err := Parse("FREQ=MONTHLY;BYDAY=-1FR;COUNT=3")
+	require.NoError(t, err)
+
+	got := r.Between(date(2026, 1, 30, 20), date(2026, 1, 1, 0), date(2027, 1, 1, 0))
+	assert.Equal(t, []time.Time{
+		date(2026, 1, 30, 20),
+		date(2026, 2, 27, 20),
+		date(2026, 3, 27, 20),
+	}, got)
+}
+
+func TestBetween_MonthlySkipsShortMonths(t *testing.T) {
+	r, err := Parse("FREQ=MONTHLY")
+	require.NoError(t, err)
+
+	got := r.Between(date(2026, 1, 31, 12), date(2026, 1, 1, 0), date(2026, 5, 31, 23))
+	assert.Equal(t, []time.Time{
+		date(2026, 1, 31, 12),
+		date(2026, 3, 31, 12),
+		date(2026, 5, 31, 12),
+	}, got)
+}
+
+func TestBetween_CountIncludesOccurrencesBeforeWindow(t *testing.T) {
+	r, err := Parse("FREQ=DAILY;COUNT=5")
+	require.NoError(t, err)
+
+	got := r.Between(date(2026, 3, 1, 9), date(2026, 3, 4, 0), date(2026, 3, 31, 0))
+	assert.Equal(t, []time.Time{date(2026, 3, 4, 9), date(2026, 3, 5, 9)}, got)
+}
+
+func TestBetween_UntilAndLocalTime(t *testing.T) {
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	require.NoError(t, err)
+
+	r, err := Parse("FREQ=DAILY;UNTIL=20260330T000000Z")
+	require.NoError(t, err)
+
+	// The wall clock time stays at 19:00 across the DST change on 2026-03-29
+	start := time.Date(2026, 3, 28, 19, 0, 0, 0, stockholm)
+	got := r.Between(start, start, start.AddDate(0, 1, 0))
+	require.Len(t, got, 2)
+	assert.Equal(t, 19, got[1].Hour())
+	assert.Equal(t, 23*time.Hour, got[1].Sub(got[0]))
+}
+
+func TestLast(t *testing.T) {
+	r, err := Parse("FREQ=WEEKLY;COUNT=4")
+	require.NoError(t, err)
+
+	last, ok := r.Last(date(2026, 9, 2, 19))
+	assert.True(t, ok)
+	assert.Equal(t, date(2026, 9, 23, 19), last)
+
+	infinite, err := Parse("FREQ=WEEKLY")
+	require.NoError(t, err)
+	_, ok = infinite.Last(date(2026, 9, 2, 19))
+	assert.False(t, ok)
+}
+
+func TestFirst(t *testing.T) {
+	start := date(2026, 9, 2, 19)
+	leapDay, err := Parse("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29")
+	require.NoError(t, err)
+
+	first, ok := leapDay.First(start, start.AddDate(10, 0, 0))
+	assert.True(t, ok)
+	assert.Equal(t, date(2028, 2, 29, 19), first)
+	_, ok = leapDay.First(start, start.AddDate(1, 0, 0))
+	assert.False(t, ok)
+
+	never, err := Parse("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30")
+	require.NoError(t, err)
+	_, ok = never.First(start, start.AddDate(10, 0, 0))
+	assert.False(t, ok)
+}
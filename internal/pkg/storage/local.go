@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Defaults of the local backend
+const (
+	DefaultLocalDir     = "uploads"
+	DefaultLocalBaseURL = "/uploads"
+)
+
+// Local stores objects as files below a directory. When the base URL is a
+// path, the router serves the directory there (see LocalMount).
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocal creates a local storage rooted at dir whose objects are served at baseURL
+func NewLocal(dir, baseURL string) *Local {
+	if dir == "" {
+		dir = DefaultLocalDir
+	}
+	if baseURL == "" {
+		baseURL = DefaultLocalBaseURL
+	}
+	return &Local{dir: dir, baseURL: baseURL}
+}
+
+// Dir returns the directory the objects are stored in
+func (l *Local) Dir() string {
+	return l.dir
+}
+
+// Put writes the object to a temporary file first, so readers never see a
+// partially written file
+func (l *Local) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+
+	path := filepath.Join(l.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+
+	return joinURL(l.baseURL, key), nil
+}
+
+// Delete removes the object's file
+func (l *Local) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+
+	err := os.Remove(filepath.Join(l.dir, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
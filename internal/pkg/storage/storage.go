@@ -0,0 +1,89 @@
+// Package storage stores uploaded files such as event images behind a
+// backend-independent interface.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/oskargbc/dws-event-service.git/configs"
+)
+
+// Backends selectable with storage.backend
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// ErrInvalidKey is returned for keys that are empty, absolute or leave the storage root
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage stores objects under slash-separated keys, e.g. "events/42/cover.jpg"
+type Storage interface {
+	// Put stores the object, replacing an existing one, and returns its public URL
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+	// Delete removes the object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// New creates the storage backend selected in the config. Without a
+// configured backend the local filesystem is used.
+func New(cfg *configs.Config) (Storage, error) {
+	switch cfg.Storage.Backend {
+	case "", BackendLocal:
+		return NewLocal(cfg.Storage.Local.Dir, cfg.Storage.Local.BaseURL), nil
+	case BackendS3:
+		return NewS3(s3Config(cfg))
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Storage.Backend)
+	}
+}
+
+// LocalMount returns the URL path and the directory the service itself serves
+// the uploads of the local backend from. ok is false for other backends and
+// for base URLs pointing elsewhere, e.g. at a CDN in front of the directory.
+func LocalMount(cfg *configs.Config) (path, dir string, ok bool) {
+	if cfg.Storage.Backend != "" && cfg.Storage.Backend != BackendLocal {
+		return "", "", false
+	}
+	local := NewLocal(cfg.Storage.Local.Dir, cfg.Storage.Local.BaseURL)
+	if !strings.HasPrefix(local.baseURL, "/") || strings.HasPrefix(local.baseURL, "//") {
+		return "", "", false
+	}
+	return strings.TrimRight(local.baseURL, "/"), local.dir, true
+}
+
+// s3Config fills in the S3 endpoint and public URL of Supabase Storage when
+// only the Supabase project URL is configured
+func s3Config(cfg *configs.Config) configs.S3Storage {
+	s3 := cfg.Storage.S3
+	project := strings.TrimRight(cfg.Supabase.URL, "/")
+	if s3.Endpoint == "" && project != "" {
+		s3.Endpoint = project + "/storage/v1/s3"
+	}
+	if s3.PublicURL == "" && project != "" && s3.Bucket != "" {
+		s3.PublicURL = project + "/storage/v1/object/public/" + s3.Bucket
+	}
+	return s3
+}
+
+// validKey reports whether key is a relative, slash-separated path that stays
+// inside the storage root
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// joinURL appends the key to a base URL
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + key
+}
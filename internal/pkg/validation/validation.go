@@ -0,0 +1,195 @@
+// Package validation reports invalid request payloads as a list of field
+// errors that clients can map onto their form inputs.
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Stable error codes; clients may switch on them, so existing codes must not change
+const (
+	CodeRequired    = "required"
+	CodeInvalid     = "invalid"
+	CodeInvalidType = "invalid_type"
+	CodeTooSmall    = "too_small"
+	CodeTooLarge    = "too_large"
+	CodeUnknown     = "unknown"
+	CodeBeforeStart = "before_start"
+)
+
+// FieldError describes why one field of a payload is invalid. Field is the
+// JSON path of the field, e.g. "endDate" or "palette.primary".
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors collects the field errors of a payload
+type Errors []FieldError
+
+// Error joins the messages, e.g. "endDate must not be before startDate"
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Field + " " + fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Add records an error for the field
+func (e *Errors) Add(field, code, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Has reports whether an error was already recorded for the field, so later
+// rules can skip fields that are known to be invalid
+func (e Errors) Has(field string) bool {
+	for _, fe := range e {
+		if fe.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Struct checks the binding tags of obj, a pointer to a struct, and returns
+// the failures as field errors
+func Struct(obj any) Errors {
+	err := binding.Validator.ValidateStruct(obj)
+	if err == nil {
+		return nil
+	}
+	errs, ok := FromError(err, obj)
+	if !ok {
+		return Errors{{Field: "", Code: CodeInvalid, Message: err.Error()}}
+	}
+	return errs
+}
+
+// FromError converts validator and JSON type errors for obj into field
+// errors. ok is false for other errors, such as malformed JSON, which do not
+// belong to a field.
+func FromError(err error, obj any) (Errors, bool) {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return Errors{{
+			Field:   typeErr.Field,
+			Code:    CodeInvalidType,
+			Message: "must be a " + jsonType(typeErr.Type),
+		}}, true
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil, false
+	}
+	objType := reflect.TypeOf(obj)
+	errs := make(Errors, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		code, message := describe(fe, objType)
+		errs.Add(jsonPath(objType, fe.StructNamespace()), code, message)
+	}
+	return errs, true
+}
+
+// describe returns the code and message of a failed binding tag
+func describe(fe validator.FieldError, objType reflect.Type) (string, string) {
+	param := fe.Param()
+	isString := fe.Kind() == reflect.String
+	switch fe.Tag() {
+	case "required":
+		return CodeRequired, "is required"
+	case "required_with":
+		return CodeRequired, "is required together with " + jsonPath(objType, namespaceOf(fe)+"."+param)
+	case "min", "gte":
+		if isString {
+			return CodeTooSmall, fmt.Sprintf("must be at least %s characters long", param)
+		}
+		return CodeTooSmall, "must be at least " + param
+	case "max", "lte":
+		if isString {
+			return CodeTooLarge, fmt.Sprintf("must be at most %s characters long", param)
+		}
+		return CodeTooLarge, "must be at most " + param
+	case "gt":
+		return CodeTooSmall, "must be greater than " + param
+	case "lt":
+		return CodeTooLarge, "must be less than " + param
+	case "ne":
+		return CodeInvalid, "must not be " + param
+	case "oneof":
+		return CodeInvalid, "must be one of " + strings.ReplaceAll(param, " ", ", ")
+	case "url", "http_url":
+		return CodeInvalid, "must be a valid URL"
+	case "email":
+		return CodeInvalid, "must be a valid email address"
+	case "uuid", "uuid4":
+		return CodeInvalid, "must be a valid UUID"
+	default:
+		return CodeInvalid, "failed the " + fe.Tag() + " check"
+	}
+}
+
+// namespaceOf returns the struct namespace of the struct holding the field
+func namespaceOf(fe validator.FieldError) string {
+	namespace := fe.StructNamespace()
+	return namespace[:strings.LastIndex(namespace, ".")]
+}
+
+// jsonPath turns a struct namespace such as "UpdateBrandingRequest.Palette.Primary"
+// into the JSON path of the field, "palette.primary"
+func jsonPath(objType reflect.Type, namespace string) string {
+	parts := strings.Split(namespace, ".")[1:]
+	path := make([]string, 0, len(parts))
+	t := objType
+	for _, part := range parts {
+		name, index := part, ""
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			name, index = part[:i], part[i:]
+		}
+
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+		jsonName := name
+		if t != nil && t.Kind() == reflect.Struct {
+			if field, ok := t.FieldByName(name); ok {
+				if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+					jsonName = tag
+				}
+				t = field.Type
+			} else {
+				t = nil
+			}
+		}
+		path = append(path, jsonName+index)
+	}
+	return strings.Join(path, ".")
+}
+
+// jsonType names the JSON type a Go type is decoded from
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "whole number"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	default:
+		return "valid value"
+	}
+}
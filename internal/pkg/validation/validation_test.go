@@ -0,0 +1,59 @@
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type palette struct {
+	Primary string `json:"primary" binding:"required"`
+}
+
+type payload struct {
+	Name      string   `json:"name" binding:"required,max=5"`
+	Count     int      `json:"count" binding:"gte=1"`
+	Palette   *palette `json:"palette" binding:"required"`
+	Tags      []string `json:"tags" binding:"dive,min=2"`
+	Latitude  *float64 `json:"latitude" binding:"required_with=Longitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+func TestStruct(t *testing.T) {
+	longitude := 22.15
+	errs := Struct(&payload{
+		Name:      "Too long",
+		Palette:   &palette{},
+		Tags:      []string{"ok", "x"},
+		Longitude: &longitude,
+	})
+
+	want := []FieldError{
+		{"name", CodeTooLarge, "must be at most 5 characters long"},
+		{"count", CodeTooSmall, "must be at least 1"},
+		{"palette.primary", CodeRequired, "is required"},
+		{"tags[1]", CodeTooSmall, "must be at least 2 characters long"},
+		{"latitude", CodeRequired, "is required together with longitude"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %+v", len(want), errs)
+	}
+	for i := range want {
+		if errs[i] != want[i] {
+			t.Errorf("error %d: expected %+v, got %+v", i, want[i], errs[i])
+		}
+	}
+}
+
+func TestFromError_JSONTypes(t *testing.T) {
+	var p payload
+	err := json.Unmarshal([]byte(`{"count":"ten"}`), &p)
+
+	errs, ok := FromError(err, &p)
+	if !ok || len(errs) != 1 || errs[0].Field != "count" || errs[0].Code != CodeInvalidType {
+		t.Errorf("expected an invalid_type error on count, got %v and %+v", ok, errs)
+	}
+
+	if _, ok := FromError(json.Unmarshal([]byte(`{`), &p), &p); ok {
+		t.Error("expected malformed JSON not to be a field error")
+	}
+}
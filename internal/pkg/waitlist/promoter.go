@@ -0,0 +1,185 @@
+package waitlist
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oskargbc/dws-event-service.git/configs"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/eventchange"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/logger"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/rabbitmq"
+	"github.com/oskargbc/dws-event-service.git/internal/services"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// PromotedRoutingKey is the routing key of the message published for every promotion
+	PromotedRoutingKey = "waitlist.promoted"
+
+	// DefaultExchange is used when no exchange is configured
+	DefaultExchange = rabbitmq.DefaultExchange
+
+	// DefaultPromotionTTL is used when no promotion TTL is configured
+	DefaultPromotionTTL = time.Hour
+)
+
+// promoteNextSQL promotes the first waiting entry of an event whose seats are
+// free: the seats are reserved with a new capacity hold in the entry's name,
+// exactly like POST /events/{id}/holds does. Entries are promoted in the order
+// they joined, skipping entries that need more seats of the event or of their
+// ticket type than are free. The entry row stays locked until the statement
+// commits, so concurrent promotions cannot promote it twice, and the seats are
+// checked again when they are reserved.
+const promoteNextSQL = `
+WITH next AS (
+    SELECT w."id", w."eventId", w."ticketTypeId", w."userId", w."quantity"
+    FROM "public"."WaitlistEntry" w
+    JOIN "public"."Event" e ON e."id" = w."eventId"
+    LEFT JOIN "public"."TicketType" t ON t."id" = w."ticketTypeId"
+    WHERE w."eventId" = $1 AND w."status" = 'WAITING'
+      AND e."status" = 'PUBLISHED'
+      AND e."seatsHeld" + e."seatsSold" + w."quantity" <= e."capacity"
+      AND (w."ticketTypeId" IS NULL OR (
+        t."held" + t."sold" + w."quantity" <= t."quota"
+        AND (t."saleStartsAt" IS NULL OR t."saleStartsAt" <= $3)
+        AND (t."saleEndsAt" IS NULL OR t."saleEndsAt" > $3)
+      ))
+    ORDER BY w."joinedAt", w."id"
+    LIMIT 1
+    FOR UPDATE OF w
+), event AS (
+    UPDATE "public"."Event" e
+    SET "seatsHeld" = e."seatsHeld" + next."quantity", "updatedAt" = $3
+    FROM next
+    WHERE e."id" = next."eventId"
+      AND e."status" = 'PUBLISHED'
+      AND e."seatsHeld" + e."seatsSold" + next."quantity" <= e."capacity"
+      AND (next."ticketTypeId" IS NULL OR EXISTS (
+        SELECT 1 FROM "public"."TicketType" t
+        WHERE t."id" = next."ticketTypeId"
+          AND t."held" + t."sold" + next."quantity" <= t."quota"
+          AND (t."saleStartsAt" IS NULL OR t."saleStartsAt" <= $3)
+          AND (t."saleEndsAt" IS NULL OR t."saleEndsAt" > $3)
+      ))
+    RETURNING e."id"
+), tier AS (
+    UPDATE "public"."TicketType" t
+    SET "held" = t."held" + next."quantity"
+    FROM next, event
+    WHERE t."id" = next."ticketTypeId"
+), hold AS (
+    INSERT INTO "public"."CapacityHold" ("id", "eventId", "ticketTypeId", "quantity", "status", "holderId", "expiresAt", "createdAt", "updatedAt")
+    SELECT $2, next."eventId", next."ticketTypeId", next."quantity", 'ACTIVE'::"public"."HoldStatus", next."userId", $4, $3, $3
+    FROM next, event
+    RETURNING "id", "expiresAt"
+)
+UPDATE "public"."WaitlistEntry" w
+SET "status" = 'PROMOTED', "holdId" = hold."id", "promotedAt" = $3, "updatedAt" = $3
+FROM hold, next
+WHERE w."id" = next."id"
+RETURNING w."id" AS "entryId", w."eventId", w."ticketTypeId", w."userId", w."quantity", hold."id" AS "holdId", hold."expiresAt" AS "holdExpiresAt"`
+
+// Promotion describes a waitlist entry that was given a hold for its seats.
+// It is the payload of the waitlist.promoted message.
+type Promotion struct {
+	EntryID       string    `json:"entryId"`
+	EventID       string    `json:"eventId"`
+	TicketTypeID  *string   `json:"ticketTypeId,omitempty"`
+	UserID        string    `json:"userId"`
+	Quantity      int       `json:"quantity"`
+	HoldID        string    `json:"holdId"`
+	HoldExpiresAt time.Time `json:"holdExpiresAt"`
+}
+
+// Promoter moves waiting students onto freed seats and announces each promotion
+type Promoter struct {
+	dbService       *services.DatabaseService
+	rabbitmqService *services.RabbitMQService
+	exchange        string
+	source          string
+	holdTTL         time.Duration
+	logger          *logrus.Logger
+	// changes announces the seats taken by promotions
+	changes *eventchange.Publisher
+}
+
+// NewPromoter creates a promoter backed by the shared database and, if
+// enabled, RabbitMQ services
+func NewPromoter() *Promoter {
+	envConfig := configs.GetEnvConfig()
+
+	var rabbitmqService *services.RabbitMQService
+	if envConfig.RabbitMQ.Enabled {
+		rabbitmqService = services.GetRabbitMQServiceInstance()
+	}
+
+	exchange := envConfig.RabbitMQ.Exchange
+	if exchange == "" {
+		exchange = DefaultExchange
+	}
+	holdTTL := envConfig.Holds.PromotionTTL
+	if holdTTL <= 0 {
+		holdTTL = DefaultPromotionTTL
+	}
+
+	return &Promoter{
+		dbService:       services.GetDatabaseSeviceInstance(),
+		rabbitmqService: rabbitmqService,
+		exchange:        exchange,
+		source:          envConfig.Service.Slug,
+		holdTTL:         holdTTL,
+		logger:          logger.NewLogrusLogger(),
+		changes:         eventchange.Default(),
+	}
+}
+
+// Promote promotes waiting entries of the event, in the order they joined,
+// for as long as seats are free for any of them. Every promotion is published as a
+// waitlist.promoted message; publishing failures are logged, not returned,
+// since the promotion itself has already been committed.
+func (p *Promoter) Promote(ctx context.Context, eventID string) ([]Promotion, error) {
+	var promotions []Promotion
+	for {
+		now := time.Now().UTC()
+		var promoted []Promotion
+		if err := p.dbService.GetClient().Prisma.QueryRaw(
+			promoteNextSQL, eventID, uuid.NewString(), now, now.Add(p.holdTTL),
+		).Exec(ctx, &promoted); err != nil {
+			return promotions, err
+		}
+		if len(promoted) == 0 {
+			return promotions, nil
+		}
+
+		promotion := promoted[0]
+		promotions = append(promotions, promotion)
+		p.changes.Announce("", promotion.EventID)
+		p.publish(promotion)
+	}
+}
+
+// PromoteAfterRelease promotes waiting entries once seats of the event were
+// released. Errors are logged, so it can be used as a fire-and-forget hook.
+func (p *Promoter) PromoteAfterRelease(ctx context.Context, eventID string) {
+	promotions, err := p.Promote(ctx, eventID)
+	if err != nil {
+		p.logger.Errorf("Failed to promote waitlist of event %s: %v", eventID, err)
+	}
+	if len(promotions) > 0 {
+		p.logger.Infof("Promoted %d waitlist entries of event %s", len(promotions), eventID)
+	}
+}
+
+// publish announces a promotion. Nothing is published when RabbitMQ is disabled.
+func (p *Promoter) publish(promotion Promotion) {
+	if p.rabbitmqService == nil {
+		return
+	}
+
+	msg := rabbitmq.NewEventMessage(PromotedRoutingKey, promotion.EventID, p.source, promotion)
+	if err := p.rabbitmqService.PublishJSON(p.exchange, PromotedRoutingKey, msg); err != nil {
+		p.logger.Errorf("Failed to publish %s for waitlist entry %s: %v", PromotedRoutingKey, promotion.EntryID, err)
+	}
+}
@@ -1,13 +1,26 @@
 package router
 
 import (
+	"net/http"
+
+	"github.com/oskargbc/dws-event-service.git/configs"
 	"github.com/oskargbc/dws-event-service.git/docs"
+	"github.com/oskargbc/dws-event-service.git/internal/controllers/calendar"
+	"github.com/oskargbc/dws-event-service.git/internal/controllers/categories"
 	"github.com/oskargbc/dws-event-service.git/internal/controllers/events"
 	"github.com/oskargbc/dws-event-service.git/internal/controllers/health"
+	"github.com/oskargbc/dws-event-service.git/internal/controllers/holds"
+	"github.com/oskargbc/dws-event-service.git/internal/controllers/organizers"
 	rabbitmqController "github.com/oskargbc/dws-event-service.git/internal/controllers/rabbitmq"
+	"github.com/oskargbc/dws-event-service.git/internal/controllers/tenants"
+	waitlistController "github.com/oskargbc/dws-event-service.git/internal/controllers/waitlist"
 	"github.com/oskargbc/dws-event-service.git/internal/middlewares"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/eventchange"
 	"github.com/oskargbc/dws-event-service.git/internal/pkg/logger"
 	"github.com/oskargbc/dws-event-service.git/internal/pkg/metrics"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/problem"
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/storage"
+	"github.com/oskargbc/dws-event-service.git/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -32,7 +45,8 @@ func NewGinRouter(mode string) *gin.Engine {
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, x-api-key")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, x-api-key, X-Tenant, X-Request-ID, traceparent")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -43,17 +57,31 @@ func NewGinRouter(mode string) *gin.Engine {
 		c.Next()
 	})
 
+	// Trace ids let clients quote a request in bug reports; they are logged with server errors
+	router.Use(middlewares.TraceID())
 	router.Use(middlewares.ErrorHandle())
 
+	router.NoRoute(func(c *gin.Context) {
+		problem.Respond(c, http.StatusNotFound, problem.CodeNotFound, "Not found", "no endpoint matches "+c.Request.URL.Path)
+	})
+
 	// Prometheus metrics endpoint (no auth required)
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
+	// Resolves the tenant (university or city) of a request from the X-Tenant header,
+	// the host or the token's tenant claim; all data access is scoped to it
+	resolveTenant := middlewares.ResolveTenant(services.GetDatabaseSeviceInstance(), configs.GetEnvConfig().Tenancy)
+
 	healthController := health.NewController()
 	router.GET("/livez", healthController.Live)
 	router.GET("/readyz", healthController.Ready)
 	router.GET("/healthz", healthController.Ready)
 	router.GET("/_meta", healthController.Info)
 
+	// Branding of the tenant's white-label frontend (no auth, fetched before login)
+	tenantsController := tenants.NewController()
+	router.GET("/_meta/branding", resolveTenant, tenantsController.GetBranding)
+
 	/*
 	* ONLY FOR TESTING PURPOSESr 
 	*/
@@ -64,6 +92,19 @@ func NewGinRouter(mode string) *gin.Engine {
 	router.POST("/rabbitmq/publish", rabbitmqTestController.PublishTestMessage)
 	router.POST("/rabbitmq/setup", rabbitmqTestController.SetupTestExchangeAndQueue)
 
+	// Subscribable calendar feeds (no auth, calendar apps cannot send Keycloak tokens;
+	// personal feeds are protected by a signed token in the URL)
+	calendarController := calendar.NewController()
+	feeds := router.Group("/calendar", resolveTenant)
+	feeds.GET("/organizers/:organizerId/events.ics", calendarController.GetOrganizerFeed)
+	feeds.GET("/categories/:category/events.ics", calendarController.GetCategoryFeed)
+	feeds.GET("/users/:userId/events.ics", calendarController.GetUserFeed)
+
+	// Event images uploaded to the local storage backend (no auth, they are shown on public pages)
+	if uploadsPath, uploadsDir, ok := storage.LocalMount(configs.GetEnvConfig()); ok {
+		router.Static(uploadsPath, uploadsDir)
+	}
+
 	// Swagger UI and OpenAPI JSON endpoints (no auth)
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -73,14 +114,81 @@ func NewGinRouter(mode string) *gin.Engine {
 	*/
 	//	router.Use(APIKeyAuthMiddleware())
 
-	// API v1 routes (protected by Keycloak auth middleware)
-	v1 := router.Group("/api/v1", middlewares.KeycloakAuthMiddleware())
+	// API v1 routes (protected by Keycloak auth middleware, scoped to the request's tenant)
+	v1 := router.Group("/api/v1", middlewares.KeycloakAuthMiddleware(), resolveTenant)
 	{
 		eventsController := events.NewController()
+		// Drop cached events written by other replicas
+		if err := eventchange.Default().Subscribe(); err != nil {
+			logger.NewLogrusLogger().Errorf("Events: cache does not follow other replicas: %v", err)
+		}
 		v1.GET("/events", eventsController.GetEvents)
+		v1.GET("/events/search", eventsController.SearchEvents)
+		// Organisers export their own events, admins those of the whole tenant
+		v1.GET("/events/export", eventsController.ExportEvents)
+		v1.GET("/events/facets", eventsController.GetEventFacets)
 		v1.GET("/events/:id", eventsController.GetEventByID)
+		v1.GET("/events/:id/occurrences", eventsController.GetEventOccurrences)
+		v1.GET("/events/:id/ics", eventsController.GetEventICS)
+		v1.GET("/events/:id/ticket-types", eventsController.ListTicketTypes)
+		v1.GET("/events/:id/ticket-types/:ticketTypeId", eventsController.GetTicketType)
 		// Only users with the "Organiser" realm role may create events
-		v1.POST("/events", middlewares.RequireRole("Organiser"), eventsController.CreateEvent)
+		v1.POST("/events", middlewares.RequireRole(middlewares.RoleOrganiser), eventsController.CreateEvent)
+		v1.POST("/events/import", middlewares.RequireRole(middlewares.RoleOrganiser), eventsController.ImportEvents)
+		// Ownership (organizer or admin) is checked by the handlers
+		v1.PUT("/events/:id", eventsController.UpdateEvent)
+		v1.PATCH("/events/:id", eventsController.PatchEvent)
+		v1.POST("/events/:id/image", eventsController.UploadEventImage)
+		v1.POST("/events/:id/publish", eventsController.PublishEvent)
+		v1.POST("/events/:id/postpone", eventsController.PostponeEvent)
+		v1.POST("/events/:id/cancel", eventsController.CancelEvent)
+		v1.POST("/events/:id/archive", eventsController.ArchiveEvent)
+		v1.PATCH("/events/:id/occurrences/:occurrence", eventsController.PatchEventOccurrence)
+		v1.POST("/events/:id/occurrences/:occurrence/cancel", eventsController.CancelEventOccurrence)
+		v1.POST("/events/:id/ticket-types", eventsController.CreateTicketType)
+		v1.PATCH("/events/:id/ticket-types/:ticketTypeId", eventsController.PatchTicketType)
+		v1.DELETE("/events/:id/ticket-types/:ticketTypeId", eventsController.DeleteTicketType)
+
+		// Organizers; only admins may create them for others, updates are checked by the handlers
+		organizersController := organizers.NewController()
+		v1.GET("/organizers", organizersController.GetOrganizers)
+		// Self-service profile of the caller's Keycloak user
+		v1.GET("/organizers/me", organizersController.GetMyOrganizer)
+		v1.POST("/organizers/me", middlewares.RequireRole(middlewares.RoleOrganiser), organizersController.CreateMyOrganizer)
+		v1.POST("/organizers/:id/claim", middlewares.RequireRole(middlewares.RoleOrganiser), organizersController.ClaimOrganizer)
+		v1.GET("/organizers/:id", organizersController.GetOrganizerByID)
+		v1.GET("/organizers/:id/events", eventsController.GetOrganizerEvents)
+		v1.POST("/organizers", middlewares.RequireRole(middlewares.RoleAdmin), organizersController.CreateOrganizer)
+		v1.PUT("/organizers/:id", organizersController.UpdateOrganizer)
+		v1.PATCH("/organizers/:id", organizersController.PatchOrganizer)
+
+		// Categories events are filed under; only admins may change them
+		categoriesController := categories.NewController()
+		v1.GET("/categories", categoriesController.GetCategories)
+		v1.GET("/categories/:id", categoriesController.GetCategoryByID)
+		v1.POST("/categories", middlewares.RequireRole(middlewares.RoleAdmin), categoriesController.CreateCategory)
+		v1.PATCH("/categories/:id", middlewares.RequireRole(middlewares.RoleAdmin), categoriesController.PatchCategory)
+		v1.DELETE("/categories/:id", middlewares.RequireRole(middlewares.RoleAdmin), categoriesController.DeleteCategory)
+
+		// Capacity holds for checkout flows; holds are only visible to the user that created them
+		holdsController := holds.NewController()
+		v1.POST("/events/:id/holds", holdsController.CreateHold)
+		v1.GET("/holds/:holdId", holdsController.GetHold)
+		v1.POST("/holds/:holdId/confirm", holdsController.ConfirmHold)
+		v1.POST("/holds/:holdId/release", holdsController.ReleaseHold)
+
+		// Waitlists for events without enough seats left; entries are promoted in the order they joined
+		waitlistsController := waitlistController.NewController()
+		v1.POST("/events/:id/waitlist", waitlistsController.JoinWaitlist)
+		v1.DELETE("/events/:id/waitlist", waitlistsController.LeaveWaitlist)
+		v1.GET("/events/:id/waitlist/position", waitlistsController.GetWaitlistPosition)
+
+		// Branding of the current tenant; only admins may change it
+		v1.PUT("/tenant/branding", middlewares.RequireRole(middlewares.RoleAdmin), tenantsController.UpdateBranding)
+		v1.PATCH("/tenant/branding", middlewares.RequireRole(middlewares.RoleAdmin), tenantsController.PatchBranding)
+
+		// Subscription link of the caller's personal calendar feed
+		v1.GET("/calendar/feed", calendarController.GetPersonalFeedURL)
 	}
 
 	return router
@@ -0,0 +1,16 @@
+package services
+
+import (
+	"context"
+
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// FindCategories returns the categories of the tenant ordered by name
+func (d *DatabaseService) FindCategories(ctx context.Context, tenantID string) ([]db.CategoryModel, error) {
+	return d.client.Category.FindMany(
+		db.Category.TenantID.Equals(tenantID),
+	).OrderBy(
+		db.Category.Name.Order(db.SortOrderAsc),
+	).Exec(ctx)
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/oskargbc/dws-event-service.git/internal/pkg/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultSweepInterval is used when no sweep interval is configured
+	DefaultSweepInterval = 30 * time.Second
+
+	// sweepBatchSize limits how many holds a single statement expires
+	sweepBatchSize = 500
+)
+
+// expireHoldsSQL expires a batch of active holds whose TTL has passed and gives
+// their seats back. Rows locked by a concurrent confirm or release (or another
+// replica's sweeper) are skipped and picked up by a later sweep.
+const expireHoldsSQL = `
+WITH expired AS (
+    UPDATE "public"."CapacityHold"
+    SET "status" = 'EXPIRED', "updatedAt" = $1
+    WHERE "status" = 'ACTIVE' AND "id" IN (
+        SELECT "id" FROM "public"."CapacityHold"
+        WHERE "status" = 'ACTIVE' AND "expiresAt" <= $1
+        ORDER BY "expiresAt"
+        LIMIT $2
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING "eventId", "ticketTypeId", "quantity"
+), event AS (
+    UPDATE "public"."Event" e
+    SET "seatsHeld" = e."seatsHeld" - released."quantity", "updatedAt" = $1
+    FROM (SELECT "eventId", SUM("quantity") AS "quantity" FROM expired GROUP BY "eventId") released
+    WHERE e."id" = released."eventId"
+), tier AS (
+    UPDATE "public"."TicketType" t
+    SET "held" = t."held" - released."quantity"
+    FROM (SELECT "ticketTypeId", SUM("quantity") AS "quantity" FROM expired GROUP BY "ticketTypeId") released
+    WHERE t."id" = released."ticketTypeId"
+)
+SELECT "eventId", COUNT(*)::int AS "count" FROM expired GROUP BY "eventId"`
+
+// HoldSweeper periodically releases the seats of expired capacity holds
+type HoldSweeper struct {
+	dbService *DatabaseService
+	interval  time.Duration
+	onRelease func(ctx context.Context, eventID string)
+	logger    *logrus.Logger
+}
+
+// NewHoldSweeper creates a sweeper that runs every interval
+func NewHoldSweeper(dbService *DatabaseService, interval time.Duration) *HoldSweeper {
+	if interval <= 0 {
+		interval = DefaultSweepInterval
+	}
+	return &HoldSweeper{
+		dbService: dbService,
+		interval:  interval,
+		logger:    logger.NewLogrusLogger(),
+	}
+}
+
+// OnRelease registers a function that is called for every event whose seats
+// were released by a sweep, e.g. to promote its waitlist
+func (s *HoldSweeper) OnRelease(fn func(ctx context.Context, eventID string)) {
+	s.onRelease = fn
+}
+
+// Run sweeps expired holds until ctx is cancelled
+func (s *HoldSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.Sweep(ctx)
+			if err != nil {
+				s.logger.Errorf("Failed to sweep expired holds: %v", err)
+				continue
+			}
+			if expired > 0 {
+				s.logger.Infof("Released %d expired holds", expired)
+			}
+		}
+	}
+}
+
+// Sweep expires every hold whose TTL has passed and returns how many were expired
+func (s *HoldSweeper) Sweep(ctx context.Context) (int, error) {
+	total := 0
+	released := map[string]bool{}
+	defer func() {
+		if s.onRelease == nil {
+			return
+		}
+		for eventID := range released {
+			s.onRelease(ctx, eventID)
+		}
+	}()
+
+	for {
+		var result []struct {
+			EventID string `json:"eventId"`
+			Count   int    `json:"count"`
+		}
+		if err := s.dbService.GetClient().Prisma.QueryRaw(
+			expireHoldsSQL, time.Now().UTC(), sweepBatchSize,
+		).Exec(ctx, &result); err != nil {
+			return total, err
+		}
+
+		expired := 0
+		for _, row := range result {
+			expired += row.Count
+			released[row.EventID] = true
+		}
+		total += expired
+		if expired < sweepBatchSize {
+			return total, nil
+		}
+	}
+}
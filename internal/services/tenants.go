@@ -0,0 +1,21 @@
+package services
+
+import (
+	"context"
+
+	"github.com/oskargbc/dws-event-service.git/prisma/db"
+)
+
+// FindTenantBySlug returns the tenant with the given slug
+func (d *DatabaseService) FindTenantBySlug(ctx context.Context, slug string) (*db.TenantModel, error) {
+	return d.client.Tenant.FindUnique(
+		db.Tenant.Slug.Equals(slug),
+	).Exec(ctx)
+}
+
+// FindTenantByDomain returns the tenant whose frontend is served from host
+func (d *DatabaseService) FindTenantByDomain(ctx context.Context, host string) (*db.TenantModel, error) {
+	return d.client.Tenant.FindFirst(
+		db.Tenant.Domains.Has(host),
+	).Exec(ctx)
+}
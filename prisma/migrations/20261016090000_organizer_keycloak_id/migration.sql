@@ -0,0 +1,5 @@
+-- AlterTable
+ALTER TABLE "public"."Organizer" ADD COLUMN "keycloakId" TEXT;
+
+-- CreateIndex
+CREATE UNIQUE INDEX "Organizer_keycloakId_key" ON "public"."Organizer"("keycloakId");
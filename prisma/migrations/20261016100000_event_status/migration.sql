@@ -0,0 +1,11 @@
+-- CreateEnum
+CREATE TYPE "public"."EventStatus" AS ENUM ('DRAFT', 'PUBLISHED', 'POSTPONED', 'CANCELLED', 'ARCHIVED');
+
+-- AlterTable
+ALTER TABLE "public"."Event" ADD COLUMN "status" "public"."EventStatus" NOT NULL DEFAULT 'DRAFT';
+
+-- Events created before lifecycle states existed were already public
+UPDATE "public"."Event" SET "status" = 'PUBLISHED';
+
+-- CreateIndex
+CREATE INDEX "Event_status_idx" ON "public"."Event"("status");
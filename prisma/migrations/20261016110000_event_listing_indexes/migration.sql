@@ -0,0 +1,5 @@
+-- CreateIndex
+CREATE INDEX "Event_startDate_idx" ON "public"."Event"("startDate");
+
+-- CreateIndex
+CREATE INDEX "Event_organizerId_idx" ON "public"."Event"("organizerId");
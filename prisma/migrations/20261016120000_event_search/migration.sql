@@ -0,0 +1,12 @@
+-- AlterTable
+-- Generated column so every insert and update keeps the search document current.
+-- The 'simple' configuration avoids language-specific stemming because events
+-- are written in several languages.
+ALTER TABLE "public"."Event" ADD COLUMN "searchVector" tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce("name", '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce("location", '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce("description", '')), 'C')
+) STORED;
+
+-- CreateIndex
+CREATE INDEX "Event_searchVector_idx" ON "public"."Event" USING GIN ("searchVector");
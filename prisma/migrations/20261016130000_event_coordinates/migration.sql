@@ -0,0 +1,6 @@
+-- AlterTable
+ALTER TABLE "public"."Event" ADD COLUMN "latitude" DOUBLE PRECISION,
+ADD COLUMN "longitude" DOUBLE PRECISION;
+
+-- CreateIndex
+CREATE INDEX "Event_latitude_longitude_idx" ON "public"."Event"("latitude", "longitude");
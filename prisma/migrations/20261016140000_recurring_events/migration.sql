@@ -0,0 +1,28 @@
+-- AlterTable
+ALTER TABLE "public"."Event" ADD COLUMN "recurrenceRule" TEXT,
+ADD COLUMN "recurrenceExceptions" TIMESTAMP(3)[],
+ADD COLUMN "recurrenceEndsAt" TIMESTAMP(3);
+
+-- CreateTable
+CREATE TABLE "public"."EventOccurrenceOverride" (
+    "id" TEXT NOT NULL,
+    "eventId" TEXT NOT NULL,
+    "originalStart" TIMESTAMP(3) NOT NULL,
+    "startDate" TIMESTAMP(3),
+    "startTime" TIMESTAMP(3),
+    "endDate" TIMESTAMP(3),
+    "name" TEXT,
+    "description" TEXT,
+    "location" TEXT,
+    "cancelled" BOOLEAN NOT NULL DEFAULT false,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "EventOccurrenceOverride_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "EventOccurrenceOverride_eventId_originalStart_key" ON "public"."EventOccurrenceOverride"("eventId", "originalStart");
+
+-- AddForeignKey
+ALTER TABLE "public"."EventOccurrenceOverride" ADD CONSTRAINT "EventOccurrenceOverride_eventId_fkey" FOREIGN KEY ("eventId") REFERENCES "public"."Event"("id") ON DELETE CASCADE ON UPDATE CASCADE;
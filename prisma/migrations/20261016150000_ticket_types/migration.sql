@@ -0,0 +1,25 @@
+-- CreateTable
+CREATE TABLE "public"."TicketType" (
+    "id" TEXT NOT NULL,
+    "eventId" TEXT NOT NULL,
+    "name" TEXT NOT NULL,
+    "description" TEXT,
+    "price" DECIMAL(65,30) NOT NULL,
+    "quota" INTEGER NOT NULL,
+    "sold" INTEGER NOT NULL DEFAULT 0,
+    "saleStartsAt" TIMESTAMP(3),
+    "saleEndsAt" TIMESTAMP(3),
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "TicketType_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "TicketType_eventId_name_key" ON "public"."TicketType"("eventId", "name");
+
+-- AddForeignKey
+ALTER TABLE "public"."TicketType" ADD CONSTRAINT "TicketType_eventId_fkey" FOREIGN KEY ("eventId") REFERENCES "public"."Event"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- Never sell more tickets than the quota (not expressible in the Prisma schema)
+ALTER TABLE "public"."TicketType" ADD CONSTRAINT "TicketType_sold_check" CHECK ("sold" >= 0 AND "sold" <= "quota");
@@ -0,0 +1,43 @@
+-- CreateEnum
+CREATE TYPE "public"."HoldStatus" AS ENUM ('ACTIVE', 'CONFIRMED', 'RELEASED', 'EXPIRED');
+
+-- AlterTable
+ALTER TABLE "public"."Event" ADD COLUMN "seatsHeld" INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN "seatsSold" INTEGER NOT NULL DEFAULT 0;
+
+-- AlterTable
+ALTER TABLE "public"."TicketType" ADD COLUMN "held" INTEGER NOT NULL DEFAULT 0;
+
+-- CreateTable
+CREATE TABLE "public"."CapacityHold" (
+    "id" TEXT NOT NULL,
+    "eventId" TEXT NOT NULL,
+    "ticketTypeId" TEXT,
+    "quantity" INTEGER NOT NULL,
+    "status" "public"."HoldStatus" NOT NULL DEFAULT 'ACTIVE',
+    "holderId" TEXT NOT NULL,
+    "expiresAt" TIMESTAMP(3) NOT NULL,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "CapacityHold_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE INDEX "CapacityHold_status_expiresAt_idx" ON "public"."CapacityHold"("status", "expiresAt");
+
+-- CreateIndex
+CREATE INDEX "CapacityHold_eventId_idx" ON "public"."CapacityHold"("eventId");
+
+-- AddForeignKey
+ALTER TABLE "public"."CapacityHold" ADD CONSTRAINT "CapacityHold_eventId_fkey" FOREIGN KEY ("eventId") REFERENCES "public"."Event"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "public"."CapacityHold" ADD CONSTRAINT "CapacityHold_ticketTypeId_fkey" FOREIGN KEY ("ticketTypeId") REFERENCES "public"."TicketType"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- The seat counters can never exceed the capacity. Holds rely on these checks:
+-- an increment that would oversell fails and rolls back the whole statement.
+ALTER TABLE "public"."Event" ADD CONSTRAINT "Event_seats_check" CHECK ("seatsHeld" >= 0 AND "seatsSold" >= 0 AND "seatsHeld" + "seatsSold" <= "capacity");
+
+ALTER TABLE "public"."TicketType" DROP CONSTRAINT "TicketType_sold_check";
+ALTER TABLE "public"."TicketType" ADD CONSTRAINT "TicketType_seats_check" CHECK ("held" >= 0 AND "sold" >= 0 AND "held" + "sold" <= "quota");
@@ -0,0 +1,37 @@
+-- CreateEnum
+CREATE TYPE "public"."WaitlistStatus" AS ENUM ('WAITING', 'PROMOTED');
+
+-- CreateTable
+CREATE TABLE "public"."WaitlistEntry" (
+    "id" TEXT NOT NULL,
+    "eventId" TEXT NOT NULL,
+    "ticketTypeId" TEXT,
+    "userId" TEXT NOT NULL,
+    "quantity" INTEGER NOT NULL DEFAULT 1,
+    "status" "public"."WaitlistStatus" NOT NULL DEFAULT 'WAITING',
+    "holdId" TEXT,
+    "joinedAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "promotedAt" TIMESTAMP(3),
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "WaitlistEntry_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "WaitlistEntry_holdId_key" ON "public"."WaitlistEntry"("holdId");
+
+-- CreateIndex
+CREATE INDEX "WaitlistEntry_eventId_status_joinedAt_idx" ON "public"."WaitlistEntry"("eventId", "status", "joinedAt");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "WaitlistEntry_eventId_userId_key" ON "public"."WaitlistEntry"("eventId", "userId");
+
+-- AddForeignKey
+ALTER TABLE "public"."WaitlistEntry" ADD CONSTRAINT "WaitlistEntry_eventId_fkey" FOREIGN KEY ("eventId") REFERENCES "public"."Event"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "public"."WaitlistEntry" ADD CONSTRAINT "WaitlistEntry_ticketTypeId_fkey" FOREIGN KEY ("ticketTypeId") REFERENCES "public"."TicketType"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "public"."WaitlistEntry" ADD CONSTRAINT "WaitlistEntry_holdId_fkey" FOREIGN KEY ("holdId") REFERENCES "public"."CapacityHold"("id") ON DELETE SET NULL ON UPDATE CASCADE;
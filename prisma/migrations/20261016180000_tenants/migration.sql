@@ -0,0 +1,48 @@
+-- CreateTable
+CREATE TABLE "public"."Tenant" (
+    "id" TEXT NOT NULL,
+    "slug" TEXT NOT NULL,
+    "name" TEXT NOT NULL,
+    "domains" TEXT[],
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "Tenant_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "Tenant_slug_key" ON "public"."Tenant"("slug");
+
+-- Existing organizers and events move to the default tenant, which is
+-- resolved when a request names no tenant (tenancy.default_tenant)
+INSERT INTO "public"."Tenant" ("id", "slug", "name", "domains", "updatedAt")
+VALUES ('00000000-0000-0000-0000-000000000001', 'default', 'Default', ARRAY[]::TEXT[], CURRENT_TIMESTAMP);
+
+-- AlterTable
+ALTER TABLE "public"."Organizer" ADD COLUMN "tenantId" TEXT NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001';
+ALTER TABLE "public"."Organizer" ALTER COLUMN "tenantId" DROP DEFAULT;
+
+-- AlterTable
+ALTER TABLE "public"."Event" ADD COLUMN "tenantId" TEXT NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001';
+ALTER TABLE "public"."Event" ALTER COLUMN "tenantId" DROP DEFAULT;
+
+-- DropIndex
+DROP INDEX "public"."Organizer_email_key";
+
+-- DropIndex
+DROP INDEX "public"."Organizer_keycloakId_key";
+
+-- CreateIndex
+CREATE UNIQUE INDEX "Organizer_tenantId_email_key" ON "public"."Organizer"("tenantId", "email");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "Organizer_tenantId_keycloakId_key" ON "public"."Organizer"("tenantId", "keycloakId");
+
+-- CreateIndex
+CREATE INDEX "Event_tenantId_status_startDate_idx" ON "public"."Event"("tenantId", "status", "startDate");
+
+-- AddForeignKey
+ALTER TABLE "public"."Organizer" ADD CONSTRAINT "Organizer_tenantId_fkey" FOREIGN KEY ("tenantId") REFERENCES "public"."Tenant"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "public"."Event" ADD CONSTRAINT "Event_tenantId_fkey" FOREIGN KEY ("tenantId") REFERENCES "public"."Tenant"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
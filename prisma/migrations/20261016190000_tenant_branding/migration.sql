@@ -0,0 +1,21 @@
+-- CreateTable
+CREATE TABLE "public"."TenantBranding" (
+    "tenantId" TEXT NOT NULL,
+    "name" TEXT NOT NULL,
+    "logoUrl" TEXT,
+    "primaryColor" TEXT NOT NULL,
+    "secondaryColor" TEXT NOT NULL,
+    "accentColor" TEXT NOT NULL,
+    "backgroundColor" TEXT NOT NULL,
+    "textColor" TEXT NOT NULL,
+    "enabledCategories" TEXT[],
+    "defaultLocale" TEXT NOT NULL,
+    "timezone" TEXT NOT NULL,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "TenantBranding_pkey" PRIMARY KEY ("tenantId")
+);
+
+-- AddForeignKey
+ALTER TABLE "public"."TenantBranding" ADD CONSTRAINT "TenantBranding_tenantId_fkey" FOREIGN KEY ("tenantId") REFERENCES "public"."Tenant"("id") ON DELETE CASCADE ON UPDATE CASCADE;
@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "public"."Event" ADD COLUMN     "imageThumbnails" TEXT[];
@@ -0,0 +1,79 @@
+-- CreateTable
+CREATE TABLE "public"."Category" (
+    "id" TEXT NOT NULL,
+    "tenantId" TEXT NOT NULL,
+    "slug" TEXT NOT NULL,
+    "name" TEXT NOT NULL,
+    "parentId" TEXT,
+    "icon" TEXT,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "Category_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "Category_tenantId_slug_key" ON "public"."Category"("tenantId", "slug");
+
+-- CreateIndex
+CREATE INDEX "Category_parentId_idx" ON "public"."Category"("parentId");
+
+-- AddForeignKey
+ALTER TABLE "public"."Category" ADD CONSTRAINT "Category_tenantId_fkey" FOREIGN KEY ("tenantId") REFERENCES "public"."Tenant"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "public"."Category" ADD CONSTRAINT "Category_parentId_fkey" FOREIGN KEY ("parentId") REFERENCES "public"."Category"("id") ON DELETE SET NULL ON UPDATE CASCADE;
+
+-- Every tenant starts with the categories that were built in so far
+INSERT INTO "public"."Category" ("id", "tenantId", "slug", "name", "updatedAt")
+SELECT gen_random_uuid()::TEXT, t."id", lower(c."name"), c."name", CURRENT_TIMESTAMP
+FROM "public"."Tenant" t
+CROSS JOIN (VALUES ('Music'), ('Party'), ('Sports'), ('Culture'), ('Workshop'),
+                   ('Lecture'), ('Career'), ('Food'), ('Outdoor'), ('Other')) AS c("name");
+
+-- AlterTable
+ALTER TABLE "public"."Event" ADD COLUMN "categoryId" TEXT;
+
+-- Map the free-text categories onto the categories of the event's tenant:
+-- case-insensitively, and singular for plural spellings ("Parties", "Workshops")
+UPDATE "public"."Event" e
+SET "categoryId" = c."id"
+FROM "public"."Category" c
+WHERE c."tenantId" = e."tenantId"
+  AND c."slug" IN (
+      lower(btrim(e."category")),
+      regexp_replace(lower(btrim(e."category")), 'ies$', 'y'),
+      regexp_replace(lower(btrim(e."category")), 's$', '')
+  );
+
+-- Values that match no category become categories of their own
+INSERT INTO "public"."Category" ("id", "tenantId", "slug", "name", "updatedAt")
+SELECT gen_random_uuid()::TEXT, u."tenantId", u."slug", min(u."name"), CURRENT_TIMESTAMP
+FROM (
+    SELECT e."tenantId",
+           btrim(e."category") AS "name",
+           coalesce(nullif(btrim(regexp_replace(lower(btrim(e."category")), '[^a-z0-9]+', '-', 'g'), '-'), ''), 'other') AS "slug"
+    FROM "public"."Event" e
+    WHERE e."categoryId" IS NULL
+) u
+WHERE NOT EXISTS (
+    SELECT 1 FROM "public"."Category" c WHERE c."tenantId" = u."tenantId" AND c."slug" = u."slug"
+)
+GROUP BY u."tenantId", u."slug";
+
+UPDATE "public"."Event" e
+SET "categoryId" = c."id"
+FROM "public"."Category" c
+WHERE e."categoryId" IS NULL
+  AND c."tenantId" = e."tenantId"
+  AND c."slug" = coalesce(nullif(btrim(regexp_replace(lower(btrim(e."category")), '[^a-z0-9]+', '-', 'g'), '-'), ''), 'other');
+
+-- AlterTable
+ALTER TABLE "public"."Event" ALTER COLUMN "categoryId" SET NOT NULL;
+ALTER TABLE "public"."Event" DROP COLUMN "category";
+
+-- CreateIndex
+CREATE INDEX "Event_categoryId_idx" ON "public"."Event"("categoryId");
+
+-- AddForeignKey
+ALTER TABLE "public"."Event" ADD CONSTRAINT "Event_categoryId_fkey" FOREIGN KEY ("categoryId") REFERENCES "public"."Category"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
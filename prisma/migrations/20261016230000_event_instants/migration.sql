@@ -0,0 +1,55 @@
+-- AlterTable
+ALTER TABLE "public"."Event" ADD COLUMN "startsAt" TIMESTAMP(3),
+ADD COLUMN "endsAt" TIMESTAMP(3),
+ADD COLUMN "timezone" TEXT,
+ADD COLUMN "allDay" BOOLEAN NOT NULL DEFAULT false;
+
+-- startTime held the start of the event and endDate its end. Some clients only
+-- sent the day as endDate (midnight UTC, before startTime); such events end at
+-- the end of that day. The events so far are kept as timed events in the
+-- timezone of their tenant's branding, or the default events.default_timezone.
+UPDATE "public"."Event"
+SET "startsAt" = "startTime",
+    "timezone" = COALESCE(
+        (SELECT b."timezone" FROM "public"."TenantBranding" b WHERE b."tenantId" = "Event"."tenantId"),
+        'Europe/Stockholm'
+    ),
+    "endsAt" = CASE
+        WHEN "endDate" >= "startTime" THEN "endDate"
+        WHEN "endDate" = date_trunc('day', "endDate") THEN GREATEST("startTime", "endDate" + INTERVAL '1 day')
+        ELSE "startTime"
+    END;
+
+ALTER TABLE "public"."Event" ALTER COLUMN "startsAt" SET NOT NULL,
+ALTER COLUMN "endsAt" SET NOT NULL,
+ALTER COLUMN "timezone" SET NOT NULL;
+
+-- DropIndex
+DROP INDEX "public"."Event_startDate_idx";
+
+-- DropIndex
+DROP INDEX "public"."Event_tenantId_status_startDate_idx";
+
+-- AlterTable
+ALTER TABLE "public"."Event" DROP COLUMN "startDate",
+DROP COLUMN "startTime",
+DROP COLUMN "endDate";
+
+-- CreateIndex
+CREATE INDEX "Event_tenantId_status_startsAt_idx" ON "public"."Event"("tenantId", "status", "startsAt");
+
+-- CreateIndex
+CREATE INDEX "Event_startsAt_idx" ON "public"."Event"("startsAt");
+
+-- AlterTable
+ALTER TABLE "public"."EventOccurrenceOverride" ADD COLUMN "startsAt" TIMESTAMP(3),
+ADD COLUMN "endsAt" TIMESTAMP(3);
+
+UPDATE "public"."EventOccurrenceOverride"
+SET "startsAt" = COALESCE("startTime", "startDate"),
+    "endsAt" = "endDate";
+
+-- AlterTable
+ALTER TABLE "public"."EventOccurrenceOverride" DROP COLUMN "startDate",
+DROP COLUMN "startTime",
+DROP COLUMN "endDate";
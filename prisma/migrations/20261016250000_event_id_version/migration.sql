@@ -0,0 +1,2 @@
+-- CreateIndex
+CREATE UNIQUE INDEX "Event_id_version_key" ON "public"."Event"("id", "version");
@@ -0,0 +1,8 @@
+-- CreateIndex
+CREATE INDEX "Organizer_tenantId_updatedAt_idx" ON "public"."Organizer"("tenantId", "updatedAt");
+
+-- CreateIndex
+CREATE INDEX "Category_tenantId_updatedAt_idx" ON "public"."Category"("tenantId", "updatedAt");
+
+-- CreateIndex
+CREATE INDEX "Event_tenantId_updatedAt_idx" ON "public"."Event"("tenantId", "updatedAt");